@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bufio"
+	"clusterix-code/internal/api/middleware"
 	"clusterix-code/internal/api_clients"
 	"clusterix-code/internal/config"
 	"clusterix-code/internal/data/db"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
 	"clusterix-code/internal/data/repositories"
 	"clusterix-code/internal/services"
 	"clusterix-code/internal/utils/di"
@@ -11,16 +16,41 @@ import (
 	"clusterix-code/internal/utils/logger"
 	"clusterix-code/internal/utils/mongo"
 	"clusterix-code/internal/utils/rabbitmq"
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 )
 
-var subdomainRegex = regexp.MustCompile(`^([a-zA-Z0-9]+)\.`)
+// subdomainRegex matches the IDE's "<fingerprint>." and a forwarded port's
+// "<port>-<fingerprint>." subdomains.
+var subdomainRegex = regexp.MustCompile(`^(?:(\d+)-)?([a-zA-Z0-9]+)\.`)
+
+// parseSubdomain extracts the workspace fingerprint from the host, and the
+// port for a forwarded port's subdomain. The port is zero for the IDE's.
+func parseSubdomain(host string) (fingerprint string, port int, ok bool) {
+	matches := subdomainRegex.FindStringSubmatch(host)
+	if len(matches) < 3 {
+		return "", 0, false
+	}
+	if matches[1] != "" {
+		port, err := strconv.Atoi(matches[1])
+		if err != nil || port < 1 || port > 65535 {
+			return "", 0, false
+		}
+		return matches[2], port, true
+	}
+	return matches[2], 0, true
+}
+
+// authCookieName is the cookie the proxy keeps the user's auth token in.
+const authCookieName = "clusterix_auth_token"
 
 type WorkspaceResponse struct {
 	Success bool `json:"success"`
@@ -34,20 +64,31 @@ type WorkspaceResponse struct {
 func proxyHandler(w http.ResponseWriter, r *http.Request, services *services.Services) {
 	host := r.Host // e.g. abc123.example.com
 
-	// Extract fingerprint
-	matches := subdomainRegex.FindStringSubmatch(host)
-	if len(matches) < 2 {
+	// Extract fingerprint, and the port for forwarded ports
+	fingerprint, port, ok := parseSubdomain(host)
+	if !ok {
 		http.Error(w, "Invalid subdomain format", http.StatusBadRequest)
 		return
 	}
-	fingerprint := matches[1]
 
-	// Get auth_token from query
-	//auth_token := r.URL.Query().Get("auth_token")
-	//if authToken == "" {
-	//	http.Error(w, "Missing auth_token", http.StatusBadRequest)
-	//	return
-	//}
+	// The IDE is first opened with the short lived auth_token in the query;
+	// the token is then kept in a cookie for the IDE's own requests.
+	authToken := r.URL.Query().Get("auth_token")
+	tokenFromQuery := authToken != ""
+	if !tokenFromQuery {
+		if cookie, err := r.Cookie(authCookieName); err == nil {
+			authToken = cookie.Value
+		}
+	}
+	if authToken == "" {
+		http.Error(w, "Missing auth_token", http.StatusUnauthorized)
+		return
+	}
+	payload, err := middleware.ParseShortAuthToken(authToken)
+	if err != nil {
+		http.Error(w, "Invalid auth_token", http.StatusUnauthorized)
+		return
+	}
 
 	response, err := services.Workspace.GetWorkspaceByFingerprint(r.Context(), fingerprint)
 
@@ -56,6 +97,40 @@ func proxyHandler(w http.ResponseWriter, r *http.Request, services *services.Ser
 		return
 	}
 
+	workerPort := response.WorkspaceConfig.WorkerPort
+	if port == 0 {
+		if !services.WorkspaceShare.CanAccessWorkspace(r.Context(), &payload.User, &response, enums.WorkspaceRoleUser) {
+			http.Error(w, "You do not have access to this workspace", http.StatusForbidden)
+			return
+		}
+	} else {
+		workspacePort, err := services.WorkspacePort.GetPort(r.Context(), response.ID, port)
+		if err != nil {
+			http.Error(w, "Port is not exposed", http.StatusNotFound)
+			return
+		}
+		if !canAccessPort(r.Context(), services, &payload.User, &response, workspacePort) {
+			http.Error(w, "You do not have access to this port", http.StatusForbidden)
+			return
+		}
+		if workspacePort.WorkerPort == nil {
+			http.Error(w, "Port is not forwarded", http.StatusBadGateway)
+			return
+		}
+		workerPort = workspacePort.WorkerPort
+	}
+
+	if tokenFromQuery {
+		http.SetCookie(w, &http.Cookie{
+			Name:     authCookieName,
+			Value:    authToken,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
 	// Call internal API to get worker port
 	//apiURL := fmt.Sprintf("http://api:8080/api/v1/workspaces/fingerprint/%s", fingerprint)
 	//req, err := http.NewRequest("GET", apiURL, nil)
@@ -95,7 +170,6 @@ func proxyHandler(w http.ResponseWriter, r *http.Request, services *services.Ser
 	//}
 	//workerPort := wsResp.Data.WorkspaceConfig.WorkerPort
 
-	workerPort := response.WorkspaceConfig.WorkerPort
 	if workerPort == nil || *workerPort == 0 {
 		http.Error(w, "Invalid worker_port", http.StatusInternalServerError)
 		return
@@ -120,6 +194,9 @@ func proxyHandler(w http.ResponseWriter, r *http.Request, services *services.Ser
 		// Set correct Host
 		req.Host = targetURL.Host
 
+		// The IDE has no use for the user's token
+		stripAuthCookie(req)
+
 		// Strip auth_token, preserve others
 		query := req.URL.Query()
 		query.Del("auth_token")
@@ -140,8 +217,71 @@ func proxyHandler(w http.ResponseWriter, r *http.Request, services *services.Ser
 	// Logging
 	log.Printf("Proxying %s → %s%s?%s", host, targetURL.String(), r.URL.Path, r.URL.RawQuery)
 
+	// Requests and the user's side of websockets keep the workspace from
+	// being stopped for being idle.
+	recordActivity := func() {
+		if err := services.Idle.RecordActivity(context.Background(), fingerprint); err != nil {
+			log.Printf("Failed to record activity of workspace %s: %v", fingerprint, err)
+		}
+	}
+	recordActivity()
+
 	// Serve
-	proxy.ServeHTTP(w, r)
+	proxy.ServeHTTP(&activityWriter{ResponseWriter: w, onActivity: recordActivity}, r)
+}
+
+// canAccessPort lets the workspace's owner open its private ports and any
+// member of its organization its organization ports.
+func canAccessPort(ctx context.Context, services *services.Services, user *dto.User, workspace *dto.WorkspaceDTO, port *models.WorkspacePort) bool {
+	if port.Visibility == enums.WorkspacePortVisibilityOrganization && user.OrganizationID == workspace.OrganizationID {
+		return true
+	}
+	return services.WorkspaceShare.CanAccessWorkspace(ctx, user, workspace, enums.WorkspaceRoleOwner)
+}
+
+// activityWriter hands the proxy a connection that reports the client's
+// traffic when a request is upgraded to a websocket.
+type activityWriter struct {
+	http.ResponseWriter
+	onActivity func()
+}
+
+func (w *activityWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &activityConn{Conn: conn, onActivity: w.onActivity}, rw, nil
+}
+
+// Unwrap lets the proxy flush streamed responses.
+func (w *activityWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type activityConn struct {
+	net.Conn
+	onActivity func()
+}
+
+func (c *activityConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.onActivity()
+	}
+	return n, err
+}
+
+// stripAuthCookie removes the proxy's auth cookie from a request, keeping
+// the IDE's own cookies.
+func stripAuthCookie(req *http.Request) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != authCookieName {
+			req.AddCookie(cookie)
+		}
+	}
 }
 
 func main() {
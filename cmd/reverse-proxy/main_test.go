@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestParseSubdomain(t *testing.T) {
+	tests := []struct {
+		name            string
+		host            string
+		wantFingerprint string
+		wantPort        int
+		wantOK          bool
+	}{
+		{name: "IDE", host: "abc123.clustercode.tech", wantFingerprint: "abc123", wantOK: true},
+		{name: "IDE with port in host", host: "abc123.clustercode.tech:443", wantFingerprint: "abc123", wantOK: true},
+		{name: "forwarded port", host: "3000-abc123.clustercode.tech", wantFingerprint: "abc123", wantPort: 3000, wantOK: true},
+		{name: "numeric fingerprint", host: "3000.clustercode.tech", wantFingerprint: "3000", wantOK: true},
+		{name: "port out of range", host: "70000-abc123.clustercode.tech", wantOK: false},
+		{name: "port zero", host: "0-abc123.clustercode.tech", wantOK: false},
+		{name: "non-numeric prefix", host: "app-abc123.clustercode.tech", wantOK: false},
+		{name: "no subdomain", host: "localhost", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fingerprint, port, ok := parseSubdomain(tt.host)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if fingerprint != tt.wantFingerprint || port != tt.wantPort {
+				t.Errorf("got (%q, %d), want (%q, %d)", fingerprint, port, tt.wantFingerprint, tt.wantPort)
+			}
+		})
+	}
+}
@@ -17,9 +17,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 
 	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -39,8 +41,64 @@ func main() {
 	c.Bootstrap()
 
 	services := di.Make[*services.Services](c)
+	cfg := di.Make[*config.Config](c)
 
-	//go devpod.StartPersistentSupervisor(context.Background(), repos.Workspace, workspaceSvc.UpdateWorkspaceStatus)
+	// The worker serves no API, so the devpod command metrics get a port of
+	// their own.
+	go func() {
+		http.Handle("/metrics", promhttp.Handler())
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", cfg.Worker.MetricsPort), nil); err != nil {
+			log.Printf("❌ Could not serve worker metrics: %v", err)
+		}
+	}()
+
+	// The unique lock keeps a single reconciliation queued fleet-wide even
+	// though every worker runs its own scheduler.
+	scheduler := jobs.NewAsynqScheduler()
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", cfg.Reconciler.Interval),
+		tasks.NewReconcileWorkspacesTask(),
+		asynq.Unique(cfg.Reconciler.Interval),
+		asynq.MaxRetry(0),
+	); err != nil {
+		log.Fatalf("❌ Could not register workspace reconciler: %v", err)
+	}
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", cfg.Budgets.EvaluationInterval),
+		tasks.NewEvaluateBudgetsTask(),
+		asynq.Unique(cfg.Budgets.EvaluationInterval),
+		asynq.MaxRetry(0),
+	); err != nil {
+		log.Fatalf("❌ Could not register budget evaluation: %v", err)
+	}
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", cfg.Idle.CheckInterval),
+		tasks.NewStopIdleWorkspacesTask(),
+		asynq.Unique(cfg.Idle.CheckInterval),
+		asynq.MaxRetry(0),
+	); err != nil {
+		log.Fatalf("❌ Could not register idle workspace check: %v", err)
+	}
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", cfg.Schedules.CheckInterval),
+		tasks.NewRunWorkspaceSchedulesTask(),
+		asynq.Unique(cfg.Schedules.CheckInterval),
+		asynq.MaxRetry(0),
+	); err != nil {
+		log.Fatalf("❌ Could not register workspace schedules: %v", err)
+	}
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", cfg.Retention.CheckInterval),
+		tasks.NewApplyRetentionTask(),
+		asynq.Unique(cfg.Retention.CheckInterval),
+		asynq.MaxRetry(0),
+	); err != nil {
+		log.Fatalf("❌ Could not register workspace retention: %v", err)
+	}
+	if err := scheduler.Start(); err != nil {
+		log.Fatalf("❌ Could not start scheduler: %v", err)
+	}
+	defer scheduler.Shutdown()
 
 	server := jobs.NewAsynqServer()
 	mux := asynq.NewServeMux()
@@ -69,7 +127,7 @@ func main() {
 			return fmt.Errorf("invalid payload: %w", err)
 		}
 		log.Printf("🛠 Processing workspace restarting job for workspace ID %d", p.WorkspaceID)
-		return jobs.HandleRestartWorkspaceTask(ctx, t, services.Workspace, services.Publisher)
+		return jobs.HandleRestartWorkspaceTask(ctx, t, services.Workspace, services.Publisher, services.WorkspaceConfig)
 	})
 
 	mux.HandleFunc(tasks.TaskRebuildWorkspace, func(ctx context.Context, t *asynq.Task) error {
@@ -78,7 +136,7 @@ func main() {
 			return fmt.Errorf("invalid payload: %w", err)
 		}
 		log.Printf("🛠 Processing workspace rebuilding job for workspace ID %d", p.WorkspaceID)
-		return jobs.HandleRebuildWorkspaceTask(ctx, t, services.Workspace, services.Publisher)
+		return jobs.HandleRebuildWorkspaceTask(ctx, t, services.Workspace, services.Publisher, services.WorkspaceConfig)
 	})
 
 	mux.HandleFunc(tasks.TaskTerminateWorkspace, func(ctx context.Context, t *asynq.Task) error {
@@ -90,6 +148,63 @@ func main() {
 		return jobs.HandleTerminateWorkspaceTask(ctx, t, services.Workspace, services.Publisher)
 	})
 
+	mux.HandleFunc(tasks.TaskReconcileWorkspaces, func(ctx context.Context, t *asynq.Task) error {
+		log.Printf("🛠 Reconciling workspaces with the backend")
+		return jobs.HandleReconcileWorkspacesTask(ctx, t, services.Reconciler)
+	})
+
+	mux.HandleFunc(tasks.TaskEvaluateBudgets, func(ctx context.Context, t *asynq.Task) error {
+		log.Printf("🛠 Evaluating organization budgets")
+		return jobs.HandleEvaluateBudgetsTask(ctx, t, services.BudgetEvaluator)
+	})
+
+	mux.HandleFunc(tasks.TaskStopIdleWorkspaces, func(ctx context.Context, t *asynq.Task) error {
+		log.Printf("🛠 Stopping idle workspaces")
+		return jobs.HandleStopIdleWorkspacesTask(ctx, t, services.Idle)
+	})
+
+	mux.HandleFunc(tasks.TaskRunWorkspaceSchedules, func(ctx context.Context, t *asynq.Task) error {
+		log.Printf("🛠 Running workspace schedules")
+		return jobs.HandleRunWorkspaceSchedulesTask(ctx, t, services.WorkspaceSchedule)
+	})
+
+	mux.HandleFunc(tasks.TaskApplyRetention, func(ctx context.Context, t *asynq.Task) error {
+		log.Printf("🛠 Applying workspace retention")
+		return jobs.HandleApplyRetentionTask(ctx, t, services.Retention)
+	})
+
+	mux.HandleFunc(tasks.TaskRunBulkOperation, func(ctx context.Context, t *asynq.Task) error {
+		var p tasks.RunBulkOperationPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		log.Printf("🛠 Processing bulk operation %d", p.OperationID)
+		return jobs.HandleRunBulkOperationTask(ctx, t, services.WorkspaceAdmin)
+	})
+
+	mux.HandleFunc(tasks.TaskDeliverWebhook, func(ctx context.Context, t *asynq.Task) error {
+		var p tasks.DeliverWebhookPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		log.Printf("🛠 Delivering webhook delivery %d", p.DeliveryID)
+		return jobs.HandleDeliverWebhookTask(ctx, t, services.Webhook)
+	})
+
+	mux.HandleFunc(tasks.TaskForwardWorkspacePorts, func(ctx context.Context, t *asynq.Task) error {
+		var p tasks.ForwardWorkspacePortsPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		log.Printf("🛠 Forwarding the ports of workspace %d", p.WorkspaceID)
+		return jobs.HandleForwardWorkspacePortsTask(ctx, t, services.WorkspacePort)
+	})
+
+	// Port forwards live in the worker's memory, so none survived a restart.
+	if err := services.WorkspacePort.ResetForwards(context.Background()); err != nil {
+		log.Printf("❌ Could not reset workspace port forwards: %v", err)
+	}
+
 	log.Println("🚀 Worker starting to process jobs...")
 	if err := server.Run(mux); err != nil {
 		log.Fatalf("❌ Could not start worker server: %v", err)
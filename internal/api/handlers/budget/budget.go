@@ -0,0 +1,87 @@
+package budget
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetBudget(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	budget, err := h.services.Budget.GetBudget(c.Request.Context(), authUser.OrganizationID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, budget)
+}
+
+func (h *Handler) SetBudget(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.SetBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	budget, err := h.services.Budget.SetBudget(c.Request.Context(), authUser.OrganizationID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, budget)
+}
+
+func (h *Handler) DeleteBudget(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.Budget.DeleteBudget(c.Request.Context(), authUser.OrganizationID); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+func (h *Handler) GetAlerts(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	page, limit := pagination.Paginate(c)
+	alerts, err := h.services.Budget.GetAlerts(c.Request.Context(), authUser.OrganizationID, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, alerts)
+}
@@ -0,0 +1,260 @@
+package env_var
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+// envVarScope is the scope a request works on, resolved from the route
+// after checking the user may manage it.
+type envVarScope struct {
+	scope          enums.EnvVarScope
+	scopeID        uint64
+	organizationID uint32
+}
+
+type scopeResolver func(c *gin.Context, authUser *dto.User) (envVarScope, error)
+
+func (h *Handler) GetWorkspaceEnvVars(c *gin.Context)   { h.getEnvVars(c, h.workspaceScope) }
+func (h *Handler) CreateWorkspaceEnvVar(c *gin.Context) { h.createEnvVar(c, h.workspaceScope) }
+func (h *Handler) UpdateWorkspaceEnvVar(c *gin.Context) { h.updateEnvVar(c, h.workspaceScope) }
+func (h *Handler) DeleteWorkspaceEnvVar(c *gin.Context) { h.deleteEnvVar(c, h.workspaceScope) }
+
+func (h *Handler) GetRepositoryEnvVars(c *gin.Context)   { h.getEnvVars(c, h.repositoryScope) }
+func (h *Handler) CreateRepositoryEnvVar(c *gin.Context) { h.createEnvVar(c, h.repositoryScope) }
+func (h *Handler) UpdateRepositoryEnvVar(c *gin.Context) { h.updateEnvVar(c, h.repositoryScope) }
+func (h *Handler) DeleteRepositoryEnvVar(c *gin.Context) { h.deleteEnvVar(c, h.repositoryScope) }
+
+func (h *Handler) GetTemplateEnvVars(c *gin.Context)   { h.getEnvVars(c, h.templateScope) }
+func (h *Handler) CreateTemplateEnvVar(c *gin.Context) { h.createEnvVar(c, h.templateScope) }
+func (h *Handler) UpdateTemplateEnvVar(c *gin.Context) { h.updateEnvVar(c, h.templateScope) }
+func (h *Handler) DeleteTemplateEnvVar(c *gin.Context) { h.deleteEnvVar(c, h.templateScope) }
+
+func (h *Handler) GetOrganizationEnvVars(c *gin.Context)   { h.getEnvVars(c, h.organizationScope) }
+func (h *Handler) CreateOrganizationEnvVar(c *gin.Context) { h.createEnvVar(c, h.organizationScope) }
+func (h *Handler) UpdateOrganizationEnvVar(c *gin.Context) { h.updateEnvVar(c, h.organizationScope) }
+func (h *Handler) DeleteOrganizationEnvVar(c *gin.Context) { h.deleteEnvVar(c, h.organizationScope) }
+
+func (h *Handler) getEnvVars(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	envVars, err := h.services.EnvVar.GetEnvVars(c.Request.Context(), scope.scope, scope.scopeID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, envVars)
+}
+
+func (h *Handler) createEnvVar(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.CreateEnvVarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	envVar, err := h.services.EnvVar.CreateEnvVar(c.Request.Context(), scope.scope, scope.scopeID, scope.organizationID, authUser.ID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, envVar)
+}
+
+func (h *Handler) updateEnvVar(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	envVarID, err := parseID(c, "env_id", "ENV_VAR")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UpdateEnvVarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	envVar, err := h.services.EnvVar.UpdateEnvVar(c.Request.Context(), scope.scope, scope.scopeID, envVarID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, envVar)
+}
+
+func (h *Handler) deleteEnvVar(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	envVarID, err := parseID(c, "env_id", "ENV_VAR")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.EnvVar.DeleteEnvVar(c.Request.Context(), scope.scope, scope.scopeID, envVarID); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+// workspaceScope lets workspace owners manage their workspace's variables.
+func (h *Handler) workspaceScope(c *gin.Context, authUser *dto.User) (envVarScope, error) {
+	id, err := parseID(c, "id", "WORKSPACE")
+	if err != nil {
+		return envVarScope{}, err
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		return envVarScope{}, err
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
+		return envVarScope{}, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil)
+	}
+
+	return envVarScope{
+		scope:          enums.EnvVarScopeWorkspace,
+		scopeID:        workspace.ID,
+		organizationID: workspace.OrganizationID,
+	}, nil
+}
+
+// repositoryScope lets the admins of a repository's organization manage its
+// variables. The route is admin only.
+func (h *Handler) repositoryScope(c *gin.Context, authUser *dto.User) (envVarScope, error) {
+	id, err := parseID(c, "id", "REPOSITORY")
+	if err != nil {
+		return envVarScope{}, err
+	}
+
+	repository, err := h.services.Repository.GetRepository(c.Request.Context(), id)
+	if err != nil {
+		return envVarScope{}, err
+	}
+
+	if repository.OrganizationID != authUser.OrganizationID {
+		return envVarScope{}, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this repository",
+			nil)
+	}
+
+	return envVarScope{
+		scope:          enums.EnvVarScopeRepository,
+		scopeID:        repository.ID,
+		organizationID: repository.OrganizationID,
+	}, nil
+}
+
+// templateScope lets the admins of a template's organization manage its
+// variables. The route is admin only.
+func (h *Handler) templateScope(c *gin.Context, authUser *dto.User) (envVarScope, error) {
+	id, err := parseID(c, "id", "TEMPLATE")
+	if err != nil {
+		return envVarScope{}, err
+	}
+
+	// Templates of other organizations are reported as missing.
+	template, err := h.services.WorkspaceTemplate.GetTemplate(c.Request.Context(), authUser.OrganizationID, id)
+	if err != nil {
+		return envVarScope{}, err
+	}
+
+	return envVarScope{
+		scope:          enums.EnvVarScopeTemplate,
+		scopeID:        template.ID,
+		organizationID: template.OrganizationID,
+	}, nil
+}
+
+// organizationScope is the organization of the admin making the request.
+// The route is admin only.
+func (h *Handler) organizationScope(c *gin.Context, authUser *dto.User) (envVarScope, error) {
+	return envVarScope{
+		scope:          enums.EnvVarScopeOrganization,
+		scopeID:        uint64(authUser.OrganizationID),
+		organizationID: authUser.OrganizationID,
+	}, nil
+}
+
+func parseID(c *gin.Context, param string, resource string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_"+resource+"_ID",
+			"ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
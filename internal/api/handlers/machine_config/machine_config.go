@@ -3,6 +3,7 @@ package machine_config
 import (
 	"clusterix-code/internal/api/api_context"
 	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
 	"clusterix-code/internal/services"
 	"clusterix-code/internal/utils/errors"
 	"clusterix-code/internal/utils/pagination"
@@ -76,3 +77,28 @@ func (h *Handler) GetMachineConfig(c *gin.Context) {
 	}
 	handlers.SuccessResponse(c, res)
 }
+
+func (h *Handler) SetMachineConfigPrice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_MACHINE_CONFIG_ID",
+			"Machine Config ID must be a valid number",
+			err))
+		return
+	}
+
+	var req requests.SetMachineConfigPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	res, err := h.services.MachineConfig.SetHourlyPrice(c.Request.Context(), id, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, res)
+}
@@ -0,0 +1,56 @@
+package organization_setting
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetSettings(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	settings, err := h.services.OrganizationSetting.GetSettings(c.Request.Context(), authUser.OrganizationID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, settings)
+}
+
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UpdateOrganizationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	settings, err := h.services.OrganizationSetting.UpdateSettings(c.Request.Context(), authUser.OrganizationID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, settings)
+}
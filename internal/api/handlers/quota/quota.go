@@ -0,0 +1,138 @@
+package quota
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetQuotas(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	quotas, err := h.services.Quota.GetQuotas(c.Request.Context(), authUser.OrganizationID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, quotas)
+}
+
+func (h *Handler) GetOrganizationQuota(c *gin.Context) {
+	h.getQuota(c, nil)
+}
+
+func (h *Handler) SetOrganizationQuota(c *gin.Context) {
+	h.setQuota(c, nil)
+}
+
+func (h *Handler) DeleteOrganizationQuota(c *gin.Context) {
+	h.deleteQuota(c, nil)
+}
+
+func (h *Handler) GetUserQuota(c *gin.Context) {
+	userID, err := parseUserID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	h.getQuota(c, &userID)
+}
+
+func (h *Handler) SetUserQuota(c *gin.Context) {
+	userID, err := parseUserID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	h.setQuota(c, &userID)
+}
+
+func (h *Handler) DeleteUserQuota(c *gin.Context) {
+	userID, err := parseUserID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	h.deleteQuota(c, &userID)
+}
+
+func (h *Handler) getQuota(c *gin.Context, userID *uint64) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	quota, err := h.services.Quota.GetQuota(c.Request.Context(), authUser.OrganizationID, userID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, quota)
+}
+
+func (h *Handler) setQuota(c *gin.Context, userID *uint64) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.SetQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	quota, err := h.services.Quota.SetQuota(c.Request.Context(), authUser.OrganizationID, userID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, quota)
+}
+
+func (h *Handler) deleteQuota(c *gin.Context, userID *uint64) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.Quota.DeleteQuota(c.Request.Context(), authUser.OrganizationID, userID); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+func parseUserID(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_USER_ID",
+			"User ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
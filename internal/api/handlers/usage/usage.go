@@ -0,0 +1,82 @@
+package usage
+
+import (
+	"bytes"
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetUsage(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	report, err := h.services.Usage.GetUsage(c.Request.Context(), authUser.OrganizationID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, report)
+}
+
+// ExportUsage downloads the usage report as a CSV file with one line per
+// workspace, or as a JSON file with format=json.
+func (h *Handler) ExportUsage(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	report, err := h.services.Usage.GetUsage(c.Request.Context(), authUser.OrganizationID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("usage_%s_%s", report.From.Format(time.DateOnly), report.To.Format(time.DateOnly))
+	if req.Format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
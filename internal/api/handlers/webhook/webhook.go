@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the organization's webhooks and their delivery logs. Its
+// routes are admin only.
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	webhooks, err := h.services.Webhook.GetWebhooks(c.Request.Context(), authUser.OrganizationID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, webhooks)
+}
+
+func (h *Handler) GetWebhook(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "id", "WEBHOOK")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	webhook, err := h.services.Webhook.GetWebhook(c.Request.Context(), authUser.OrganizationID, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, webhook)
+}
+
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	webhook, err := h.services.Webhook.CreateWebhook(c.Request.Context(), authUser.OrganizationID, authUser.ID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, webhook)
+}
+
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "id", "WEBHOOK")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	webhook, err := h.services.Webhook.UpdateWebhook(c.Request.Context(), authUser.OrganizationID, id, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, webhook)
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "id", "WEBHOOK")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.Webhook.DeleteWebhook(c.Request.Context(), authUser.OrganizationID, id); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "id", "WEBHOOK")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	page, limit := pagination.Paginate(c)
+
+	response, err := h.services.Webhook.GetDeliveries(c.Request.Context(), authUser.OrganizationID, id, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, response)
+}
+
+func (h *Handler) Redeliver(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "id", "WEBHOOK")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	deliveryID, err := parseID(c, "delivery_id", "DELIVERY")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	delivery, err := h.services.Webhook.Redeliver(c.Request.Context(), authUser.OrganizationID, id, deliveryID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, delivery)
+}
+
+func parseID(c *gin.Context, param string, resource string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_"+resource+"_ID",
+			"ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
@@ -3,6 +3,8 @@
 package websocket
 
 import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
 	"clusterix-code/internal/services"
 	"clusterix-code/internal/websocket"
 	"github.com/gin-gonic/gin"
@@ -17,5 +19,11 @@ func NewHandler(services *services.Services) *Handler {
 }
 
 func (h *Handler) WebSocket(c *gin.Context) {
-	websocket.ServeWs(h.services.Socket.Config.Hub, c.Writer, c.Request)
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	websocket.ServeWs(h.services.Socket.Config.Hub, authUser, c.Writer, c.Request)
 }
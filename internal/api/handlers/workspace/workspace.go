@@ -4,6 +4,8 @@ import (
 	"clusterix-code/internal/api/api_context"
 	"clusterix-code/internal/api/handlers"
 	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/services"
 	"clusterix-code/internal/utils/errors"
 	"clusterix-code/internal/utils/pagination"
@@ -42,6 +44,25 @@ func (h *Handler) GetWorkspaces(c *gin.Context) {
 	handlers.SuccessResponse(c, response)
 }
 
+func (h *Handler) GetSharedWorkspaces(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	with := c.QueryArray("with")
+	page, limit := pagination.Paginate(c)
+
+	response, err := h.services.Workspace.GetSharedWorkspaces(ctx, authUser.ID, with, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, response)
+}
+
 func (h *Handler) GetWorkspace(c *gin.Context) {
 	authUser, err := api_context.AuthUser(c)
 	if err != nil {
@@ -77,8 +98,7 @@ func (h *Handler) GetWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -116,8 +136,7 @@ func (h *Handler) GetWorkspaceByFingerprint(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -194,8 +213,7 @@ func (h *Handler) UpdateWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -248,8 +266,7 @@ func (h *Handler) DeleteWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -258,7 +275,7 @@ func (h *Handler) DeleteWorkspace(c *gin.Context) {
 		return
 	}
 
-	err = h.services.Workspace.DeleteWorkspace(ctx, workspaceId)
+	err = h.services.Workspace.DeleteWorkspace(ctx, workspaceId, enums.WorkspaceActorUser, "")
 	if err != nil {
 		handlers.ErrorResponse(c, err)
 		return
@@ -303,8 +320,7 @@ func (h *Handler) StartWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOperator) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -358,8 +374,7 @@ func (h *Handler) StopWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOperator) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -413,8 +428,7 @@ func (h *Handler) RestartWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOperator) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -468,8 +482,7 @@ func (h *Handler) RebuildWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -523,8 +536,7 @@ func (h *Handler) TerminateWorkspace(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -540,3 +552,297 @@ func (h *Handler) TerminateWorkspace(c *gin.Context) {
 	}
 	handlers.SuccessResponse(c, repo)
 }
+
+func (h *Handler) CancelWorkspace(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspaceId := c.Param("id")
+	if workspaceId == "" {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"MISSING_WORKSPACE_ID",
+			"Workspace ID is required",
+			nil))
+		return
+	}
+	var req requests.WorkspaceActionRequest
+	id, err := strconv.ParseUint(workspaceId, 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+	req.ID = id
+	req.UserID = authUser.ID
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	// Validate user permission
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOperator) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	repo, err := h.services.Workspace.CancelWorkspaceAction(ctx, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, repo)
+}
+
+func (h *Handler) GetWorkspaceActions(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspaceId := c.Param("id")
+	if workspaceId == "" {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"MISSING_WORKSPACE_ID",
+			"Workspace ID is required",
+			nil))
+		return
+	}
+	id, err := strconv.ParseUint(workspaceId, 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	// Validate user permission
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	actions, err := h.services.Workspace.GetPendingWorkspaceActions(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, actions)
+}
+
+// GetWorkspaceEvents returns the workspace's status history, latest first.
+func (h *Handler) GetWorkspaceEvents(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	page, limit := pagination.Paginate(c)
+	events, err := h.services.Workspace.GetWorkspaceTimeline(ctx, id, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, events)
+}
+
+// GetWorkspaceRuns returns the workspace's action runs, latest first.
+func (h *Handler) GetWorkspaceRuns(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	page, limit := pagination.Paginate(c)
+	runs, err := h.services.Workspace.GetWorkspaceRuns(ctx, id, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, runs)
+}
+
+func (h *Handler) GetSupportedIDEs(c *gin.Context) {
+	handlers.SuccessResponse(c, dto.ToIDEDTOs(enums.SupportedIDEs()))
+}
+
+// SetIdleTimeout overrides the organization's idle timeout for the
+// workspace.
+func (h *Handler) SetIdleTimeout(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+
+	var req requests.SetIdleTimeoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	// Validate user permission
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	if err := h.services.Idle.SetIdleTimeout(ctx, id, req.IdleTimeoutMinutes); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	workspace.IdleTimeoutMinutes = req.IdleTimeoutMinutes
+	handlers.SuccessResponse(c, workspace)
+}
+
+// SetKeep exempts the workspace from the organization's retention policy.
+func (h *Handler) SetKeep(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+
+	var req requests.SetKeepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	// Validate user permission
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	if err := h.services.Retention.SetKeep(ctx, id, *req.Keep); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	workspace.Keep = *req.Keep
+	handlers.SuccessResponse(c, workspace)
+}
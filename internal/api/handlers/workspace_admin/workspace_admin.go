@@ -0,0 +1,146 @@
+package workspace_admin
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the admin API over all of the organization's workspaces.
+// Its routes are admin only.
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetWorkspaces(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var filter requests.AdminWorkspaceFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	with := c.QueryArray("with")
+	page, limit := pagination.Paginate(c)
+
+	response, err := h.services.WorkspaceAdmin.GetWorkspaces(c.Request.Context(), authUser.OrganizationID, filter, with, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, response)
+}
+
+func (h *Handler) CreateBulkOperation(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.BulkWorkspaceActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	operation, err := h.services.WorkspaceAdmin.CreateBulkOperation(c.Request.Context(), authUser.OrganizationID, authUser.ID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, operation)
+}
+
+func (h *Handler) GetBulkOperations(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	page, limit := pagination.Paginate(c)
+
+	response, err := h.services.WorkspaceAdmin.GetBulkOperations(c.Request.Context(), authUser.OrganizationID, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, response)
+}
+
+func (h *Handler) GetBulkOperation(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "BULK_OPERATION")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	operation, err := h.services.WorkspaceAdmin.GetBulkOperation(c.Request.Context(), authUser.OrganizationID, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, operation)
+}
+
+func (h *Handler) ReassignWorkspace(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseID(c, "WORKSPACE")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.ReassignWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.services.WorkspaceAdmin.ReassignWorkspace(c.Request.Context(), authUser.OrganizationID, id, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, workspace)
+}
+
+func parseID(c *gin.Context, resource string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_"+resource+"_ID",
+			"ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
@@ -3,6 +3,7 @@ package workspace_log
 import (
 	"clusterix-code/internal/api/api_context"
 	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/services"
 	"clusterix-code/internal/utils/errors"
 	"fmt"
@@ -55,8 +56,7 @@ func (h *Handler) GetWorkspaceLogs(c *gin.Context) {
 	}
 
 	// Validate user permission
-	permissionService := services.NewPermissionService()
-	if !permissionService.CanAccessWorkspace(ctx, authUser, &workspace) {
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
 		handlers.ErrorResponse(c, errors.NewError(
 			errors.ErrorTypeAuth,
 			"FORBIDDEN",
@@ -72,3 +72,54 @@ func (h *Handler) GetWorkspaceLogs(c *gin.Context) {
 	}
 	handlers.SuccessResponse(c, res)
 }
+
+// GetWorkspaceRunLogs returns the log lines of one run of the workspace.
+func (h *Handler) GetWorkspaceRunLogs(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"Workspace ID must be a valid number",
+			err))
+		return
+	}
+	runID, err := strconv.ParseUint(c.Param("run_id"), 10, 64)
+	if err != nil {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_RUN_ID",
+			"Run ID must be a valid number",
+			err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleViewer) {
+		handlers.ErrorResponse(c, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil))
+		return
+	}
+
+	logs, err := h.services.WorkspaceLog.GetRunLogs(ctx, id, runID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, logs)
+}
@@ -0,0 +1,152 @@
+package workspace_port
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetWorkspacePorts(c *gin.Context) {
+	workspace, err := h.workspace(c, enums.WorkspaceRoleViewer)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	ports, err := h.services.WorkspacePort.GetPorts(c.Request.Context(), workspace)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, ports)
+}
+
+func (h *Handler) CreateWorkspacePort(c *gin.Context) {
+	var req requests.CreateWorkspacePortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.workspace(c, enums.WorkspaceRoleOwner)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	port, err := h.services.WorkspacePort.CreatePort(c.Request.Context(), workspace, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, port)
+}
+
+func (h *Handler) UpdateWorkspacePort(c *gin.Context) {
+	number, err := parsePort(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UpdateWorkspacePortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.workspace(c, enums.WorkspaceRoleOwner)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	port, err := h.services.WorkspacePort.UpdatePort(c.Request.Context(), workspace, number, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, port)
+}
+
+func (h *Handler) DeleteWorkspacePort(c *gin.Context) {
+	number, err := parsePort(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.workspace(c, enums.WorkspaceRoleOwner)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.WorkspacePort.DeletePort(c.Request.Context(), workspace, number); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+// workspace loads the workspace of the route after checking the user has
+// the required role on it.
+func (h *Handler) workspace(c *gin.Context, required enums.WorkspaceRole) (dto.WorkspaceDTO, error) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return dto.WorkspaceDTO{}, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_WORKSPACE_ID",
+			"ID must be a valid number",
+			err)
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, required) {
+		return dto.WorkspaceDTO{}, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil)
+	}
+	return workspace, nil
+}
+
+func parsePort(c *gin.Context) (int, error) {
+	port, err := strconv.Atoi(c.Param("port"))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_PORT",
+			"Port must be a number between 1 and 65535",
+			err)
+	}
+	return port, nil
+}
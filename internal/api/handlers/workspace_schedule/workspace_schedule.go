@@ -0,0 +1,212 @@
+package workspace_schedule
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+// scheduleScope is the scope a request works on, resolved from the route
+// after checking the user may manage it.
+type scheduleScope struct {
+	scope          enums.WorkspaceScheduleScope
+	scopeID        uint64
+	organizationID uint32
+}
+
+type scopeResolver func(c *gin.Context, authUser *dto.User) (scheduleScope, error)
+
+func (h *Handler) GetWorkspaceSchedules(c *gin.Context)   { h.getSchedules(c, h.workspaceScope) }
+func (h *Handler) CreateWorkspaceSchedule(c *gin.Context) { h.createSchedule(c, h.workspaceScope) }
+func (h *Handler) UpdateWorkspaceSchedule(c *gin.Context) { h.updateSchedule(c, h.workspaceScope) }
+func (h *Handler) DeleteWorkspaceSchedule(c *gin.Context) { h.deleteSchedule(c, h.workspaceScope) }
+
+func (h *Handler) GetTemplateSchedules(c *gin.Context)   { h.getSchedules(c, h.templateScope) }
+func (h *Handler) CreateTemplateSchedule(c *gin.Context) { h.createSchedule(c, h.templateScope) }
+func (h *Handler) UpdateTemplateSchedule(c *gin.Context) { h.updateSchedule(c, h.templateScope) }
+func (h *Handler) DeleteTemplateSchedule(c *gin.Context) { h.deleteSchedule(c, h.templateScope) }
+
+func (h *Handler) getSchedules(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	schedules, err := h.services.WorkspaceSchedule.GetSchedules(c.Request.Context(), scope.scope, scope.scopeID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, schedules)
+}
+
+func (h *Handler) createSchedule(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.CreateWorkspaceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	schedule, err := h.services.WorkspaceSchedule.CreateSchedule(c.Request.Context(), scope.scope, scope.scopeID, scope.organizationID, authUser.ID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, schedule)
+}
+
+func (h *Handler) updateSchedule(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scheduleID, err := parseID(c, "schedule_id", "SCHEDULE")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UpdateWorkspaceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	schedule, err := h.services.WorkspaceSchedule.UpdateSchedule(c.Request.Context(), scope.scope, scope.scopeID, scheduleID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, schedule)
+}
+
+func (h *Handler) deleteSchedule(c *gin.Context, resolve scopeResolver) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scheduleID, err := parseID(c, "schedule_id", "SCHEDULE")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	scope, err := resolve(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.WorkspaceSchedule.DeleteSchedule(c.Request.Context(), scope.scope, scope.scopeID, scheduleID); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+// workspaceScope lets workspace owners manage their workspace's schedules.
+func (h *Handler) workspaceScope(c *gin.Context, authUser *dto.User) (scheduleScope, error) {
+	id, err := parseID(c, "id", "WORKSPACE")
+	if err != nil {
+		return scheduleScope{}, err
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		return scheduleScope{}, err
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
+		return scheduleScope{}, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil)
+	}
+
+	return scheduleScope{
+		scope:          enums.WorkspaceScheduleScopeWorkspace,
+		scopeID:        workspace.ID,
+		organizationID: workspace.OrganizationID,
+	}, nil
+}
+
+// templateScope lets the admins of a template's organization manage its
+// schedules. The route is admin only.
+func (h *Handler) templateScope(c *gin.Context, authUser *dto.User) (scheduleScope, error) {
+	id, err := parseID(c, "id", "TEMPLATE")
+	if err != nil {
+		return scheduleScope{}, err
+	}
+
+	// Templates of other organizations are reported as missing.
+	template, err := h.services.WorkspaceTemplate.GetTemplate(c.Request.Context(), authUser.OrganizationID, id)
+	if err != nil {
+		return scheduleScope{}, err
+	}
+
+	return scheduleScope{
+		scope:          enums.WorkspaceScheduleScopeTemplate,
+		scopeID:        template.ID,
+		organizationID: template.OrganizationID,
+	}, nil
+}
+
+func parseID(c *gin.Context, param string, resource string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_"+resource+"_ID",
+			"ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
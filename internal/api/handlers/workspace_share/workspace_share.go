@@ -0,0 +1,151 @@
+package workspace_share
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetShares(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.workspace(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	shares, err := h.services.WorkspaceShare.GetShares(c.Request.Context(), workspace.ID)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, shares)
+}
+
+func (h *Handler) ShareWorkspace(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	userID, err := parseID(c, "user_id", "USER")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.ShareWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.workspace(c, authUser)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	share, err := h.services.WorkspaceShare.ShareWorkspace(c.Request.Context(), &workspace, userID, authUser.ID, enums.WorkspaceRole(req.Role))
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, share)
+}
+
+// UnshareWorkspace revokes a user's access to the workspace. Besides the
+// owner, collaborators may remove themselves.
+func (h *Handler) UnshareWorkspace(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	userID, err := parseID(c, "user_id", "USER")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspaceID, err := parseID(c, "id", "WORKSPACE")
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if userID != authUser.ID {
+		workspace, err := h.workspace(c, authUser)
+		if err != nil {
+			handlers.ErrorResponse(c, err)
+			return
+		}
+		workspaceID = workspace.ID
+	}
+
+	if err := h.services.WorkspaceShare.UnshareWorkspace(c.Request.Context(), workspaceID, userID); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+// workspace loads the workspace of the route after checking the user owns
+// it. Only owners manage who a workspace is shared with.
+func (h *Handler) workspace(c *gin.Context, authUser *dto.User) (dto.WorkspaceDTO, error) {
+	id, err := parseID(c, "id", "WORKSPACE")
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	ctx := c.Request.Context()
+	workspace, err := h.services.Workspace.GetWorkspace(ctx, id)
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	if !h.services.WorkspaceShare.CanAccessWorkspace(ctx, authUser, &workspace, enums.WorkspaceRoleOwner) {
+		return dto.WorkspaceDTO{}, errors.NewError(
+			errors.ErrorTypeAuth,
+			"FORBIDDEN",
+			"You do not have access to this workspace",
+			nil)
+	}
+	return workspace, nil
+}
+
+func parseID(c *gin.Context, param string, resource string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_"+resource+"_ID",
+			"ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
@@ -0,0 +1,184 @@
+package workspace_template
+
+import (
+	"clusterix-code/internal/api/api_context"
+	"clusterix-code/internal/api/handlers"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+func NewHandler(services *services.Services) *Handler {
+	return &Handler{
+		services: services,
+	}
+}
+
+func (h *Handler) GetTemplates(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	query := c.Query("q")
+	with := c.QueryArray("with")
+	page, limit := pagination.Paginate(c)
+
+	response, err := h.services.WorkspaceTemplate.GetTemplates(ctx, authUser.OrganizationID, query, with, page, limit)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, response)
+}
+
+func (h *Handler) GetTemplate(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseTemplateID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	template, err := h.services.WorkspaceTemplate.GetTemplate(c.Request.Context(), authUser.OrganizationID, id)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, template)
+}
+
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.CreateWorkspaceTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	template, err := h.services.WorkspaceTemplate.CreateTemplate(c.Request.Context(), authUser.OrganizationID, authUser.ID, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, template)
+}
+
+func (h *Handler) UpdateTemplate(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseTemplateID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	var req requests.UpdateWorkspaceTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	template, err := h.services.WorkspaceTemplate.UpdateTemplate(c.Request.Context(), authUser.OrganizationID, id, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, template)
+}
+
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseTemplateID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	if err := h.services.WorkspaceTemplate.DeleteTemplate(c.Request.Context(), authUser.OrganizationID, id); err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, nil)
+}
+
+// CreateWorkspaceFromTemplate creates a workspace for the user with the
+// settings and environment of one of the organization's templates.
+func (h *Handler) CreateWorkspaceFromTemplate(c *gin.Context) {
+	authUser, err := api_context.AuthUser(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	id, err := parseTemplateID(c)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	// The body is optional; one click creates a workspace named after the
+	// template.
+	var req requests.CreateWorkspaceFromTemplateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handlers.ErrorResponse(c, err)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	workspaceReq, err := h.services.WorkspaceTemplate.NewWorkspaceRequest(ctx, authUser, id, req)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+
+	workspace, err := h.services.Workspace.CreateWorkspace(ctx, workspaceReq)
+	if err != nil {
+		handlers.ErrorResponse(c, err)
+		return
+	}
+	handlers.SuccessResponse(c, workspace)
+}
+
+func parseTemplateID(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.NewError(
+			errors.ErrorTypeBadRequest,
+			"INVALID_TEMPLATE_ID",
+			"Template ID must be a valid number",
+			err)
+	}
+	return id, nil
+}
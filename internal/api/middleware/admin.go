@@ -27,3 +27,23 @@ func AdminOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// PlatformAdminOnly guards the settings every organization shares, which no
+// organization's admin may change for the others.
+func PlatformAdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, exists := c.Get("authTokenPayload")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		tokenPayload := payload.(*dto.TokenPayload)
+
+		permissionService := services.NewPermissionService()
+		if !permissionService.IsPlatformAdmin(tokenPayload.Roles) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Platform admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -79,47 +79,51 @@ func AuthMiddlewareWithQueryParam() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.NewAuthenticationError("Unexpected signing method")
-			}
-			return []byte(os.Getenv("AUTH_JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
-			handlers.ErrorResponse(c, errors.NewAuthenticationError("Invalid or expired token"))
+		payload, err := ParseShortAuthToken(tokenStr)
+		if err != nil {
+			handlers.ErrorResponse(c, err)
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			handlers.ErrorResponse(c, errors.NewAuthenticationError("Invalid token claims"))
-			c.Abort()
-			return
-		}
+		c.Set("authTokenPayload", payload)
+		c.Next()
+	}
+}
 
-		if exp, ok := claims["exp"].(float64); !ok || int64(exp) < time.Now().Unix() {
-			handlers.ErrorResponse(c, errors.NewAuthenticationError("Token has expired"))
-			c.Abort()
-			return
+// ParseShortAuthToken validates a short lived token issued by the auth
+// endpoint and returns the user it was issued to.
+func ParseShortAuthToken(tokenStr string) (*dto.TokenPayload, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.NewAuthenticationError("Unexpected signing method")
 		}
+		return []byte(os.Getenv("AUTH_JWT_SECRET")), nil
+	})
 
-		if purpose, ok := claims["purpose"].(string); !ok || purpose != "ws-auth" {
-			handlers.ErrorResponse(c, errors.NewAuthenticationError("Invalid token purpose"))
-			c.Abort()
-			return
-		}
+	if err != nil || !token.Valid {
+		return nil, errors.NewAuthenticationError("Invalid or expired token")
+	}
 
-		payload := &dto.TokenPayload{}
-		if userID, ok := claims["user_id"].(float64); ok {
-			payload.User.ID = uint64(userID)
-		}
-		if orgID, ok := claims["org_id"].(float64); ok {
-			payload.User.OrganizationID = uint32(orgID)
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.NewAuthenticationError("Invalid token claims")
+	}
 
-		c.Set("authTokenPayload", payload)
-		c.Next()
+	if exp, ok := claims["exp"].(float64); !ok || int64(exp) < time.Now().Unix() {
+		return nil, errors.NewAuthenticationError("Token has expired")
+	}
+
+	if purpose, ok := claims["purpose"].(string); !ok || purpose != "ws-auth" {
+		return nil, errors.NewAuthenticationError("Invalid token purpose")
+	}
+
+	payload := &dto.TokenPayload{}
+	if userID, ok := claims["user_id"].(float64); ok {
+		payload.User.ID = uint64(userID)
+	}
+	if orgID, ok := claims["org_id"].(float64); ok {
+		payload.User.OrganizationID = uint32(orgID)
 	}
+	return payload, nil
 }
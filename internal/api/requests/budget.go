@@ -0,0 +1,6 @@
+package requests
+
+type SetBudgetRequest struct {
+	MonthlyAmount *float64 `json:"monthly_amount" binding:"required,gt=0"`
+	Policy        string   `json:"policy" binding:"required,oneof=warn block stop"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+type CreateEnvVarRequest struct {
+	Key      string `json:"key" binding:"required,max=255"`
+	Value    string `json:"value"`
+	IsSecret bool   `json:"is_secret"`
+}
+
+type UpdateEnvVarRequest struct {
+	Value    *string `json:"value" binding:"omitempty"`
+	IsSecret *bool   `json:"is_secret" binding:"omitempty"`
+}
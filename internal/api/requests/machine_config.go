@@ -0,0 +1,5 @@
+package requests
+
+type SetMachineConfigPriceRequest struct {
+	HourlyPrice *float64 `json:"hourly_price" binding:"required,min=0"`
+}
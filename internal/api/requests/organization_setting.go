@@ -0,0 +1,24 @@
+package requests
+
+// UpdateOrganizationSettingsRequest replaces the organization's settings;
+// omitted settings use the platform defaults.
+type UpdateOrganizationSettingsRequest struct {
+	// IdleTimeoutMinutes of 0 never stops idle workspaces.
+	IdleTimeoutMinutes *int `json:"idle_timeout_minutes" binding:"omitempty,min=0"`
+	// Stopped and failed workspaces are terminated after this many days;
+	// 0 keeps them.
+	StoppedRetentionDays *int `json:"stopped_retention_days" binding:"omitempty,min=0"`
+	FailedRetentionDays  *int `json:"failed_retention_days" binding:"omitempty,min=0"`
+}
+
+// SetIdleTimeoutRequest overrides the organization's idle timeout for a
+// workspace; null uses the organization's and 0 never stops it.
+type SetIdleTimeoutRequest struct {
+	IdleTimeoutMinutes *int `json:"idle_timeout_minutes" binding:"omitempty,min=0"`
+}
+
+// SetKeepRequest exempts a workspace from the retention policy, or makes it
+// subject to it again.
+type SetKeepRequest struct {
+	Keep *bool `json:"keep" binding:"required"`
+}
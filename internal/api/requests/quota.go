@@ -0,0 +1,11 @@
+package requests
+
+// SetQuotaRequest replaces a quota. Omitted limits are unlimited and an
+// empty allowed_categories allows every instance category.
+type SetQuotaRequest struct {
+	MaxWorkspaces        *int     `json:"max_workspaces" binding:"omitempty,min=0"`
+	MaxRunningWorkspaces *int     `json:"max_running_workspaces" binding:"omitempty,min=0"`
+	MaxCPUCores          *int     `json:"max_cpu_cores" binding:"omitempty,min=0"`
+	MaxMemoryGB          *float64 `json:"max_memory_gb" binding:"omitempty,min=0"`
+	AllowedCategories    []string `json:"allowed_categories"`
+}
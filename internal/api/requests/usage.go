@@ -0,0 +1,11 @@
+package requests
+
+// UsageRequest filters the usage report. From and to are dates (YYYY-MM-DD)
+// or RFC 3339 times; a date as to includes the whole day.
+type UsageRequest struct {
+	From         string `form:"from"`
+	To           string `form:"to"`
+	UserID       uint64 `form:"user_id"`
+	RepositoryID uint64 `form:"repository_id"`
+	Format       string `form:"format" binding:"omitempty,oneof=csv json"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+type UpdateUserPreferencesRequest struct {
+	DotfilesRepository      string  `json:"dotfiles_repository"`
+	DotfilesInstallScript   string  `json:"dotfiles_install_script"`
+	DefaultIDE              string  `json:"default_ide"`
+	DefaultColor            string  `json:"default_color" binding:"max=30"`
+	DefaultMachineConfigID  *uint64 `json:"default_machine_config_id"`
+	DefaultGitAccessTokenID *uint64 `json:"default_git_access_token_id"`
+}
@@ -0,0 +1,14 @@
+package requests
+
+// CreateWebhookRequest subscribes URL to the listed events, or to all of
+// them without any. Without a secret one is generated.
+type CreateWebhookRequest struct {
+	URL     string   `json:"url" binding:"required,url,startswith=http"`
+	Events  []string `json:"events" binding:"omitempty,dive,oneof=workspace.pending workspace.starting workspace.creating workspace.running workspace.stopping workspace.stopped workspace.restarting workspace.rebuilding workspace.terminating workspace.terminated workspace.failed workspace.cancelled"`
+	Secret  string   `json:"secret" binding:"omitempty,min=16"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// UpdateWebhookRequest replaces the whole webhook. Without a secret the
+// current one is kept.
+type UpdateWebhookRequest = CreateWebhookRequest
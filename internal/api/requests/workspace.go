@@ -1,16 +1,27 @@
 package requests
 
+import "clusterix-code/internal/data/enums"
+
+// CreateWorkspaceRequest leaves color, IDE, git access token and machine
+// config optional; the user's preferences fill them in.
 type CreateWorkspaceRequest struct {
 	Title            string   `json:"title" binding:"required"`
-	Color            string   `json:"color" binding:"required"`
-	IDE              string   `json:"ide" binding:"required"`
+	Color            string   `json:"color"`
+	IDE              string   `json:"ide"`
 	RepositoryID     uint64   `json:"repository_id" binding:"required"`
 	UserID           uint64   `json:"user_id" binding:"required"`
-	GitAccessTokenID uint64   `json:"git_access_token_id" binding:"required"`
+	GitAccessTokenID uint64   `json:"git_access_token_id"`
+	MachineConfigID  uint64   `json:"machine_config_id"`
 	OrganizationID   uint32   `json:"organization_id" binding:"required"`
 	ProviderID       uint64   `json:"provider_id" binding:"required"`
 	Status           string   `json:"status" binding:"required,oneof=running processing failed"`
 	Tags             []string `json:"tags"`
+	GitRefType       string   `json:"git_ref_type" binding:"omitempty,oneof=branch tag commit pull_request"`
+	GitRef           string   `json:"git_ref" binding:"required_with=GitRefType"`
+	Subfolder        string   `json:"subfolder"`
+	DevcontainerPath string   `json:"devcontainer_path"`
+	// TemplateID is set when the workspace is created from a template.
+	TemplateID uint64 `json:"-"`
 }
 
 type UpdateWorkspaceRequest struct {
@@ -22,9 +33,17 @@ type UpdateWorkspaceRequest struct {
 	GitAccessTokenID uint64   `json:"git_access_token_id" binding:"omitempty"`
 	ProviderID       uint64   `json:"provider_id" binding:"omitempty"`
 	Tags             []string `json:"tags"`
+	GitRefType       string   `json:"git_ref_type" binding:"omitempty,oneof=branch tag commit pull_request"`
+	GitRef           string   `json:"git_ref" binding:"required_with=GitRefType"`
+	Subfolder        string   `json:"subfolder"`
+	DevcontainerPath string   `json:"devcontainer_path"`
 }
 
 type WorkspaceActionRequest struct {
 	ID     uint64 `json:"id" binding:"required"`
 	UserID uint64 `json:"user_id" binding:"required"`
+	// Actor is set when the action is not the user's own doing.
+	Actor enums.WorkspaceActor `json:"-"`
+	// Reason is the actor's explanation, see models.WorkspaceAction.
+	Reason string `json:"-"`
 }
@@ -0,0 +1,27 @@
+package requests
+
+// AdminWorkspaceFilter selects workspaces of the organization, from the
+// query string when listing and from the body of a bulk operation. Empty
+// fields don't filter.
+type AdminWorkspaceFilter struct {
+	UserID          uint64 `form:"user_id" json:"user_id,omitempty"`
+	Status          string `form:"status" json:"status,omitempty" binding:"omitempty,oneof=pending starting creating running stopping stopped restarting rebuilding terminating terminated failed cancelled"`
+	MachineConfigID uint64 `form:"machine_config_id" json:"machine_config_id,omitempty"`
+	RepositoryID    uint64 `form:"repository_id" json:"repository_id,omitempty"`
+	TemplateID      uint64 `form:"template_id" json:"template_id,omitempty"`
+	Search          string `form:"q" json:"q,omitempty"`
+}
+
+// BulkWorkspaceActionRequest applies the action to every workspace matching
+// the filter.
+type BulkWorkspaceActionRequest struct {
+	Action string               `json:"action" binding:"required,oneof=start stop terminate"`
+	Filter AdminWorkspaceFilter `json:"filter"`
+}
+
+// ReassignWorkspaceRequest hands a workspace over to another user of the
+// organization. Without a git access token the user's default one is used.
+type ReassignWorkspaceRequest struct {
+	UserID           uint64 `json:"user_id" binding:"required"`
+	GitAccessTokenID uint64 `json:"git_access_token_id"`
+}
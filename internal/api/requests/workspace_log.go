@@ -5,4 +5,5 @@ type CreateWorkspaceLogRequest struct {
 	Text        string `json:"text" binding:"required"`
 	Type        string `json:"type" binding:"required"`
 	Time        string `json:"time" binding:"required"`
+	RunID       uint64 `json:"run_id"`
 }
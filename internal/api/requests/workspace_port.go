@@ -0,0 +1,14 @@
+package requests
+
+// CreateWorkspacePortRequest exposes a port of the workspace. Ports are
+// private unless made visible to the organization.
+type CreateWorkspacePortRequest struct {
+	Port       int    `json:"port" binding:"required,min=1,max=65535"`
+	Label      string `json:"label" binding:"max=100"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=private organization"`
+}
+
+type UpdateWorkspacePortRequest struct {
+	Label      string `json:"label" binding:"max=100"`
+	Visibility string `json:"visibility" binding:"required,oneof=private organization"`
+}
@@ -0,0 +1,14 @@
+package requests
+
+// CreateWorkspaceScheduleRequest needs a start or a stop time, or both.
+// Without a timezone the times are UTC.
+type CreateWorkspaceScheduleRequest struct {
+	Days      []string `json:"days" binding:"required,min=1,dive,oneof=mon tue wed thu fri sat sun"`
+	StartTime *string  `json:"start_time" binding:"omitempty,datetime=15:04"`
+	StopTime  *string  `json:"stop_time" binding:"omitempty,datetime=15:04"`
+	Timezone  string   `json:"timezone" binding:"omitempty,timezone"`
+	Enabled   *bool    `json:"enabled"`
+}
+
+// UpdateWorkspaceScheduleRequest replaces the whole schedule.
+type UpdateWorkspaceScheduleRequest = CreateWorkspaceScheduleRequest
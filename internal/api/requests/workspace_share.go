@@ -0,0 +1,5 @@
+package requests
+
+type ShareWorkspaceRequest struct {
+	Role string `json:"role" binding:"required,oneof=viewer user operator"`
+}
@@ -0,0 +1,28 @@
+package requests
+
+type CreateWorkspaceTemplateRequest struct {
+	Name             string   `json:"name" binding:"required,max=255"`
+	Description      string   `json:"description"`
+	RepositoryID     uint64   `json:"repository_id" binding:"required"`
+	MachineConfigID  *uint64  `json:"machine_config_id"`
+	ProviderID       uint64   `json:"provider_id" binding:"required"`
+	IDE              string   `json:"ide"`
+	Color            string   `json:"color" binding:"max=30"`
+	GitRefType       string   `json:"git_ref_type" binding:"omitempty,oneof=branch tag commit pull_request"`
+	GitRef           string   `json:"git_ref" binding:"required_with=GitRefType"`
+	Subfolder        string   `json:"subfolder"`
+	DevcontainerPath string   `json:"devcontainer_path"`
+	Tags             []string `json:"tags"`
+}
+
+// UpdateWorkspaceTemplateRequest replaces the whole template.
+type UpdateWorkspaceTemplateRequest = CreateWorkspaceTemplateRequest
+
+// CreateWorkspaceFromTemplateRequest only carries what a template can't
+// know. Without a title the workspace is named after the template; color
+// and git access token fall back to the user's preferences.
+type CreateWorkspaceFromTemplateRequest struct {
+	Title            string `json:"title"`
+	Color            string `json:"color"`
+	GitAccessTokenID uint64 `json:"git_access_token_id"`
+}
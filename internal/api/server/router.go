@@ -2,15 +2,27 @@ package server
 
 import (
 	"clusterix-code/internal/api/handlers/auth"
+	"clusterix-code/internal/api/handlers/budget"
+	"clusterix-code/internal/api/handlers/env_var"
 	"clusterix-code/internal/api/handlers/git_access_token"
 	"clusterix-code/internal/api/handlers/health"
 	"clusterix-code/internal/api/handlers/machine_config"
 	"clusterix-code/internal/api/handlers/metrics"
+	"clusterix-code/internal/api/handlers/organization_setting"
 	"clusterix-code/internal/api/handlers/provider"
+	"clusterix-code/internal/api/handlers/quota"
 	"clusterix-code/internal/api/handlers/repository"
+	"clusterix-code/internal/api/handlers/usage"
+	"clusterix-code/internal/api/handlers/user_preference"
+	"clusterix-code/internal/api/handlers/webhook"
 	"clusterix-code/internal/api/handlers/websocket"
 	"clusterix-code/internal/api/handlers/workspace"
+	"clusterix-code/internal/api/handlers/workspace_admin"
 	"clusterix-code/internal/api/handlers/workspace_log"
+	"clusterix-code/internal/api/handlers/workspace_port"
+	"clusterix-code/internal/api/handlers/workspace_schedule"
+	"clusterix-code/internal/api/handlers/workspace_share"
+	"clusterix-code/internal/api/handlers/workspace_template"
 	"clusterix-code/internal/api/middleware"
 	"clusterix-code/internal/config"
 	"clusterix-code/internal/services"
@@ -71,6 +83,18 @@ func (r *Router) SetupRoutes() {
 	authHandler := auth.NewHandler(r.services)
 	socketHandler := websocket.NewHandler(r.services)
 	workspaceLogHandler := workspace_log.NewHandler(r.services)
+	envVarHandler := env_var.NewHandler(r.services)
+	userPreferenceHandler := user_preference.NewHandler(r.services)
+	workspaceShareHandler := workspace_share.NewHandler(r.services)
+	workspaceTemplateHandler := workspace_template.NewHandler(r.services)
+	quotaHandler := quota.NewHandler(r.services)
+	usageHandler := usage.NewHandler(r.services)
+	budgetHandler := budget.NewHandler(r.services)
+	organizationSettingHandler := organization_setting.NewHandler(r.services)
+	workspaceScheduleHandler := workspace_schedule.NewHandler(r.services)
+	workspacePortHandler := workspace_port.NewHandler(r.services)
+	workspaceAdminHandler := workspace_admin.NewHandler(r.services)
+	webhookHandler := webhook.NewHandler(r.services)
 
 	// Metrics and Health Check Endpoints
 	r.engine.GET("/metrics", metrics.Handler())
@@ -84,8 +108,12 @@ func (r *Router) SetupRoutes() {
 
 		protected.GET("/machine-configs", machineConfigHandler.GetMachineConfigs)
 		protected.GET("/machine-configs/:id", machineConfigHandler.GetMachineConfig)
+		protected.PUT("/machine-configs/:id/price", middleware.PlatformAdminOnly(), machineConfigHandler.SetMachineConfigPrice)
 		protected.GET("/providers", providerHandler.GetProviders)
 
+		protected.GET("/preferences", userPreferenceHandler.GetPreferences)
+		protected.PUT("/preferences", userPreferenceHandler.UpdatePreferences)
+
 		protected.GET("/git-access-tokens", gitAccessTokenHandler.GetUserAccessTokens)
 		protected.GET("/git-access-tokens/:id", gitAccessTokenHandler.GetUserAccessToken)
 		protected.POST("/git-access-tokens", gitAccessTokenHandler.CreateUserAccessToken)
@@ -100,19 +128,104 @@ func (r *Router) SetupRoutes() {
 		protected.GET("/user-repositories", repositoryHandler.GetUserRepositories)
 		protected.POST("/user-repositories", repositoryHandler.CreateUserRepository)
 
+		protected.GET("/quotas", middleware.AdminOnly(), quotaHandler.GetQuotas)
+		protected.GET("/quotas/organization", middleware.AdminOnly(), quotaHandler.GetOrganizationQuota)
+		protected.PUT("/quotas/organization", middleware.AdminOnly(), quotaHandler.SetOrganizationQuota)
+		protected.DELETE("/quotas/organization", middleware.AdminOnly(), quotaHandler.DeleteOrganizationQuota)
+		protected.GET("/quotas/users/:user_id", middleware.AdminOnly(), quotaHandler.GetUserQuota)
+		protected.PUT("/quotas/users/:user_id", middleware.AdminOnly(), quotaHandler.SetUserQuota)
+		protected.DELETE("/quotas/users/:user_id", middleware.AdminOnly(), quotaHandler.DeleteUserQuota)
+
+		protected.GET("/organization/settings", organizationSettingHandler.GetSettings)
+		protected.PUT("/organization/settings", middleware.AdminOnly(), organizationSettingHandler.UpdateSettings)
+
+		protected.GET("/usage", middleware.AdminOnly(), usageHandler.GetUsage)
+		protected.GET("/usage/export", middleware.AdminOnly(), usageHandler.ExportUsage)
+
+		protected.GET("/budget", middleware.AdminOnly(), budgetHandler.GetBudget)
+		protected.PUT("/budget", middleware.AdminOnly(), budgetHandler.SetBudget)
+		protected.DELETE("/budget", middleware.AdminOnly(), budgetHandler.DeleteBudget)
+		protected.GET("/budget/alerts", middleware.AdminOnly(), budgetHandler.GetAlerts)
+
+		protected.GET("/ides", workspaceHandler.GetSupportedIDEs)
+
+		protected.GET("/templates", workspaceTemplateHandler.GetTemplates)
+		protected.GET("/templates/:id", workspaceTemplateHandler.GetTemplate)
+		protected.POST("/templates", middleware.AdminOnly(), workspaceTemplateHandler.CreateTemplate)
+		protected.PUT("/templates/:id", middleware.AdminOnly(), workspaceTemplateHandler.UpdateTemplate)
+		protected.DELETE("/templates/:id", middleware.AdminOnly(), workspaceTemplateHandler.DeleteTemplate)
+
 		protected.GET("/workspaces", workspaceHandler.GetWorkspaces)
 		protected.GET("/workspaces/:id", workspaceHandler.GetWorkspace)
 		protected.POST("/workspaces", workspaceHandler.CreateWorkspace)
+		protected.POST("/workspaces/from-template/:id", workspaceTemplateHandler.CreateWorkspaceFromTemplate)
 		protected.PATCH("/workspaces/:id", workspaceHandler.UpdateWorkspace)
 		protected.DELETE("/workspaces/:id", workspaceHandler.DeleteWorkspace)
 		protected.GET("/workspaces/fingerprint/:fingerprint", workspaceHandler.GetWorkspaceByFingerprint)
 		protected.GET("/workspaces/:id/logs", workspaceLogHandler.GetWorkspaceLogs)
+		protected.GET("/workspaces/:id/actions", workspaceHandler.GetWorkspaceActions)
+		protected.GET("/workspaces/:id/events", workspaceHandler.GetWorkspaceEvents)
+		protected.GET("/workspaces/:id/runs", workspaceHandler.GetWorkspaceRuns)
+		protected.GET("/workspaces/:id/runs/:run_id/logs", workspaceLogHandler.GetWorkspaceRunLogs)
+		protected.GET("/shared-workspaces", workspaceHandler.GetSharedWorkspaces)
+
+		protected.GET("/workspaces/:id/shares", workspaceShareHandler.GetShares)
+		protected.PUT("/workspaces/:id/shares/:user_id", workspaceShareHandler.ShareWorkspace)
+		protected.DELETE("/workspaces/:id/shares/:user_id", workspaceShareHandler.UnshareWorkspace)
 
 		protected.POST("/workspaces/:id/start", workspaceHandler.StartWorkspace)
 		protected.POST("/workspaces/:id/stop", workspaceHandler.StopWorkspace)
 		protected.POST("/workspaces/:id/restart", workspaceHandler.RestartWorkspace)
 		protected.POST("/workspaces/:id/rebuild", workspaceHandler.RebuildWorkspace)
 		protected.POST("/workspaces/:id/terminate", workspaceHandler.TerminateWorkspace)
+		protected.POST("/workspaces/:id/cancel", workspaceHandler.CancelWorkspace)
+		protected.PUT("/workspaces/:id/idle-timeout", workspaceHandler.SetIdleTimeout)
+		protected.PUT("/workspaces/:id/keep", workspaceHandler.SetKeep)
+
+		protected.GET("/workspaces/:id/env", envVarHandler.GetWorkspaceEnvVars)
+		protected.POST("/workspaces/:id/env", envVarHandler.CreateWorkspaceEnvVar)
+		protected.PATCH("/workspaces/:id/env/:env_id", envVarHandler.UpdateWorkspaceEnvVar)
+		protected.DELETE("/workspaces/:id/env/:env_id", envVarHandler.DeleteWorkspaceEnvVar)
+		protected.GET("/repositories/:id/env", middleware.AdminOnly(), envVarHandler.GetRepositoryEnvVars)
+		protected.POST("/repositories/:id/env", middleware.AdminOnly(), envVarHandler.CreateRepositoryEnvVar)
+		protected.PATCH("/repositories/:id/env/:env_id", middleware.AdminOnly(), envVarHandler.UpdateRepositoryEnvVar)
+		protected.DELETE("/repositories/:id/env/:env_id", middleware.AdminOnly(), envVarHandler.DeleteRepositoryEnvVar)
+		protected.GET("/templates/:id/env", middleware.AdminOnly(), envVarHandler.GetTemplateEnvVars)
+		protected.POST("/templates/:id/env", middleware.AdminOnly(), envVarHandler.CreateTemplateEnvVar)
+		protected.PATCH("/templates/:id/env/:env_id", middleware.AdminOnly(), envVarHandler.UpdateTemplateEnvVar)
+		protected.DELETE("/templates/:id/env/:env_id", middleware.AdminOnly(), envVarHandler.DeleteTemplateEnvVar)
+		protected.GET("/organization/env", middleware.AdminOnly(), envVarHandler.GetOrganizationEnvVars)
+		protected.POST("/organization/env", middleware.AdminOnly(), envVarHandler.CreateOrganizationEnvVar)
+		protected.PATCH("/organization/env/:env_id", middleware.AdminOnly(), envVarHandler.UpdateOrganizationEnvVar)
+		protected.DELETE("/organization/env/:env_id", middleware.AdminOnly(), envVarHandler.DeleteOrganizationEnvVar)
+
+		protected.GET("/workspaces/:id/schedules", workspaceScheduleHandler.GetWorkspaceSchedules)
+		protected.POST("/workspaces/:id/schedules", workspaceScheduleHandler.CreateWorkspaceSchedule)
+		protected.PUT("/workspaces/:id/schedules/:schedule_id", workspaceScheduleHandler.UpdateWorkspaceSchedule)
+		protected.DELETE("/workspaces/:id/schedules/:schedule_id", workspaceScheduleHandler.DeleteWorkspaceSchedule)
+		protected.GET("/templates/:id/schedules", middleware.AdminOnly(), workspaceScheduleHandler.GetTemplateSchedules)
+		protected.POST("/templates/:id/schedules", middleware.AdminOnly(), workspaceScheduleHandler.CreateTemplateSchedule)
+		protected.PUT("/templates/:id/schedules/:schedule_id", middleware.AdminOnly(), workspaceScheduleHandler.UpdateTemplateSchedule)
+		protected.DELETE("/templates/:id/schedules/:schedule_id", middleware.AdminOnly(), workspaceScheduleHandler.DeleteTemplateSchedule)
+
+		protected.GET("/workspaces/:id/ports", workspacePortHandler.GetWorkspacePorts)
+		protected.POST("/workspaces/:id/ports", workspacePortHandler.CreateWorkspacePort)
+		protected.PUT("/workspaces/:id/ports/:port", workspacePortHandler.UpdateWorkspacePort)
+		protected.DELETE("/workspaces/:id/ports/:port", workspacePortHandler.DeleteWorkspacePort)
+
+		protected.GET("/admin/workspaces", middleware.AdminOnly(), workspaceAdminHandler.GetWorkspaces)
+		protected.PUT("/admin/workspaces/:id/owner", middleware.AdminOnly(), workspaceAdminHandler.ReassignWorkspace)
+		protected.POST("/admin/workspaces/bulk", middleware.AdminOnly(), workspaceAdminHandler.CreateBulkOperation)
+		protected.GET("/admin/bulk-operations", middleware.AdminOnly(), workspaceAdminHandler.GetBulkOperations)
+		protected.GET("/admin/bulk-operations/:id", middleware.AdminOnly(), workspaceAdminHandler.GetBulkOperation)
+
+		protected.GET("/webhooks", middleware.AdminOnly(), webhookHandler.GetWebhooks)
+		protected.POST("/webhooks", middleware.AdminOnly(), webhookHandler.CreateWebhook)
+		protected.GET("/webhooks/:id", middleware.AdminOnly(), webhookHandler.GetWebhook)
+		protected.PUT("/webhooks/:id", middleware.AdminOnly(), webhookHandler.UpdateWebhook)
+		protected.DELETE("/webhooks/:id", middleware.AdminOnly(), webhookHandler.DeleteWebhook)
+		protected.GET("/webhooks/:id/deliveries", middleware.AdminOnly(), webhookHandler.GetDeliveries)
+		protected.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", middleware.AdminOnly(), webhookHandler.Redeliver)
 	}
 
 	// Websocket
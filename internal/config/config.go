@@ -18,6 +18,15 @@ type Config struct {
 	ExternalServices ExternalServicesConfig
 	Redis            RedisConfig
 	MongoDB          MongoDBConfig
+	WorkspaceBackend WorkspaceBackendConfig
+	Reconciler       ReconcilerConfig
+	Budgets          BudgetsConfig
+	Idle             IdleConfig
+	Schedules        SchedulesConfig
+	Retention        RetentionConfig
+	Webhooks         WebhooksConfig
+	Worker           WorkerConfig
+	Secrets          SecretsConfig
 }
 
 type ExternalServicesConfig struct {
@@ -63,6 +72,75 @@ type MongoDBConfig struct {
 	AuthSource string
 }
 
+type WorkspaceBackendConfig struct {
+	Driver          string
+	FakeStepDelay   time.Duration
+	FakeFailActions []string
+	Devpod          DevpodConfig
+}
+
+type DevpodConfig struct {
+	Binary        string
+	Home          string
+	Context       string
+	Provider      string
+	UpTimeout     time.Duration
+	StopTimeout   time.Duration
+	DeleteTimeout time.Duration
+	StatusTimeout time.Duration
+	// InactivityTimeout is the AWS provider's idle timeout of SSH-only
+	// workspaces.
+	InactivityTimeout string
+}
+
+type SecretsConfig struct {
+	EncryptionKey string
+}
+
+type WorkerConfig struct {
+	MetricsPort int
+}
+
+type ReconcilerConfig struct {
+	Interval              time.Duration
+	GarbageCollectOrphans bool
+}
+
+type BudgetsConfig struct {
+	EvaluationInterval time.Duration
+}
+
+// IdleConfig drives the idle service. Timeout applies to organizations
+// that did not set their own; 0 never stops idle workspaces.
+type IdleConfig struct {
+	Timeout       time.Duration
+	WarningBefore time.Duration
+	CheckInterval time.Duration
+}
+
+type SchedulesConfig struct {
+	CheckInterval time.Duration
+}
+
+// RetentionConfig drives the retention service. The days apply to
+// organizations that did not set their own; 0 keeps workspaces forever.
+type RetentionConfig struct {
+	StoppedDays   int
+	FailedDays    int
+	WarningBefore time.Duration
+	CheckInterval time.Duration
+}
+
+// WebhooksConfig drives the delivery of webhooks. A failed delivery is
+// retried MaxRetries times, backing off between attempts. Endpoints on
+// loopback and private networks are refused unless AllowPrivateNetworks is
+// set; link-local and cloud metadata addresses always are.
+type WebhooksConfig struct {
+	Timeout              time.Duration
+	MaxRetries           int
+	AllowPrivateNetworks bool
+}
+
 type AuthConfig struct {
 	JWTSecret string
 }
@@ -195,6 +273,55 @@ func NewConfig() (*Config, error) {
 			Database:   GetEnv("MONGO_DB", "clusterix"),
 			AuthSource: GetEnv("MONGO_AUTH_SOURCE", "admin"),
 		},
+		WorkspaceBackend: WorkspaceBackendConfig{
+			Driver:          GetEnv("WORKSPACE_BACKEND", "devpod"),
+			FakeStepDelay:   getEnvAsDuration("FAKE_BACKEND_STEP_DELAY", 500*time.Millisecond),
+			FakeFailActions: getEnvAsSlice("FAKE_BACKEND_FAIL_ACTIONS", []string{}),
+			Devpod: DevpodConfig{
+				Binary:        GetEnv("DEVPOD_BINARY", "devpod"),
+				Home:          GetEnv("DEVPOD_HOME", ""),
+				Context:       GetEnv("DEVPOD_CONTEXT", ""),
+				Provider:      GetEnv("DEVPOD_PROVIDER", "aws"),
+				UpTimeout:     getEnvAsDuration("DEVPOD_UP_TIMEOUT", 25*time.Minute),
+				StopTimeout:   getEnvAsDuration("DEVPOD_STOP_TIMEOUT", 10*time.Minute),
+				DeleteTimeout: getEnvAsDuration("DEVPOD_DELETE_TIMEOUT", 10*time.Minute),
+				StatusTimeout: getEnvAsDuration("DEVPOD_STATUS_TIMEOUT", time.Minute),
+
+				InactivityTimeout: GetEnv("DEVPOD_INACTIVITY_TIMEOUT", "10m"),
+			},
+		},
+		Worker: WorkerConfig{
+			MetricsPort: getEnvAsInt("WORKER_METRICS_PORT", 9091),
+		},
+		Secrets: SecretsConfig{
+			EncryptionKey: GetEnv("SECRETS_ENCRYPTION_KEY", ""),
+		},
+		Reconciler: ReconcilerConfig{
+			Interval:              getEnvAsDuration("RECONCILER_INTERVAL", time.Minute),
+			GarbageCollectOrphans: getEnvAsBool("RECONCILER_GC_ORPHANS", false),
+		},
+		Budgets: BudgetsConfig{
+			EvaluationInterval: getEnvAsDuration("BUDGET_EVALUATION_INTERVAL", 15*time.Minute),
+		},
+		Idle: IdleConfig{
+			Timeout:       getEnvAsDuration("IDLE_TIMEOUT", 10*time.Minute),
+			WarningBefore: getEnvAsDuration("IDLE_WARNING_BEFORE", 2*time.Minute),
+			CheckInterval: getEnvAsDuration("IDLE_CHECK_INTERVAL", time.Minute),
+		},
+		Schedules: SchedulesConfig{
+			CheckInterval: getEnvAsDuration("SCHEDULE_CHECK_INTERVAL", time.Minute),
+		},
+		Retention: RetentionConfig{
+			StoppedDays:   getEnvAsInt("RETENTION_STOPPED_DAYS", 0),
+			FailedDays:    getEnvAsInt("RETENTION_FAILED_DAYS", 0),
+			WarningBefore: getEnvAsDuration("RETENTION_WARNING_BEFORE", 24*time.Hour),
+			CheckInterval: getEnvAsDuration("RETENTION_CHECK_INTERVAL", time.Hour),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:              getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxRetries:           getEnvAsInt("WEBHOOK_MAX_RETRIES", 8),
+			AllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
 	}, nil
 }
 
@@ -215,6 +342,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
 		if duration, err := time.ParseDuration(value); err == nil {
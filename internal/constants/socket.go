@@ -33,4 +33,8 @@ const (
 	WorkspaceCreated EventType = "workspace_created"
 	WorkspaceLogs    EventType = "workspace_log"
 	WorkspaceStatus  EventType = "workspace_status"
+	BudgetAlert      EventType = "budget_alert"
+
+	WorkspaceIdleWarning   EventType = "workspace_idle_warning"
+	WorkspaceExpiryWarning EventType = "workspace_expiry_warning"
 )
@@ -54,6 +54,7 @@ func (c *WorkspaceLogHandlerConsumer) HandleMessage(delivery amqp.Delivery) (err
 			Text:        logData.Text,
 			Type:        logData.Type,
 			Time:        logData.Time,
+			RunID:       logData.RunId,
 		}
 		if logData.Text != "" {
 			if err := c.services.WorkspaceLog.Create(context.Background(), logRequest); err != nil {
@@ -0,0 +1,25 @@
+package migrations
+
+type AddVersionToWorkspaces struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddVersionToWorkspaces) UpSql() string {
+	return `
+		ALTER TABLE workspaces
+		ADD COLUMN version BIGINT NOT NULL DEFAULT 0
+	`
+}
+
+func (m *AddVersionToWorkspaces) DownSql() string {
+	return `
+		ALTER TABLE workspaces
+		DROP COLUMN IF EXISTS version
+	`
+}
+
+func (m *AddVersionToWorkspaces) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304603_add_version_to_workspaces"
+}
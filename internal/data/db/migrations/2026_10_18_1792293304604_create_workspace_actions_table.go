@@ -0,0 +1,34 @@
+package migrations
+
+type CreateWorkspaceActionsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWorkspaceActionsTable) UpSql() string {
+	return `CREATE TABLE workspace_actions (
+		id BIGSERIAL PRIMARY KEY,
+		workspace_id BIGINT NOT NULL,
+		user_id BIGINT NOT NULL,
+		action VARCHAR(50) NOT NULL,
+		status VARCHAR(50) NOT NULL,
+		task_id VARCHAR(255),
+		message TEXT,
+		created_at TIMESTAMP DEFAULT NOW(),
+		started_at TIMESTAMP,
+		finished_at TIMESTAMP,
+
+		FOREIGN KEY (workspace_id) REFERENCES workspaces(id)
+	);
+	CREATE INDEX idx_workspace_actions_workspace_id ON workspace_actions (workspace_id);
+	CREATE UNIQUE INDEX idx_workspace_actions_one_running ON workspace_actions (workspace_id) WHERE status = 'running';`
+}
+
+func (m *CreateWorkspaceActionsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS workspace_actions"
+}
+
+func (m *CreateWorkspaceActionsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304604_create_workspace_actions_table"
+}
@@ -0,0 +1,27 @@
+package migrations
+
+type AddErrorToWorkspaceStatusEvents struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddErrorToWorkspaceStatusEvents) UpSql() string {
+	return `
+		ALTER TABLE workspace_status_events
+		ADD COLUMN error_code VARCHAR(50),
+		ADD COLUMN error_reason TEXT
+	`
+}
+
+func (m *AddErrorToWorkspaceStatusEvents) DownSql() string {
+	return `
+		ALTER TABLE workspace_status_events
+		DROP COLUMN IF EXISTS error_code,
+		DROP COLUMN IF EXISTS error_reason
+	`
+}
+
+func (m *AddErrorToWorkspaceStatusEvents) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304605_add_error_to_workspace_status_events"
+}
@@ -0,0 +1,31 @@
+package migrations
+
+type CreateEnvVarsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateEnvVarsTable) UpSql() string {
+	return `CREATE TABLE env_vars (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		scope VARCHAR(20) NOT NULL,
+		scope_id BIGINT NOT NULL,
+		key VARCHAR(255) NOT NULL,
+		value TEXT NOT NULL,
+		is_secret BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by_id BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW()
+	);
+	CREATE UNIQUE INDEX idx_env_vars_scope_key ON env_vars (scope, scope_id, key);`
+}
+
+func (m *CreateEnvVarsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS env_vars"
+}
+
+func (m *CreateEnvVarsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304606_create_env_vars_table"
+}
@@ -0,0 +1,31 @@
+package migrations
+
+type AddGitSourceToWorkspaces struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddGitSourceToWorkspaces) UpSql() string {
+	return `
+		ALTER TABLE workspaces
+		ADD COLUMN git_ref_type VARCHAR(20) NOT NULL DEFAULT '',
+		ADD COLUMN git_ref VARCHAR(255) NOT NULL DEFAULT '',
+		ADD COLUMN subfolder TEXT NOT NULL DEFAULT '',
+		ADD COLUMN devcontainer_path TEXT NOT NULL DEFAULT ''
+	`
+}
+
+func (m *AddGitSourceToWorkspaces) DownSql() string {
+	return `
+		ALTER TABLE workspaces
+		DROP COLUMN IF EXISTS git_ref_type,
+		DROP COLUMN IF EXISTS git_ref,
+		DROP COLUMN IF EXISTS subfolder,
+		DROP COLUMN IF EXISTS devcontainer_path
+	`
+}
+
+func (m *AddGitSourceToWorkspaces) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304607_add_git_source_to_workspaces"
+}
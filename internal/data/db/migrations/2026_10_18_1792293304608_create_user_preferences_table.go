@@ -0,0 +1,33 @@
+package migrations
+
+type CreateUserPreferencesTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateUserPreferencesTable) UpSql() string {
+	return `CREATE TABLE user_preferences (
+		user_id BIGINT PRIMARY KEY,
+		dotfiles_repository TEXT NOT NULL DEFAULT '',
+		dotfiles_install_script TEXT NOT NULL DEFAULT '',
+		default_ide VARCHAR(50) NOT NULL DEFAULT '',
+		default_color VARCHAR(30) NOT NULL DEFAULT '',
+		default_machine_config_id BIGINT,
+		default_git_access_token_id BIGINT,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (default_machine_config_id) REFERENCES machine_configs(id) ON DELETE SET NULL,
+		FOREIGN KEY (default_git_access_token_id) REFERENCES git_personal_access_tokens(id) ON DELETE SET NULL
+	)`
+}
+
+func (m *CreateUserPreferencesTable) DownSql() string {
+	return "DROP TABLE IF EXISTS user_preferences"
+}
+
+func (m *CreateUserPreferencesTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304608_create_user_preferences_table"
+}
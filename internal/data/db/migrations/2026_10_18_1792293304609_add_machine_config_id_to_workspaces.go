@@ -0,0 +1,25 @@
+package migrations
+
+type AddMachineConfigIdToWorkspaces struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddMachineConfigIdToWorkspaces) UpSql() string {
+	return `
+		ALTER TABLE workspaces
+		ADD COLUMN machine_config_id BIGINT REFERENCES machine_configs(id)
+	`
+}
+
+func (m *AddMachineConfigIdToWorkspaces) DownSql() string {
+	return `
+		ALTER TABLE workspaces
+		DROP COLUMN IF EXISTS machine_config_id
+	`
+}
+
+func (m *AddMachineConfigIdToWorkspaces) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304609_add_machine_config_id_to_workspaces"
+}
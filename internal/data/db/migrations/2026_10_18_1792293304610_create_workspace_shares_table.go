@@ -0,0 +1,33 @@
+package migrations
+
+type CreateWorkspaceSharesTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWorkspaceSharesTable) UpSql() string {
+	return `CREATE TABLE workspace_shares (
+		id BIGSERIAL PRIMARY KEY,
+		workspace_id BIGINT NOT NULL,
+		user_id BIGINT NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		shared_by_id BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+
+		FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (shared_by_id) REFERENCES users(id),
+		UNIQUE (workspace_id, user_id)
+	);
+	CREATE INDEX idx_workspace_shares_user_id ON workspace_shares(user_id)`
+}
+
+func (m *CreateWorkspaceSharesTable) DownSql() string {
+	return "DROP TABLE IF EXISTS workspace_shares"
+}
+
+func (m *CreateWorkspaceSharesTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304610_create_workspace_shares_table"
+}
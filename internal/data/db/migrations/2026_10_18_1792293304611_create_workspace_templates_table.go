@@ -0,0 +1,44 @@
+package migrations
+
+type CreateWorkspaceTemplatesTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWorkspaceTemplatesTable) UpSql() string {
+	return `CREATE TABLE workspace_templates (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		repository_id BIGINT NOT NULL,
+		machine_config_id BIGINT,
+		provider_id BIGINT NOT NULL,
+		ide VARCHAR(50) NOT NULL DEFAULT '',
+		color VARCHAR(30) NOT NULL DEFAULT '',
+		git_ref_type VARCHAR(20) NOT NULL DEFAULT '',
+		git_ref VARCHAR(255) NOT NULL DEFAULT '',
+		subfolder TEXT NOT NULL DEFAULT '',
+		devcontainer_path TEXT NOT NULL DEFAULT '',
+		tags TEXT[],
+		created_by_id BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+		deleted_at TIMESTAMP,
+
+		FOREIGN KEY (repository_id) REFERENCES repositories(id),
+		FOREIGN KEY (machine_config_id) REFERENCES machine_configs(id),
+		FOREIGN KEY (provider_id) REFERENCES providers(id),
+		FOREIGN KEY (created_by_id) REFERENCES users(id)
+	);
+	CREATE INDEX idx_workspace_templates_organization_id ON workspace_templates(organization_id)`
+}
+
+func (m *CreateWorkspaceTemplatesTable) DownSql() string {
+	return "DROP TABLE IF EXISTS workspace_templates"
+}
+
+func (m *CreateWorkspaceTemplatesTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304611_create_workspace_templates_table"
+}
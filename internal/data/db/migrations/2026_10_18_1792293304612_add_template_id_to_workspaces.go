@@ -0,0 +1,25 @@
+package migrations
+
+type AddTemplateIdToWorkspaces struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddTemplateIdToWorkspaces) UpSql() string {
+	return `
+		ALTER TABLE workspaces
+		ADD COLUMN template_id BIGINT REFERENCES workspace_templates(id) ON DELETE SET NULL
+	`
+}
+
+func (m *AddTemplateIdToWorkspaces) DownSql() string {
+	return `
+		ALTER TABLE workspaces
+		DROP COLUMN IF EXISTS template_id
+	`
+}
+
+func (m *AddTemplateIdToWorkspaces) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304612_add_template_id_to_workspaces"
+}
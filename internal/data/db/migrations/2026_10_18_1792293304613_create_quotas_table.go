@@ -0,0 +1,34 @@
+package migrations
+
+type CreateQuotasTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateQuotasTable) UpSql() string {
+	return `CREATE TABLE quotas (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		user_id BIGINT,
+		max_workspaces INT,
+		max_running_workspaces INT,
+		max_cpu_cores INT,
+		max_memory_gb DOUBLE PRECISION,
+		allowed_categories TEXT[],
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE UNIQUE INDEX idx_quotas_organization ON quotas (organization_id) WHERE user_id IS NULL;
+	CREATE UNIQUE INDEX idx_quotas_user ON quotas (organization_id, user_id) WHERE user_id IS NOT NULL`
+}
+
+func (m *CreateQuotasTable) DownSql() string {
+	return "DROP TABLE IF EXISTS quotas"
+}
+
+func (m *CreateQuotasTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304613_create_quotas_table"
+}
@@ -0,0 +1,25 @@
+package migrations
+
+type AddHourlyPriceToMachineConfigs struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddHourlyPriceToMachineConfigs) UpSql() string {
+	return `
+		ALTER TABLE machine_configs
+		ADD COLUMN hourly_price NUMERIC(10, 4) NOT NULL DEFAULT 0
+	`
+}
+
+func (m *AddHourlyPriceToMachineConfigs) DownSql() string {
+	return `
+		ALTER TABLE machine_configs
+		DROP COLUMN IF EXISTS hourly_price
+	`
+}
+
+func (m *AddHourlyPriceToMachineConfigs) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304614_add_hourly_price_to_machine_configs"
+}
@@ -0,0 +1,26 @@
+package migrations
+
+type CreateBudgetsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateBudgetsTable) UpSql() string {
+	return `CREATE TABLE budgets (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL UNIQUE,
+		monthly_amount NUMERIC(12, 2) NOT NULL,
+		policy VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW()
+	)`
+}
+
+func (m *CreateBudgetsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS budgets"
+}
+
+func (m *CreateBudgetsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304615_create_budgets_table"
+}
@@ -0,0 +1,29 @@
+package migrations
+
+type CreateBudgetAlertsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateBudgetAlertsTable) UpSql() string {
+	return `CREATE TABLE budget_alerts (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		period VARCHAR(7) NOT NULL,
+		threshold INT NOT NULL,
+		monthly_amount NUMERIC(12, 2) NOT NULL,
+		cost NUMERIC(12, 2) NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+
+		UNIQUE (organization_id, period, threshold)
+	)`
+}
+
+func (m *CreateBudgetAlertsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS budget_alerts"
+}
+
+func (m *CreateBudgetAlertsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304616_create_budget_alerts_table"
+}
@@ -0,0 +1,29 @@
+package migrations
+
+type AddIdleTrackingToWorkspaces struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddIdleTrackingToWorkspaces) UpSql() string {
+	return `
+		ALTER TABLE workspaces
+		ADD COLUMN last_activity_at TIMESTAMP,
+		ADD COLUMN idle_warned_at TIMESTAMP,
+		ADD COLUMN idle_timeout_minutes INT
+	`
+}
+
+func (m *AddIdleTrackingToWorkspaces) DownSql() string {
+	return `
+		ALTER TABLE workspaces
+		DROP COLUMN IF EXISTS last_activity_at,
+		DROP COLUMN IF EXISTS idle_warned_at,
+		DROP COLUMN IF EXISTS idle_timeout_minutes
+	`
+}
+
+func (m *AddIdleTrackingToWorkspaces) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304617_add_idle_tracking_to_workspaces"
+}
@@ -0,0 +1,24 @@
+package migrations
+
+type CreateOrganizationSettingsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateOrganizationSettingsTable) UpSql() string {
+	return `CREATE TABLE organization_settings (
+		organization_id INT PRIMARY KEY,
+		idle_timeout_minutes INT,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW()
+	)`
+}
+
+func (m *CreateOrganizationSettingsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS organization_settings"
+}
+
+func (m *CreateOrganizationSettingsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304618_create_organization_settings_table"
+}
@@ -0,0 +1,34 @@
+package migrations
+
+type CreateWorkspaceSchedulesTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWorkspaceSchedulesTable) UpSql() string {
+	return `CREATE TABLE workspace_schedules (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		scope VARCHAR(20) NOT NULL,
+		scope_id BIGINT NOT NULL,
+		days TEXT[] NOT NULL,
+		start_time VARCHAR(5),
+		stop_time VARCHAR(5),
+		timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		last_applied_at TIMESTAMP,
+		created_by_id BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW()
+	);
+	CREATE INDEX idx_workspace_schedules_scope ON workspace_schedules (scope, scope_id);`
+}
+
+func (m *CreateWorkspaceSchedulesTable) DownSql() string {
+	return "DROP TABLE IF EXISTS workspace_schedules"
+}
+
+func (m *CreateWorkspaceSchedulesTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304619_create_workspace_schedules_table"
+}
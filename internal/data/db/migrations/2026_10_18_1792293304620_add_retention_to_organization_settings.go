@@ -0,0 +1,23 @@
+package migrations
+
+type AddRetentionToOrganizationSettings struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddRetentionToOrganizationSettings) UpSql() string {
+	return `ALTER TABLE organization_settings
+		ADD COLUMN stopped_retention_days INT,
+		ADD COLUMN failed_retention_days INT`
+}
+
+func (m *AddRetentionToOrganizationSettings) DownSql() string {
+	return `ALTER TABLE organization_settings
+		DROP COLUMN IF EXISTS stopped_retention_days,
+		DROP COLUMN IF EXISTS failed_retention_days`
+}
+
+func (m *AddRetentionToOrganizationSettings) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304620_add_retention_to_organization_settings"
+}
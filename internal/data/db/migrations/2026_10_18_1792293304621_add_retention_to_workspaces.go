@@ -0,0 +1,25 @@
+package migrations
+
+type AddRetentionToWorkspaces struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddRetentionToWorkspaces) UpSql() string {
+	return `ALTER TABLE workspaces
+		ADD COLUMN keep BOOLEAN NOT NULL DEFAULT FALSE,
+		ADD COLUMN expiry_warned_at TIMESTAMP,
+		ADD COLUMN purged_at TIMESTAMP`
+}
+
+func (m *AddRetentionToWorkspaces) DownSql() string {
+	return `ALTER TABLE workspaces
+		DROP COLUMN IF EXISTS keep,
+		DROP COLUMN IF EXISTS expiry_warned_at,
+		DROP COLUMN IF EXISTS purged_at`
+}
+
+func (m *AddRetentionToWorkspaces) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304621_add_retention_to_workspaces"
+}
@@ -0,0 +1,34 @@
+package migrations
+
+type CreateBulkOperationsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateBulkOperationsTable) UpSql() string {
+	return `CREATE TABLE bulk_operations (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		user_id BIGINT NOT NULL,
+		action VARCHAR(50) NOT NULL,
+		filter TEXT NOT NULL,
+		status VARCHAR(50) NOT NULL,
+		total INT NOT NULL DEFAULT 0,
+		submitted INT NOT NULL DEFAULT 0,
+		skipped INT NOT NULL DEFAULT 0,
+		failed INT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+		finished_at TIMESTAMP
+	);
+	CREATE INDEX idx_bulk_operations_organization ON bulk_operations (organization_id, created_at);`
+}
+
+func (m *CreateBulkOperationsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS bulk_operations"
+}
+
+func (m *CreateBulkOperationsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304622_create_bulk_operations_table"
+}
@@ -0,0 +1,29 @@
+package migrations
+
+type CreateBulkOperationItemsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateBulkOperationItemsTable) UpSql() string {
+	return `CREATE TABLE bulk_operation_items (
+		id BIGSERIAL PRIMARY KEY,
+		bulk_operation_id BIGINT NOT NULL,
+		workspace_id BIGINT NOT NULL,
+		status VARCHAR(50) NOT NULL,
+		message TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+		FOREIGN KEY (bulk_operation_id) REFERENCES bulk_operations(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_bulk_operation_items_operation ON bulk_operation_items (bulk_operation_id);`
+}
+
+func (m *CreateBulkOperationItemsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS bulk_operation_items"
+}
+
+func (m *CreateBulkOperationItemsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304623_create_bulk_operation_items_table"
+}
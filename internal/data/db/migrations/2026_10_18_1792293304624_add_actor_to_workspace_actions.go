@@ -0,0 +1,21 @@
+package migrations
+
+type AddActorToWorkspaceActions struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddActorToWorkspaceActions) UpSql() string {
+	return `ALTER TABLE workspace_actions
+		ADD COLUMN actor VARCHAR(20) NOT NULL DEFAULT 'user'`
+}
+
+func (m *AddActorToWorkspaceActions) DownSql() string {
+	return `ALTER TABLE workspace_actions
+		DROP COLUMN IF EXISTS actor`
+}
+
+func (m *AddActorToWorkspaceActions) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304624_add_actor_to_workspace_actions"
+}
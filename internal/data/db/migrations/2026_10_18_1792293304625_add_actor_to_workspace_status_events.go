@@ -0,0 +1,23 @@
+package migrations
+
+type AddActorToWorkspaceStatusEvents struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddActorToWorkspaceStatusEvents) UpSql() string {
+	return `ALTER TABLE workspace_status_events
+		ADD COLUMN actor VARCHAR(20) NOT NULL DEFAULT '',
+		ADD COLUMN actor_user_id BIGINT`
+}
+
+func (m *AddActorToWorkspaceStatusEvents) DownSql() string {
+	return `ALTER TABLE workspace_status_events
+		DROP COLUMN IF EXISTS actor,
+		DROP COLUMN IF EXISTS actor_user_id`
+}
+
+func (m *AddActorToWorkspaceStatusEvents) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304625_add_actor_to_workspace_status_events"
+}
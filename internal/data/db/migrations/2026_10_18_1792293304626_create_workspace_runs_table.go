@@ -0,0 +1,37 @@
+package migrations
+
+type CreateWorkspaceRunsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWorkspaceRunsTable) UpSql() string {
+	return `CREATE TABLE workspace_runs (
+		id BIGSERIAL PRIMARY KEY,
+		workspace_id BIGINT NOT NULL,
+		action_id BIGINT,
+		action VARCHAR(50) NOT NULL,
+		actor VARCHAR(20) NOT NULL,
+		actor_user_id BIGINT,
+		task_id VARCHAR(255) NOT NULL DEFAULT '',
+		attempt INT NOT NULL DEFAULT 1,
+		status VARCHAR(20) NOT NULL,
+		error_code VARCHAR(50) NOT NULL DEFAULT '',
+		error_reason TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP,
+
+		FOREIGN KEY (workspace_id) REFERENCES workspaces(id),
+		FOREIGN KEY (action_id) REFERENCES workspace_actions(id)
+	);
+	CREATE INDEX idx_workspace_runs_workspace_id ON workspace_runs (workspace_id);`
+}
+
+func (m *CreateWorkspaceRunsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS workspace_runs"
+}
+
+func (m *CreateWorkspaceRunsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304626_create_workspace_runs_table"
+}
@@ -0,0 +1,21 @@
+package migrations
+
+type AddRunIDToWorkspaceStatusEvents struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddRunIDToWorkspaceStatusEvents) UpSql() string {
+	return `ALTER TABLE workspace_status_events
+		ADD COLUMN run_id BIGINT`
+}
+
+func (m *AddRunIDToWorkspaceStatusEvents) DownSql() string {
+	return `ALTER TABLE workspace_status_events
+		DROP COLUMN IF EXISTS run_id`
+}
+
+func (m *AddRunIDToWorkspaceStatusEvents) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304627_add_run_id_to_workspace_status_events"
+}
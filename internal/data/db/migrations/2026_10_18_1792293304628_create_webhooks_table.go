@@ -0,0 +1,30 @@
+package migrations
+
+type CreateWebhooksTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWebhooksTable) UpSql() string {
+	return `CREATE TABLE webhooks (
+		id BIGSERIAL PRIMARY KEY,
+		organization_id INT NOT NULL,
+		url TEXT NOT NULL,
+		events TEXT[],
+		secret TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by_id BIGINT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW()
+	);
+	CREATE INDEX idx_webhooks_organization_id ON webhooks (organization_id);`
+}
+
+func (m *CreateWebhooksTable) DownSql() string {
+	return "DROP TABLE IF EXISTS webhooks"
+}
+
+func (m *CreateWebhooksTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304628_create_webhooks_table"
+}
@@ -0,0 +1,37 @@
+package migrations
+
+type CreateWebhookDeliveriesTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWebhookDeliveriesTable) UpSql() string {
+	return `CREATE TABLE webhook_deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		webhook_id BIGINT NOT NULL,
+		workspace_id BIGINT NOT NULL,
+		event VARCHAR(50) NOT NULL,
+		payload TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		response_status INT,
+		response_body TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		redelivery_of_id BIGINT,
+		delivered_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+
+		FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);`
+}
+
+func (m *CreateWebhookDeliveriesTable) DownSql() string {
+	return "DROP TABLE IF EXISTS webhook_deliveries"
+}
+
+func (m *CreateWebhookDeliveriesTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304629_create_webhook_deliveries_table"
+}
@@ -0,0 +1,32 @@
+package migrations
+
+type CreateWorkspacePortsTable struct {
+	BaseMigration
+	Name string
+}
+
+func (m *CreateWorkspacePortsTable) UpSql() string {
+	return `CREATE TABLE workspace_ports (
+		id BIGSERIAL PRIMARY KEY,
+		workspace_id BIGINT NOT NULL,
+		port INT NOT NULL,
+		label VARCHAR(100) NOT NULL DEFAULT '',
+		visibility VARCHAR(20) NOT NULL,
+		source VARCHAR(20) NOT NULL,
+		worker_port INT,
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW(),
+
+		FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+		UNIQUE (workspace_id, port)
+	);`
+}
+
+func (m *CreateWorkspacePortsTable) DownSql() string {
+	return "DROP TABLE IF EXISTS workspace_ports"
+}
+
+func (m *CreateWorkspacePortsTable) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304630_create_workspace_ports_table"
+}
@@ -0,0 +1,21 @@
+package migrations
+
+type AddCancelRequestedAtToWorkspaceActions struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddCancelRequestedAtToWorkspaceActions) UpSql() string {
+	return `ALTER TABLE workspace_actions
+		ADD COLUMN cancel_requested_at TIMESTAMP`
+}
+
+func (m *AddCancelRequestedAtToWorkspaceActions) DownSql() string {
+	return `ALTER TABLE workspace_actions
+		DROP COLUMN IF EXISTS cancel_requested_at`
+}
+
+func (m *AddCancelRequestedAtToWorkspaceActions) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304631_add_cancel_requested_at_to_workspace_actions"
+}
@@ -0,0 +1,21 @@
+package migrations
+
+type AddReasonToWorkspaceActions struct {
+	BaseMigration
+	Name string
+}
+
+func (m *AddReasonToWorkspaceActions) UpSql() string {
+	return `ALTER TABLE workspace_actions
+		ADD COLUMN reason TEXT NOT NULL DEFAULT ''`
+}
+
+func (m *AddReasonToWorkspaceActions) DownSql() string {
+	return `ALTER TABLE workspace_actions
+		DROP COLUMN IF EXISTS reason`
+}
+
+func (m *AddReasonToWorkspaceActions) GetName() string {
+	// don't change this after the migration is applied
+	return "2026_10_18_1792293304632_add_reason_to_workspace_actions"
+}
@@ -28,6 +28,36 @@ var migrationsRegistry = []migrations.Migrant{
 	&migrations.AddFingerprintToWorkspaces{},
 	&migrations.CreateWorkspaceConfigsTable{},
 	&migrations.AddWorkspaceConfigIdToWorkspaces{},
+	&migrations.AddVersionToWorkspaces{},
+	&migrations.CreateWorkspaceActionsTable{},
+	&migrations.AddErrorToWorkspaceStatusEvents{},
+	&migrations.CreateEnvVarsTable{},
+	&migrations.AddGitSourceToWorkspaces{},
+	&migrations.CreateUserPreferencesTable{},
+	&migrations.AddMachineConfigIdToWorkspaces{},
+	&migrations.CreateWorkspaceSharesTable{},
+	&migrations.CreateWorkspaceTemplatesTable{},
+	&migrations.AddTemplateIdToWorkspaces{},
+	&migrations.CreateQuotasTable{},
+	&migrations.AddHourlyPriceToMachineConfigs{},
+	&migrations.CreateBudgetsTable{},
+	&migrations.CreateBudgetAlertsTable{},
+	&migrations.AddIdleTrackingToWorkspaces{},
+	&migrations.CreateOrganizationSettingsTable{},
+	&migrations.CreateWorkspaceSchedulesTable{},
+	&migrations.AddRetentionToOrganizationSettings{},
+	&migrations.AddRetentionToWorkspaces{},
+	&migrations.CreateBulkOperationsTable{},
+	&migrations.CreateBulkOperationItemsTable{},
+	&migrations.AddActorToWorkspaceActions{},
+	&migrations.AddActorToWorkspaceStatusEvents{},
+	&migrations.CreateWorkspaceRunsTable{},
+	&migrations.AddRunIDToWorkspaceStatusEvents{},
+	&migrations.CreateWebhooksTable{},
+	&migrations.CreateWebhookDeliveriesTable{},
+	&migrations.CreateWorkspacePortsTable{},
+	&migrations.AddCancelRequestedAtToWorkspaceActions{},
+	&migrations.AddReasonToWorkspaceActions{},
 }
 
 func findMigrationForRollback(name string) (migrations.Migrant, error) {
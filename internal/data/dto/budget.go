@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type BudgetDTO struct {
+	ID             uint64          `json:"id"`
+	OrganizationID uint32          `json:"organization_id"`
+	MonthlyAmount  float64         `json:"monthly_amount"`
+	Policy         string          `json:"policy"`
+	Spending       *BudgetSpendDTO `json:"spending,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// BudgetSpendDTO is what the organization spent of its budget this month.
+type BudgetSpendDTO struct {
+	Period   string  `json:"period"`
+	Cost     float64 `json:"cost"`
+	Percent  float64 `json:"percent"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+type BudgetAlertDTO struct {
+	ID             uint64    `json:"id"`
+	OrganizationID uint32    `json:"organization_id"`
+	Period         string    `json:"period"`
+	Threshold      int       `json:"threshold"`
+	MonthlyAmount  float64   `json:"monthly_amount"`
+	Cost           float64   `json:"cost"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func ToBudgetDTO(budget models.Budget) BudgetDTO {
+	return BudgetDTO{
+		ID:             budget.ID,
+		OrganizationID: budget.OrganizationID,
+		MonthlyAmount:  budget.MonthlyAmount,
+		Policy:         string(budget.Policy),
+		CreatedAt:      budget.CreatedAt,
+		UpdatedAt:      budget.UpdatedAt,
+	}
+}
+
+func ToBudgetAlertDTO(alert models.BudgetAlert) BudgetAlertDTO {
+	return BudgetAlertDTO{
+		ID:             alert.ID,
+		OrganizationID: alert.OrganizationID,
+		Period:         alert.Period,
+		Threshold:      alert.Threshold,
+		MonthlyAmount:  alert.MonthlyAmount,
+		Cost:           alert.Cost,
+		CreatedAt:      alert.CreatedAt,
+	}
+}
+
+func ToBudgetAlertDTOs(alerts []models.BudgetAlert) []BudgetAlertDTO {
+	result := make([]BudgetAlertDTO, len(alerts))
+	for i, alert := range alerts {
+		result[i] = ToBudgetAlertDTO(alert)
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"encoding/json"
+	"time"
+)
+
+type BulkOperationDTO struct {
+	ID         uint64                 `json:"id"`
+	UserID     uint64                 `json:"user_id"`
+	Action     string                 `json:"action"`
+	Filter     json.RawMessage        `json:"filter"`
+	Status     string                 `json:"status"`
+	Total      int                    `json:"total"`
+	Submitted  int                    `json:"submitted"`
+	Skipped    int                    `json:"skipped"`
+	Failed     int                    `json:"failed"`
+	CreatedAt  time.Time              `json:"created_at"`
+	FinishedAt *time.Time             `json:"finished_at"`
+	Items      []BulkOperationItemDTO `json:"items,omitempty"`
+}
+
+type BulkOperationItemDTO struct {
+	WorkspaceID uint64    `json:"workspace_id"`
+	Status      string    `json:"status"`
+	Message     string    `json:"message,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func ToBulkOperationDTO(operation models.BulkOperation) BulkOperationDTO {
+	dto := BulkOperationDTO{
+		ID:         operation.ID,
+		UserID:     operation.UserID,
+		Action:     string(operation.Action),
+		Filter:     json.RawMessage(operation.Filter),
+		Status:     string(operation.Status),
+		Total:      operation.Total,
+		Submitted:  operation.Submitted,
+		Skipped:    operation.Skipped,
+		Failed:     operation.Failed,
+		CreatedAt:  operation.CreatedAt,
+		FinishedAt: operation.FinishedAt,
+	}
+	for _, item := range operation.Items {
+		dto.Items = append(dto.Items, BulkOperationItemDTO{
+			WorkspaceID: item.WorkspaceID,
+			Status:      string(item.Status),
+			Message:     item.Message,
+			UpdatedAt:   item.UpdatedAt,
+		})
+	}
+	return dto
+}
+
+func ToBulkOperationDTOs(operations []models.BulkOperation) []BulkOperationDTO {
+	dtos := make([]BulkOperationDTO, len(operations))
+	for i, operation := range operations {
+		dtos[i] = ToBulkOperationDTO(operation)
+	}
+	return dtos
+}
@@ -1,10 +1,29 @@
 package dto
 
 type DevpodWorkspace struct {
-	AccessToken        string `json:"access_token"`
-	RepositoryUrl      string `json:"repository_url"`
-	DevpodWorkspaceId  uint64 `json:"devpod_workspace_id"`
-	DevpodWorkspaceIde string `json:"devpod_workspace_ide"`
-	AWSInstanceType    string `json:"aws_instance_type"`
-	UserId             uint64 `json:"user_id"`
+	AccessToken        string            `json:"access_token"`
+	RepositoryUrl      string            `json:"repository_url"`
+	DevpodWorkspaceId  uint64            `json:"devpod_workspace_id"`
+	DevpodWorkspaceIde string            `json:"devpod_workspace_ide"`
+	AWSInstanceType    string            `json:"aws_instance_type"`
+	UserId             uint64            `json:"user_id"`
+	Fingerprint        string            `json:"fingerprint"`
+	GitRefType         string            `json:"git_ref_type"`
+	GitRef             string            `json:"git_ref"`
+	Subfolder          string            `json:"subfolder"`
+	DevcontainerPath   string            `json:"devcontainer_path"`
+	DotfilesRepository string            `json:"dotfiles_repository"`
+	DotfilesScript     string            `json:"dotfiles_script"`
+	Env                []WorkspaceEnvVar `json:"-"`
+	// Ports are the ports the workspace exposes, whose DNS records go
+	// with the workspace's.
+	Ports []int `json:"-"`
+}
+
+// WorkspaceEnvVar is a resolved environment variable of a workspace. Secret
+// values are decrypted here, so it is never serialized.
+type WorkspaceEnvVar struct {
+	Key      string
+	Value    string
+	IsSecret bool
 }
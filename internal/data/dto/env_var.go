@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+// EnvVarDTO is an environment variable as shown to users. Secret values are
+// write-only and never leave the server.
+type EnvVarDTO struct {
+	ID        uint64    `json:"id"`
+	Scope     string    `json:"scope"`
+	ScopeID   uint64    `json:"scope_id"`
+	Key       string    `json:"key"`
+	Value     string    `json:"value,omitempty"`
+	IsSecret  bool      `json:"is_secret"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ToEnvVarDTO(envVar models.EnvVar) EnvVarDTO {
+	dto := EnvVarDTO{
+		ID:        envVar.ID,
+		Scope:     string(envVar.Scope),
+		ScopeID:   envVar.ScopeID,
+		Key:       envVar.Key,
+		IsSecret:  envVar.IsSecret,
+		CreatedAt: envVar.CreatedAt,
+		UpdatedAt: envVar.UpdatedAt,
+	}
+	if !envVar.IsSecret {
+		dto.Value = envVar.Value
+	}
+	return dto
+}
+
+func ToEnvVarDTOs(envVars []models.EnvVar) []EnvVarDTO {
+	dtos := make([]EnvVarDTO, len(envVars))
+	for i, envVar := range envVars {
+		dtos[i] = ToEnvVarDTO(envVar)
+	}
+	return dtos
+}
@@ -17,6 +17,7 @@ type MachineConfigDTO struct {
 	EnhancedNetworking bool    `json:"enhanced_networking"`
 	GPU                string  `json:"gpu"`
 	AdditionalFeatures string  `json:"additional_features"`
+	HourlyPrice        float64 `json:"hourly_price"`
 }
 
 func ToMachineConfigDTO(config models.MachineConfig) MachineConfigDTO {
@@ -35,6 +36,7 @@ func ToMachineConfigDTO(config models.MachineConfig) MachineConfigDTO {
 		EnhancedNetworking: config.EnhancedNetworking,
 		GPU:                config.GPU,
 		AdditionalFeatures: config.AdditionalFeatures,
+		HourlyPrice:        config.HourlyPrice,
 	}
 }
 
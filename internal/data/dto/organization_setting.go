@@ -0,0 +1,21 @@
+package dto
+
+import "clusterix-code/internal/data/models"
+
+type OrganizationSettingsDTO struct {
+	OrganizationID     uint32 `json:"organization_id"`
+	IdleTimeoutMinutes *int   `json:"idle_timeout_minutes"`
+
+	StoppedRetentionDays *int `json:"stopped_retention_days"`
+	FailedRetentionDays  *int `json:"failed_retention_days"`
+}
+
+func ToOrganizationSettingsDTO(organizationID uint32, setting *models.OrganizationSetting) OrganizationSettingsDTO {
+	dto := OrganizationSettingsDTO{OrganizationID: organizationID}
+	if setting != nil {
+		dto.IdleTimeoutMinutes = setting.IdleTimeoutMinutes
+		dto.StoppedRetentionDays = setting.StoppedRetentionDays
+		dto.FailedRetentionDays = setting.FailedRetentionDays
+	}
+	return dto
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type QuotaDTO struct {
+	ID                   uint64         `json:"id"`
+	OrganizationID       uint32         `json:"organization_id"`
+	UserID               *uint64        `json:"user_id"`
+	User                 *UserDto       `json:"user,omitempty"`
+	MaxWorkspaces        *int           `json:"max_workspaces"`
+	MaxRunningWorkspaces *int           `json:"max_running_workspaces"`
+	MaxCPUCores          *int           `json:"max_cpu_cores"`
+	MaxMemoryGB          *float64       `json:"max_memory_gb"`
+	AllowedCategories    []string       `json:"allowed_categories"`
+	Usage                *QuotaUsageDTO `json:"usage,omitempty"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
+
+// QuotaUsageDTO is what currently counts against a quota.
+type QuotaUsageDTO struct {
+	Workspaces        int64   `json:"workspaces"`
+	RunningWorkspaces int64   `json:"running_workspaces"`
+	CPUCores          int64   `json:"cpu_cores"`
+	MemoryGB          float64 `json:"memory_gb"`
+}
+
+func ToQuotaDTO(quota models.Quota) QuotaDTO {
+	dto := QuotaDTO{
+		ID:                   quota.ID,
+		OrganizationID:       quota.OrganizationID,
+		UserID:               quota.UserID,
+		MaxWorkspaces:        quota.MaxWorkspaces,
+		MaxRunningWorkspaces: quota.MaxRunningWorkspaces,
+		MaxCPUCores:          quota.MaxCPUCores,
+		MaxMemoryGB:          quota.MaxMemoryGB,
+		AllowedCategories:    quota.AllowedCategories,
+		CreatedAt:            quota.CreatedAt,
+		UpdatedAt:            quota.UpdatedAt,
+	}
+
+	if quota.User.ID != 0 {
+		dto.User = ToUserDTO(quota.User)
+	}
+
+	return dto
+}
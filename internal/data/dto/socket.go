@@ -2,12 +2,18 @@ package dto
 
 import (
 	"clusterix-code/internal/constants"
+	"context"
 )
 
 type MessageHandler interface {
 	HandleMessage(message InputMessage) error
 }
 
+// SubscriptionAuthorizer decides which channels a user may subscribe to.
+type SubscriptionAuthorizer interface {
+	CanSubscribe(ctx context.Context, authUser *User, channel string) bool
+}
+
 type InputMessage struct {
 	Action    string              `json:"action"`
 	EventType constants.EventType `json:"event_type"`
@@ -26,4 +32,5 @@ type LogData struct {
 	Text        string `json:"text"`
 	Type        string `json:"type"`
 	Time        string `json:"time"`
+	RunId       uint64 `json:"run_id"`
 }
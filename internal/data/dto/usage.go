@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// UsageReportDTO is the running time and cost of an organization's
+// workspaces over a period, grouped per user and per repository.
+type UsageReportDTO struct {
+	OrganizationID uint32              `json:"organization_id"`
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	Hours          float64             `json:"hours"`
+	Cost           float64             `json:"cost"`
+	Users          []UsageGroupDTO     `json:"users"`
+	Repositories   []UsageGroupDTO     `json:"repositories"`
+	Workspaces     []WorkspaceUsageDTO `json:"workspaces"`
+}
+
+type UsageGroupDTO struct {
+	ID    uint64  `json:"id"`
+	Name  string  `json:"name"`
+	Hours float64 `json:"hours"`
+	Cost  float64 `json:"cost"`
+}
+
+type WorkspaceUsageDTO struct {
+	WorkspaceID     uint64  `json:"workspace_id"`
+	Title           string  `json:"title"`
+	UserID          uint64  `json:"user_id"`
+	UserName        string  `json:"user_name"`
+	UserEmail       string  `json:"user_email"`
+	RepositoryID    uint64  `json:"repository_id"`
+	RepositoryTitle string  `json:"repository_title"`
+	InstanceType    string  `json:"instance_type"`
+	HourlyPrice     float64 `json:"hourly_price"`
+	Hours           float64 `json:"hours"`
+	Cost            float64 `json:"cost"`
+}
+
+var usageCSVHeader = []string{
+	"workspace_id", "title", "user_id", "user_name", "user_email", "repository_id", "repository_title",
+	"instance_type", "hourly_price", "hours", "cost",
+}
+
+// WriteCSV writes one line per workspace.
+func (r UsageReportDTO) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(usageCSVHeader); err != nil {
+		return err
+	}
+	for _, workspace := range r.Workspaces {
+		err := writer.Write([]string{
+			strconv.FormatUint(workspace.WorkspaceID, 10),
+			workspace.Title,
+			strconv.FormatUint(workspace.UserID, 10),
+			workspace.UserName,
+			workspace.UserEmail,
+			strconv.FormatUint(workspace.RepositoryID, 10),
+			workspace.RepositoryTitle,
+			workspace.InstanceType,
+			strconv.FormatFloat(workspace.HourlyPrice, 'f', -1, 64),
+			strconv.FormatFloat(workspace.Hours, 'f', -1, 64),
+			strconv.FormatFloat(workspace.Cost, 'f', -1, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// RoundUsage rounds hours and costs to two decimals for reports.
+func RoundUsage(value float64) float64 {
+	return math.Round(value*100) / 100
+}
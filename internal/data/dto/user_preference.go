@@ -0,0 +1,28 @@
+package dto
+
+import "clusterix-code/internal/data/models"
+
+type UserPreferencesDTO struct {
+	DotfilesRepository      string  `json:"dotfiles_repository"`
+	DotfilesInstallScript   string  `json:"dotfiles_install_script"`
+	DefaultIDE              string  `json:"default_ide"`
+	DefaultColor            string  `json:"default_color"`
+	DefaultMachineConfigID  *uint64 `json:"default_machine_config_id"`
+	DefaultGitAccessTokenID *uint64 `json:"default_git_access_token_id"`
+}
+
+// ToUserPreferencesDTO converts the user's preferences; a user without
+// preferences gets empty ones.
+func ToUserPreferencesDTO(preference *models.UserPreference) UserPreferencesDTO {
+	if preference == nil {
+		return UserPreferencesDTO{}
+	}
+	return UserPreferencesDTO{
+		DotfilesRepository:      preference.DotfilesRepository,
+		DotfilesInstallScript:   preference.DotfilesInstallScript,
+		DefaultIDE:              preference.DefaultIDE,
+		DefaultColor:            preference.DefaultColor,
+		DefaultMachineConfigID:  preference.DefaultMachineConfigID,
+		DefaultGitAccessTokenID: preference.DefaultGitAccessTokenID,
+	}
+}
@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"encoding/json"
+	"time"
+)
+
+// WebhookDTO is a webhook. The secret is only returned when it was
+// generated.
+type WebhookDTO struct {
+	ID          uint64    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Enabled     bool      `json:"enabled"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedByID uint64    `json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func ToWebhookDTO(webhook models.Webhook) WebhookDTO {
+	events := webhook.Events
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookDTO{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		Events:      events,
+		Enabled:     webhook.Enabled,
+		CreatedByID: webhook.CreatedByID,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+}
+
+func ToWebhookDTOs(webhooks []models.Webhook) []WebhookDTO {
+	dtos := make([]WebhookDTO, len(webhooks))
+	for i, webhook := range webhooks {
+		dtos[i] = ToWebhookDTO(webhook)
+	}
+	return dtos
+}
+
+type WebhookDeliveryDTO struct {
+	ID             uint64          `json:"id"`
+	WebhookID      uint64          `json:"webhook_id"`
+	WorkspaceID    uint64          `json:"workspace_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	ResponseBody   string          `json:"response_body,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	RedeliveryOfID *uint64         `json:"redelivery_of_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+func ToWebhookDeliveryDTO(delivery models.WebhookDelivery) WebhookDeliveryDTO {
+	return WebhookDeliveryDTO{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		WorkspaceID:    delivery.WorkspaceID,
+		Event:          delivery.Event,
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.Error,
+		RedeliveryOfID: delivery.RedeliveryOfID,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+}
+
+func ToWebhookDeliveryDTOs(deliveries []models.WebhookDelivery) []WebhookDeliveryDTO {
+	dtos := make([]WebhookDeliveryDTO, len(deliveries))
+	for i, delivery := range deliveries {
+		dtos[i] = ToWebhookDeliveryDTO(delivery)
+	}
+	return dtos
+}
+
+// WebhookPayload is the body posted to webhooks when a workspace changes
+// status.
+type WebhookPayload struct {
+	Event          string                 `json:"event"`
+	OccurredAt     time.Time              `json:"occurred_at"`
+	OrganizationID uint32                 `json:"organization_id"`
+	Workspace      WebhookWorkspace       `json:"workspace"`
+	StatusEvent    WebhookStatusEventData `json:"status_event"`
+}
+
+type WebhookWorkspace struct {
+	ID          uint64 `json:"id"`
+	Title       string `json:"title"`
+	Fingerprint string `json:"fingerprint"`
+	UserID      uint64 `json:"user_id"`
+	Status      string `json:"status"`
+	URL         string `json:"url"`
+}
+
+type WebhookStatusEventData struct {
+	ID          uint64  `json:"id"`
+	Status      string  `json:"status"`
+	Message     string  `json:"message"`
+	ErrorCode   string  `json:"error_code,omitempty"`
+	ErrorReason string  `json:"error_reason,omitempty"`
+	Actor       string  `json:"actor"`
+	ActorUserID *uint64 `json:"actor_user_id"`
+	RunID       *uint64 `json:"run_id"`
+}
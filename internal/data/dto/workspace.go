@@ -1,33 +1,92 @@
 package dto
 
 import (
+	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/data/models"
 	"time"
 )
 
 type WorkspaceDTO struct {
-	ID                uint64              `json:"id"`
-	Title             string              `json:"title"`
-	Color             string              `json:"color"`
-	IDE               string              `json:"ide"`
-	URL               string              `json:"url"`
-	Fingerprint       string              `json:"fingerprint"`
-	RepositoryID      uint64              `json:"repository_id"`
-	Repository        *RepositoryDTO      `json:"repository,omitempty"` // Optional nested
-	UserID            uint64              `json:"user_id"`
-	User              *UserDto            `json:"user,omitempty"` // Optional nested
-	GitAccessTokenID  uint64              `json:"git_access_token_id"`
-	GitAccessToken    *GitAccessTokenDTO  `json:"git_access_token,omitempty"` // Optional nested
-	OrganizationID    uint32              `json:"organization_id"`
-	ProviderID        uint64              `json:"provider_id,omitempty"`
-	Provider          *ProviderDTO        `json:"provider,omitempty"` // Optional nested
-	WorkspaceConfig   *WorkspaceConfigDTO `json:"workspace_config,omitempty"`
-	WorkspaceConfigID uint64              `json:"workspace_config_id"`
-	Status            string              `json:"status"`
-	Tags              []string            `json:"tags"`
-	LastRunAt         *time.Time          `json:"last_run_at"`
-	CreatedAt         string              `json:"created_at"`
-	UpdatedAt         string              `json:"updated_at"`
+	ID                uint64               `json:"id"`
+	Title             string               `json:"title"`
+	Color             string               `json:"color"`
+	IDE               string               `json:"ide"`
+	IDELinks          *IDELinksDTO         `json:"ide_links,omitempty"`
+	URL               string               `json:"url"`
+	Fingerprint       string               `json:"fingerprint"`
+	RepositoryID      uint64               `json:"repository_id"`
+	Repository        *RepositoryDTO       `json:"repository,omitempty"` // Optional nested
+	UserID            uint64               `json:"user_id"`
+	User              *UserDto             `json:"user,omitempty"` // Optional nested
+	GitAccessTokenID  uint64               `json:"git_access_token_id"`
+	GitAccessToken    *GitAccessTokenDTO   `json:"git_access_token,omitempty"` // Optional nested
+	OrganizationID    uint32               `json:"organization_id"`
+	ProviderID        uint64               `json:"provider_id,omitempty"`
+	Provider          *ProviderDTO         `json:"provider,omitempty"` // Optional nested
+	WorkspaceConfig   *WorkspaceConfigDTO  `json:"workspace_config,omitempty"`
+	WorkspaceConfigID uint64               `json:"workspace_config_id"`
+	Status            string               `json:"status"`
+	Failure           *WorkspaceFailureDTO `json:"failure,omitempty"`
+	Tags              []string             `json:"tags"`
+	GitRefType        string               `json:"git_ref_type"`
+	GitRef            string               `json:"git_ref"`
+	Subfolder         string               `json:"subfolder"`
+	DevcontainerPath  string               `json:"devcontainer_path"`
+	MachineConfigID   *uint64              `json:"machine_config_id"`
+	TemplateID        *uint64              `json:"template_id"`
+	LastRunAt         *time.Time           `json:"last_run_at"`
+	CreatedAt         string               `json:"created_at"`
+	UpdatedAt         string               `json:"updated_at"`
+
+	IdleTimeoutMinutes *int       `json:"idle_timeout_minutes"`
+	LastActivityAt     *time.Time `json:"last_activity_at"`
+	Keep               bool       `json:"keep"`
+}
+
+// WorkspaceFailureDTO explains why a failed workspace failed.
+type WorkspaceFailureDTO struct {
+	Code     string    `json:"code"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+func ToWorkspaceFailureDTO(event *models.WorkspaceStatusEvent) *WorkspaceFailureDTO {
+	if event == nil {
+		return nil
+	}
+	return &WorkspaceFailureDTO{
+		Code:     event.ErrorCode,
+		Reason:   event.ErrorReason,
+		FailedAt: event.CreatedAt,
+	}
+}
+
+// IDELinksDTO holds the ways to open a workspace. Browser is set for
+// browser IDEs once the workspace is exposed.
+type IDELinksDTO struct {
+	Browser string `json:"browser,omitempty"`
+}
+
+func ToIDELinksDTO(workspace models.Workspace) *IDELinksDTO {
+	links := &IDELinksDTO{}
+	if enums.IDE(workspace.Ide).OrDefault().Kind() == enums.IDEKindBrowser {
+		links.Browser = workspace.URL
+	}
+	return links
+}
+
+// IDEDTO is an entry of the supported IDE registry.
+type IDEDTO struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+func ToIDEDTOs(ides []enums.IDE) []IDEDTO {
+	dtos := make([]IDEDTO, len(ides))
+	for i, ide := range ides {
+		dtos[i] = IDEDTO{Name: string(ide), Kind: string(ide.Kind())}
+	}
+	return dtos
 }
 
 func ToWorkspaceDTO(workspace models.Workspace) WorkspaceDTO {
@@ -35,7 +94,8 @@ func ToWorkspaceDTO(workspace models.Workspace) WorkspaceDTO {
 		ID:               workspace.ID,
 		Title:            workspace.Title,
 		Color:            workspace.Color,
-		IDE:              workspace.Ide,
+		IDE:              string(enums.IDE(workspace.Ide).OrDefault()),
+		IDELinks:         ToIDELinksDTO(workspace),
 		URL:              workspace.URL,
 		Fingerprint:      workspace.Fingerprint,
 		RepositoryID:     workspace.RepositoryID,
@@ -43,10 +103,20 @@ func ToWorkspaceDTO(workspace models.Workspace) WorkspaceDTO {
 		OrganizationID:   workspace.OrganizationID,
 		Status:           string(workspace.Status),
 		Tags:             workspace.Tags,
+		GitRefType:       string(workspace.GitRefType),
+		GitRef:           workspace.GitRef,
+		Subfolder:        workspace.Subfolder,
+		DevcontainerPath: workspace.DevcontainerPath,
+		MachineConfigID:  workspace.MachineConfigID,
+		TemplateID:       workspace.TemplateID,
 		LastRunAt:        workspace.LastRunAt,
 		CreatedAt:        workspace.CreatedAt.String(),
 		UpdatedAt:        workspace.UpdatedAt.String(),
 		UserID:           workspace.UserID,
+
+		IdleTimeoutMinutes: workspace.IdleTimeoutMinutes,
+		LastActivityAt:     workspace.LastActivityAt,
+		Keep:               workspace.Keep,
 	}
 
 	if workspace.ProviderID != nil {
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type WorkspaceActionDTO struct {
+	ID          uint64     `json:"id"`
+	WorkspaceID uint64     `json:"workspace_id"`
+	UserID      uint64     `json:"user_id"`
+	Action      string     `json:"action"`
+	Status      string     `json:"status"`
+	Message     string     `json:"message,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+func ToWorkspaceActionDTO(action models.WorkspaceAction) WorkspaceActionDTO {
+	return WorkspaceActionDTO{
+		ID:          action.ID,
+		WorkspaceID: action.WorkspaceID,
+		UserID:      action.UserID,
+		Action:      string(action.Action),
+		Status:      string(action.Status),
+		Message:     action.Message,
+		CreatedAt:   action.CreatedAt,
+		StartedAt:   action.StartedAt,
+		FinishedAt:  action.FinishedAt,
+	}
+}
+
+func ToWorkspaceActionDTOs(actions []models.WorkspaceAction) []WorkspaceActionDTO {
+	dtos := make([]WorkspaceActionDTO, len(actions))
+	for i, action := range actions {
+		dtos[i] = ToWorkspaceActionDTO(action)
+	}
+	return dtos
+}
@@ -8,6 +8,8 @@ type WorkspaceLogDTO struct {
 	Text string `json:"text"`
 	Type string `json:"type"`
 	Time string `json:"time"`
+	// RunID is the run that wrote the line, 0 for older lines.
+	RunID uint64 `json:"run_id"`
 }
 
 func ToWorkspaceLogDTO(config *models.WorkspaceLog) *WorkspaceLogDTO {
@@ -15,9 +17,10 @@ func ToWorkspaceLogDTO(config *models.WorkspaceLog) *WorkspaceLogDTO {
 		return nil
 	}
 	return &WorkspaceLogDTO{
-		Text: config.Text,
-		Type: config.Type,
-		Time: config.Time,
+		Text:  config.Text,
+		Type:  config.Type,
+		Time:  config.Time,
+		RunID: config.RunID,
 	}
 }
 
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WorkspacePortDTO is a port a workspace exposes. URL is empty until the
+// workspace was first exposed, and Forwarded tells whether the port can be
+// reached right now.
+type WorkspacePortDTO struct {
+	Port       int       `json:"port"`
+	Label      string    `json:"label"`
+	Visibility string    `json:"visibility"`
+	Source     string    `json:"source"`
+	URL        string    `json:"url"`
+	Forwarded  bool      `json:"forwarded"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ToWorkspacePortDTO converts a port of the workspace at workspaceURL,
+// whose host the port's subdomain is derived from.
+func ToWorkspacePortDTO(port models.WorkspacePort, workspaceURL string) WorkspacePortDTO {
+	host := workspaceURL
+	if _, rest, found := strings.Cut(host, "://"); found {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+
+	var url string
+	if host != "" {
+		url = fmt.Sprintf("%d-%s", port.Port, host)
+	}
+	return WorkspacePortDTO{
+		Port:       port.Port,
+		Label:      port.Label,
+		Visibility: string(port.Visibility),
+		Source:     string(port.Source),
+		URL:        url,
+		Forwarded:  port.WorkerPort != nil,
+		CreatedAt:  port.CreatedAt,
+	}
+}
+
+func ToWorkspacePortDTOs(ports []models.WorkspacePort, workspaceURL string) []WorkspacePortDTO {
+	dtos := make([]WorkspacePortDTO, len(ports))
+	for i, port := range ports {
+		dtos[i] = ToWorkspacePortDTO(port, workspaceURL)
+	}
+	return dtos
+}
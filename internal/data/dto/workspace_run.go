@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type WorkspaceRunDTO struct {
+	ID          uint64     `json:"id"`
+	WorkspaceID uint64     `json:"workspace_id"`
+	ActionID    *uint64    `json:"action_id"`
+	Action      string     `json:"action"`
+	Actor       string     `json:"actor"`
+	ActorUserID *uint64    `json:"actor_user_id"`
+	TaskID      string     `json:"task_id"`
+	Attempt     int        `json:"attempt"`
+	Status      string     `json:"status"`
+	ErrorCode   string     `json:"error_code,omitempty"`
+	ErrorReason string     `json:"error_reason,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+func ToWorkspaceRunDTO(run models.WorkspaceRun) WorkspaceRunDTO {
+	return WorkspaceRunDTO{
+		ID:          run.ID,
+		WorkspaceID: run.WorkspaceID,
+		ActionID:    run.ActionID,
+		Action:      string(run.Action),
+		Actor:       string(run.Actor),
+		ActorUserID: run.ActorUserID,
+		TaskID:      run.TaskID,
+		Attempt:     run.Attempt,
+		Status:      string(run.Status),
+		ErrorCode:   run.ErrorCode,
+		ErrorReason: run.ErrorReason,
+		StartedAt:   run.StartedAt,
+		FinishedAt:  run.FinishedAt,
+	}
+}
+
+func ToWorkspaceRunDTOs(runs []models.WorkspaceRun) []WorkspaceRunDTO {
+	dtos := make([]WorkspaceRunDTO, len(runs))
+	for i, run := range runs {
+		dtos[i] = ToWorkspaceRunDTO(run)
+	}
+	return dtos
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type WorkspaceScheduleDTO struct {
+	ID            uint64     `json:"id"`
+	Scope         string     `json:"scope"`
+	ScopeID       uint64     `json:"scope_id"`
+	Days          []string   `json:"days"`
+	StartTime     *string    `json:"start_time"`
+	StopTime      *string    `json:"stop_time"`
+	Timezone      string     `json:"timezone"`
+	Enabled       bool       `json:"enabled"`
+	LastAppliedAt *time.Time `json:"last_applied_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func ToWorkspaceScheduleDTO(schedule models.WorkspaceSchedule) WorkspaceScheduleDTO {
+	return WorkspaceScheduleDTO{
+		ID:            schedule.ID,
+		Scope:         string(schedule.Scope),
+		ScopeID:       schedule.ScopeID,
+		Days:          schedule.Days,
+		StartTime:     schedule.StartTime,
+		StopTime:      schedule.StopTime,
+		Timezone:      schedule.Timezone,
+		Enabled:       schedule.Enabled,
+		LastAppliedAt: schedule.LastAppliedAt,
+		CreatedAt:     schedule.CreatedAt,
+		UpdatedAt:     schedule.UpdatedAt,
+	}
+}
+
+func ToWorkspaceScheduleDTOs(schedules []models.WorkspaceSchedule) []WorkspaceScheduleDTO {
+	dtos := make([]WorkspaceScheduleDTO, len(schedules))
+	for i, schedule := range schedules {
+		dtos[i] = ToWorkspaceScheduleDTO(schedule)
+	}
+	return dtos
+}
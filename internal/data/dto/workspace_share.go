@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type WorkspaceShareDTO struct {
+	WorkspaceID uint64    `json:"workspace_id"`
+	UserID      uint64    `json:"user_id"`
+	User        *UserDto  `json:"user,omitempty"`
+	Role        string    `json:"role"`
+	SharedByID  uint64    `json:"shared_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func ToWorkspaceShareDTO(share models.WorkspaceShare) WorkspaceShareDTO {
+	dto := WorkspaceShareDTO{
+		WorkspaceID: share.WorkspaceID,
+		UserID:      share.UserID,
+		Role:        string(share.Role),
+		SharedByID:  share.SharedByID,
+		CreatedAt:   share.CreatedAt,
+		UpdatedAt:   share.UpdatedAt,
+	}
+	if share.User.ID != 0 {
+		dto.User = ToUserDTO(share.User)
+	}
+	return dto
+}
+
+func ToWorkspaceShareDTOs(shares []models.WorkspaceShare) []WorkspaceShareDTO {
+	dtos := make([]WorkspaceShareDTO, len(shares))
+	for i, share := range shares {
+		dtos[i] = ToWorkspaceShareDTO(share)
+	}
+	return dtos
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+// WorkspaceStatusEventDTO is a status change of a workspace. Duration is the
+// number of seconds the workspace spent in the status, so far for the
+// current one.
+type WorkspaceStatusEventDTO struct {
+	ID          uint64     `json:"id"`
+	Status      string     `json:"status"`
+	Message     string     `json:"message"`
+	ErrorCode   string     `json:"error_code,omitempty"`
+	ErrorReason string     `json:"error_reason,omitempty"`
+	Actor       string     `json:"actor"`
+	ActorUserID *uint64    `json:"actor_user_id"`
+	RunID       *uint64    `json:"run_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+	Duration    int64      `json:"duration"`
+}
+
+// ToWorkspaceStatusEventDTO converts an event the workspace left at endedAt,
+// nil while it is still in the status.
+func ToWorkspaceStatusEventDTO(event models.WorkspaceStatusEvent, endedAt *time.Time, now time.Time) WorkspaceStatusEventDTO {
+	end := now
+	if endedAt != nil {
+		end = *endedAt
+	}
+	return WorkspaceStatusEventDTO{
+		ID:          event.ID,
+		Status:      string(event.Status),
+		Message:     event.Message,
+		ErrorCode:   event.ErrorCode,
+		ErrorReason: event.ErrorReason,
+		Actor:       string(event.Actor),
+		ActorUserID: event.ActorUserID,
+		RunID:       event.RunID,
+		CreatedAt:   event.CreatedAt,
+		EndedAt:     endedAt,
+		Duration:    int64(end.Sub(event.CreatedAt).Seconds()),
+	}
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"clusterix-code/internal/data/models"
+	"time"
+)
+
+type WorkspaceTemplateDTO struct {
+	ID               uint64            `json:"id"`
+	OrganizationID   uint32            `json:"organization_id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	RepositoryID     uint64            `json:"repository_id"`
+	Repository       *RepositoryDTO    `json:"repository,omitempty"`
+	MachineConfigID  *uint64           `json:"machine_config_id"`
+	MachineConfig    *MachineConfigDTO `json:"machine_config,omitempty"`
+	ProviderID       uint64            `json:"provider_id"`
+	IDE              string            `json:"ide"`
+	Color            string            `json:"color"`
+	GitRefType       string            `json:"git_ref_type"`
+	GitRef           string            `json:"git_ref"`
+	Subfolder        string            `json:"subfolder"`
+	DevcontainerPath string            `json:"devcontainer_path"`
+	Tags             []string          `json:"tags"`
+	CreatedByID      uint64            `json:"created_by_id"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+func ToWorkspaceTemplateDTO(template models.WorkspaceTemplate) WorkspaceTemplateDTO {
+	dto := WorkspaceTemplateDTO{
+		ID:               template.ID,
+		OrganizationID:   template.OrganizationID,
+		Name:             template.Name,
+		Description:      template.Description,
+		RepositoryID:     template.RepositoryID,
+		MachineConfigID:  template.MachineConfigID,
+		ProviderID:       template.ProviderID,
+		IDE:              template.Ide,
+		Color:            template.Color,
+		GitRefType:       string(template.GitRefType),
+		GitRef:           template.GitRef,
+		Subfolder:        template.Subfolder,
+		DevcontainerPath: template.DevcontainerPath,
+		Tags:             template.Tags,
+		CreatedByID:      template.CreatedByID,
+		CreatedAt:        template.CreatedAt,
+		UpdatedAt:        template.UpdatedAt,
+	}
+
+	if template.Repository.ID != 0 {
+		repository := ToRepositoryDTO(template.Repository)
+		dto.Repository = &repository
+	}
+
+	if template.MachineConfig.ID != 0 {
+		machineConfig := ToMachineConfigDTO(template.MachineConfig)
+		dto.MachineConfig = &machineConfig
+	}
+
+	return dto
+}
+
+func ToWorkspaceTemplateDTOs(templates []models.WorkspaceTemplate) []WorkspaceTemplateDTO {
+	dtos := make([]WorkspaceTemplateDTO, len(templates))
+	for i, template := range templates {
+		dtos[i] = ToWorkspaceTemplateDTO(template)
+	}
+	return dtos
+}
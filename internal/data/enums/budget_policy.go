@@ -0,0 +1,23 @@
+package enums
+
+// BudgetPolicy is what happens once an organization spent its monthly
+// budget.
+type BudgetPolicy string
+
+const (
+	// BudgetPolicyWarn only raises the alerts.
+	BudgetPolicyWarn BudgetPolicy = "warn"
+	// BudgetPolicyBlock refuses to start workspaces.
+	BudgetPolicyBlock BudgetPolicy = "block"
+	// BudgetPolicyStop refuses to start workspaces and stops running ones.
+	BudgetPolicyStop BudgetPolicy = "stop"
+)
+
+// BlocksStarts reports whether workspaces may not start over budget.
+func (p BudgetPolicy) BlocksStarts() bool {
+	return p == BudgetPolicyBlock || p == BudgetPolicyStop
+}
+
+// BudgetAlertThresholds are the percentages of the budget that raise an
+// alert, lowest first.
+var BudgetAlertThresholds = []int{50, 80, 100}
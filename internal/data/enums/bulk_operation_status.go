@@ -0,0 +1,23 @@
+package enums
+
+type BulkOperationStatus string
+
+const (
+	BulkOperationStatusPending   BulkOperationStatus = "pending"
+	BulkOperationStatusRunning   BulkOperationStatus = "running"
+	BulkOperationStatusCompleted BulkOperationStatus = "completed"
+	// BulkOperationStatusFailed operations never reached a worker.
+	BulkOperationStatusFailed BulkOperationStatus = "failed"
+)
+
+// BulkOperationItemStatus is the outcome of a bulk operation for one
+// workspace. Submitted means the action was queued on the workspace, whose
+// own status then tells how it went.
+type BulkOperationItemStatus string
+
+const (
+	BulkOperationItemStatusPending   BulkOperationItemStatus = "pending"
+	BulkOperationItemStatusSubmitted BulkOperationItemStatus = "submitted"
+	BulkOperationItemStatusSkipped   BulkOperationItemStatus = "skipped"
+	BulkOperationItemStatusFailed    BulkOperationItemStatus = "failed"
+)
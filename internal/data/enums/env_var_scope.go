@@ -0,0 +1,21 @@
+package enums
+
+// EnvVarScope is what an environment variable applies to. A workspace gets
+// the variables of its organization, repository, the template it was
+// created from and its own; on a key clash the narrower scope wins.
+type EnvVarScope string
+
+const (
+	EnvVarScopeOrganization EnvVarScope = "organization"
+	EnvVarScopeRepository   EnvVarScope = "repository"
+	EnvVarScopeTemplate     EnvVarScope = "template"
+	EnvVarScopeWorkspace    EnvVarScope = "workspace"
+)
+
+// EnvVarScopePrecedence lists the scopes from the weakest to the strongest.
+var EnvVarScopePrecedence = []EnvVarScope{
+	EnvVarScopeOrganization,
+	EnvVarScopeRepository,
+	EnvVarScopeTemplate,
+	EnvVarScopeWorkspace,
+}
@@ -0,0 +1,12 @@
+package enums
+
+// GitRefType tells what a workspace's git ref names. A workspace without a
+// ref uses the repository's default branch.
+type GitRefType string
+
+const (
+	GitRefTypeBranch      GitRefType = "branch"
+	GitRefTypeTag         GitRefType = "tag"
+	GitRefTypeCommit      GitRefType = "commit"
+	GitRefTypePullRequest GitRefType = "pull_request"
+)
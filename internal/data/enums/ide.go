@@ -0,0 +1,90 @@
+package enums
+
+import "sort"
+
+type IDE string
+
+const (
+	IDEOpenVSCode IDE = "openvscode"
+	IDEVSCode     IDE = "vscode"
+	IDEIntelliJ   IDE = "intellij"
+	IDEGoLand     IDE = "goland"
+	IDEPyCharm    IDE = "pycharm"
+	IDEWebStorm   IDE = "webstorm"
+	IDEPhpStorm   IDE = "phpstorm"
+	IDERubyMine   IDE = "rubymine"
+	IDECLion      IDE = "clion"
+	IDERider      IDE = "rider"
+	IDEJupyter    IDE = "jupyternotebook"
+	IDENone       IDE = "none"
+)
+
+// IDEKind tells how users reach a workspace's IDE.
+type IDEKind string
+
+const (
+	// IDEKindBrowser IDEs are served by the workspace and opened through its
+	// public URL.
+	IDEKindBrowser IDEKind = "browser"
+	// IDEKindDesktop IDEs run on the user's machine and connect to the
+	// workspace over SSH.
+	IDEKindDesktop IDEKind = "desktop"
+	// IDEKindSSH workspaces have no IDE, only SSH access.
+	IDEKindSSH IDEKind = "ssh"
+)
+
+type ideSpec struct {
+	kind IDEKind
+}
+
+// knownIDEs is the registry of the IDEs devpod can open a workspace in.
+// Every name is also devpod's name of the IDE.
+var knownIDEs = map[IDE]ideSpec{
+	IDEOpenVSCode: {kind: IDEKindBrowser},
+	IDEVSCode:     {kind: IDEKindDesktop},
+	IDEIntelliJ:   {kind: IDEKindDesktop},
+	IDEGoLand:     {kind: IDEKindDesktop},
+	IDEPyCharm:    {kind: IDEKindDesktop},
+	IDEWebStorm:   {kind: IDEKindDesktop},
+	IDEPhpStorm:   {kind: IDEKindDesktop},
+	IDERubyMine:   {kind: IDEKindDesktop},
+	IDECLion:      {kind: IDEKindDesktop},
+	IDERider:      {kind: IDEKindDesktop},
+	IDEJupyter:    {kind: IDEKindBrowser},
+	IDENone:       {kind: IDEKindSSH},
+}
+
+// IsSupported reports whether workspaces can be opened in the IDE. Desktop
+// IDEs are known but not supported: they connect to the workspace over
+// SSH, and the platform has no SSH endpoint users can reach.
+func (i IDE) IsSupported() bool {
+	spec, ok := knownIDEs[i]
+	return ok && spec.kind != IDEKindDesktop
+}
+
+// OrDefault is the IDE if it is supported, else openvscode. Workspaces
+// created with an unknown or desktop IDE are opened in openvscode, as they
+// always were before IDEs were validated.
+func (i IDE) OrDefault() IDE {
+	if i.IsSupported() {
+		return i
+	}
+	return IDEOpenVSCode
+}
+
+func (i IDE) Kind() IDEKind {
+	return knownIDEs[i].kind
+}
+
+// SupportedIDEs lists the supported IDEs in name order.
+func SupportedIDEs() []IDE {
+	ides := make([]IDE, 0, len(knownIDEs))
+	for ide := range knownIDEs {
+		if !ide.IsSupported() {
+			continue
+		}
+		ides = append(ides, ide)
+	}
+	sort.Slice(ides, func(a, b int) bool { return ides[a] < ides[b] })
+	return ides
+}
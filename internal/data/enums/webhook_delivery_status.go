@@ -0,0 +1,11 @@
+package enums
+
+// WebhookDeliveryStatus is where a webhook delivery stands. A pending
+// delivery is waiting for its first attempt or for a retry.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
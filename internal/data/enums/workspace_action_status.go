@@ -0,0 +1,14 @@
+package enums
+
+type WorkspaceActionStatus string
+
+const (
+	WorkspaceActionStatusQueued    WorkspaceActionStatus = "queued"
+	WorkspaceActionStatusRunning   WorkspaceActionStatus = "running"
+	WorkspaceActionStatusCompleted WorkspaceActionStatus = "completed"
+	WorkspaceActionStatusFailed    WorkspaceActionStatus = "failed"
+	WorkspaceActionStatusCancelled WorkspaceActionStatus = "cancelled"
+	// WorkspaceActionStatusCoalesced marks a queued action that was merged
+	// into, or superseded by, a later one.
+	WorkspaceActionStatusCoalesced WorkspaceActionStatus = "coalesced"
+)
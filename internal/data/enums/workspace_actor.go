@@ -0,0 +1,16 @@
+package enums
+
+// WorkspaceActor is what triggered a workspace action or status change.
+// System covers the worker and platform changes no one asked for.
+type WorkspaceActor string
+
+const (
+	WorkspaceActorUser       WorkspaceActor = "user"
+	WorkspaceActorScheduler  WorkspaceActor = "scheduler"
+	WorkspaceActorIdle       WorkspaceActor = "idle"
+	WorkspaceActorRetention  WorkspaceActor = "retention"
+	WorkspaceActorBudget     WorkspaceActor = "budget"
+	WorkspaceActorReconciler WorkspaceActor = "reconciler"
+	WorkspaceActorAdmin      WorkspaceActor = "admin"
+	WorkspaceActorSystem     WorkspaceActor = "system"
+)
@@ -0,0 +1,20 @@
+package enums
+
+// WorkspacePortVisibility is who may open a forwarded port of a workspace:
+// private ports need the workspace's owner, organization ports any member
+// of its organization.
+type WorkspacePortVisibility string
+
+const (
+	WorkspacePortVisibilityPrivate      WorkspacePortVisibility = "private"
+	WorkspacePortVisibilityOrganization WorkspacePortVisibility = "organization"
+)
+
+// WorkspacePortSource is where a port was declared: through the API or in
+// the forwardPorts of the workspace's devcontainer.json.
+type WorkspacePortSource string
+
+const (
+	WorkspacePortSourceAPI          WorkspacePortSource = "api"
+	WorkspacePortSourceDevcontainer WorkspacePortSource = "devcontainer"
+)
@@ -0,0 +1,32 @@
+package enums
+
+// WorkspaceRole is what a user may do with a workspace. Each role includes
+// the ones before it: viewers see status and logs, users can also open the
+// IDE, operators can also start and stop it, and owners can do anything.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleViewer   WorkspaceRole = "viewer"
+	WorkspaceRoleUser     WorkspaceRole = "user"
+	WorkspaceRoleOperator WorkspaceRole = "operator"
+	WorkspaceRoleOwner    WorkspaceRole = "owner"
+)
+
+var workspaceRoleRanks = map[WorkspaceRole]int{
+	WorkspaceRoleViewer:   1,
+	WorkspaceRoleUser:     2,
+	WorkspaceRoleOperator: 3,
+	WorkspaceRoleOwner:    4,
+}
+
+// IsShareable reports whether owners can grant the role to other users.
+func (r WorkspaceRole) IsShareable() bool {
+	return r == WorkspaceRoleViewer || r == WorkspaceRoleUser || r == WorkspaceRoleOperator
+}
+
+// Includes reports whether the role grants everything the required role
+// does. Unknown roles grant nothing.
+func (r WorkspaceRole) Includes(required WorkspaceRole) bool {
+	rank, ok := workspaceRoleRanks[r]
+	return ok && rank >= workspaceRoleRanks[required]
+}
@@ -0,0 +1,15 @@
+package enums
+
+// WorkspaceRunStatus is the outcome of a run, one attempt of a worker at a
+// workspace action. A run that failed and is retried is followed by another
+// run of the same action. Interrupted runs were left running by a worker that
+// went away.
+type WorkspaceRunStatus string
+
+const (
+	WorkspaceRunStatusRunning     WorkspaceRunStatus = "running"
+	WorkspaceRunStatusSucceeded   WorkspaceRunStatus = "succeeded"
+	WorkspaceRunStatusFailed      WorkspaceRunStatus = "failed"
+	WorkspaceRunStatusCancelled   WorkspaceRunStatus = "cancelled"
+	WorkspaceRunStatusInterrupted WorkspaceRunStatus = "interrupted"
+)
@@ -0,0 +1,11 @@
+package enums
+
+// WorkspaceScheduleScope is what a schedule applies to. A workspace follows
+// its own schedules, or those of the template it was created from when it
+// has none.
+type WorkspaceScheduleScope string
+
+const (
+	WorkspaceScheduleScopeTemplate  WorkspaceScheduleScope = "template"
+	WorkspaceScheduleScopeWorkspace WorkspaceScheduleScope = "workspace"
+)
@@ -14,4 +14,81 @@ const (
 	WorkspaceStatusTerminating WorkspaceStatus = "terminating"
 	WorkspaceStatusTerminated  WorkspaceStatus = "terminated"
 	WorkspaceStatusFailed      WorkspaceStatus = "failed"
+	WorkspaceStatusCancelled   WorkspaceStatus = "cancelled"
 )
+
+// workspaceStatusTransitions lists the statuses a workspace may move to from
+// each status. Terminated is final.
+var workspaceStatusTransitions = map[WorkspaceStatus][]WorkspaceStatus{
+	WorkspaceStatusPending: {
+		WorkspaceStatusStarting, WorkspaceStatusCreating, WorkspaceStatusFailed, WorkspaceStatusTerminating, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusStarting: {
+		WorkspaceStatusCreating, WorkspaceStatusRunning, WorkspaceStatusFailed, WorkspaceStatusStopping, WorkspaceStatusTerminating, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusCreating: {
+		WorkspaceStatusRunning, WorkspaceStatusFailed, WorkspaceStatusStopping, WorkspaceStatusTerminating, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusRunning: {
+		WorkspaceStatusStopping, WorkspaceStatusStopped, WorkspaceStatusRestarting, WorkspaceStatusRebuilding,
+		WorkspaceStatusTerminating, WorkspaceStatusFailed,
+	},
+	WorkspaceStatusStopping: {
+		WorkspaceStatusStopped, WorkspaceStatusFailed, WorkspaceStatusTerminating, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusStopped: {
+		WorkspaceStatusStarting, WorkspaceStatusRunning, WorkspaceStatusRestarting, WorkspaceStatusRebuilding,
+		WorkspaceStatusTerminating,
+	},
+	WorkspaceStatusRestarting: {
+		WorkspaceStatusStarting, WorkspaceStatusCreating, WorkspaceStatusRunning, WorkspaceStatusFailed,
+		WorkspaceStatusTerminating, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusRebuilding: {
+		WorkspaceStatusStarting, WorkspaceStatusCreating, WorkspaceStatusRunning, WorkspaceStatusFailed,
+		WorkspaceStatusTerminating, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusTerminating: {
+		WorkspaceStatusTerminated, WorkspaceStatusFailed, WorkspaceStatusCancelled,
+	},
+	WorkspaceStatusTerminated: {},
+	WorkspaceStatusCancelled: {
+		WorkspaceStatusStarting, WorkspaceStatusRunning, WorkspaceStatusStopping, WorkspaceStatusStopped,
+		WorkspaceStatusRestarting, WorkspaceStatusRebuilding, WorkspaceStatusTerminating,
+	},
+	WorkspaceStatusFailed: {
+		WorkspaceStatusStarting, WorkspaceStatusRunning, WorkspaceStatusStopping, WorkspaceStatusStopped,
+		WorkspaceStatusRestarting, WorkspaceStatusRebuilding, WorkspaceStatusTerminating,
+	},
+}
+
+// CanTransitionTo reports whether a workspace in status s may move to next.
+// Re-reporting the current status is always allowed, and statuses outside
+// the table (legacy rows) are not restricted.
+func (s WorkspaceStatus) CanTransitionTo(next WorkspaceStatus) bool {
+	if s == next {
+		return true
+	}
+	allowed, ok := workspaceStatusTransitions[s]
+	if !ok {
+		return true
+	}
+	for _, status := range allowed {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveWorkspaceStatuses are the statuses of workspaces that have, or are
+// about to get, a machine.
+var ActiveWorkspaceStatuses = []WorkspaceStatus{
+	WorkspaceStatusPending,
+	WorkspaceStatusStarting,
+	WorkspaceStatusCreating,
+	WorkspaceStatusRunning,
+	WorkspaceStatusStopping,
+	WorkspaceStatusRestarting,
+	WorkspaceStatusRebuilding,
+}
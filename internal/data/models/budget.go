@@ -0,0 +1,36 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// Budget is what an organization may spend on workspaces each month.
+type Budget struct {
+	ID             uint64             `gorm:"primaryKey"`
+	OrganizationID uint32             `gorm:"not null;uniqueIndex"`
+	MonthlyAmount  float64            `gorm:"type:numeric(12,2);not null"`
+	Policy         enums.BudgetPolicy `gorm:"type:varchar(20);not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (Budget) TableName() string {
+	return "budgets"
+}
+
+// BudgetAlert records that an organization reached a threshold of its
+// budget in a month. Each threshold alerts once per month.
+type BudgetAlert struct {
+	ID             uint64    `gorm:"primaryKey"`
+	OrganizationID uint32    `gorm:"not null"`
+	Period         string    `gorm:"type:varchar(7);not null"` // 2006-01
+	Threshold      int       `gorm:"not null"`
+	MonthlyAmount  float64   `gorm:"type:numeric(12,2);not null"`
+	Cost           float64   `gorm:"type:numeric(12,2);not null"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+}
+
+func (BudgetAlert) TableName() string {
+	return "budget_alerts"
+}
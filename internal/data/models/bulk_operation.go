@@ -0,0 +1,47 @@
+package models
+
+import (
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// BulkOperation is an action an admin applied to every workspace of the
+// organization matching Filter, the JSON encoded filter as requested. The
+// workspaces are resolved when the operation is created.
+type BulkOperation struct {
+	ID             uint64                    `gorm:"primaryKey"`
+	OrganizationID uint32                    `gorm:"not null"`
+	UserID         uint64                    `gorm:"not null"`
+	Action         constants.WorkspaceAction `gorm:"type:varchar(50);not null"`
+	Filter         string                    `gorm:"type:text;not null"`
+	Status         enums.BulkOperationStatus `gorm:"type:varchar(50);not null"`
+	Total          int                       `gorm:"not null;default:0"`
+	Submitted      int                       `gorm:"not null;default:0"`
+	Skipped        int                       `gorm:"not null;default:0"`
+	Failed         int                       `gorm:"not null;default:0"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FinishedAt     *time.Time
+
+	Items []BulkOperationItem `gorm:"foreignKey:BulkOperationID"`
+}
+
+func (BulkOperation) TableName() string {
+	return "bulk_operations"
+}
+
+// BulkOperationItem is the outcome of a bulk operation for one workspace.
+type BulkOperationItem struct {
+	ID              uint64                        `gorm:"primaryKey"`
+	BulkOperationID uint64                        `gorm:"index;not null"`
+	WorkspaceID     uint64                        `gorm:"not null"`
+	Status          enums.BulkOperationItemStatus `gorm:"type:varchar(50);not null"`
+	Message         string                        `gorm:"type:text;not null;default:''"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (BulkOperationItem) TableName() string {
+	return "bulk_operation_items"
+}
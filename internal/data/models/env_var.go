@@ -0,0 +1,27 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// EnvVar is an environment variable injected into workspaces. ScopeID is the
+// ID of the organization, repository or workspace it belongs to. The value
+// of a secret is stored encrypted.
+type EnvVar struct {
+	ID             uint64            `gorm:"primaryKey"`
+	OrganizationID uint32            `gorm:"not null"`
+	Scope          enums.EnvVarScope `gorm:"type:varchar(20);not null"`
+	ScopeID        uint64            `gorm:"not null"`
+	Key            string            `gorm:"type:varchar(255);not null"`
+	Value          string            `gorm:"type:text;not null"`
+	IsSecret       bool              `gorm:"type:boolean;not null;default:false"`
+	CreatedByID    uint64            `gorm:"not null"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (EnvVar) TableName() string {
+	return "env_vars"
+}
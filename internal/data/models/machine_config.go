@@ -15,6 +15,8 @@ type MachineConfig struct {
 	EnhancedNetworking bool   `gorm:"type:boolean"`
 	GPU                string `gorm:"type:varchar(255)"`
 	AdditionalFeatures string `gorm:"type:text"`
+	// HourlyPrice is what an hour of running the machine costs.
+	HourlyPrice float64 `gorm:"type:numeric(10,4);not null;default:0"`
 }
 
 func (MachineConfig) TableName() string {
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OrganizationSetting holds the organization-wide defaults. Nil settings
+// fall back to the platform configuration.
+type OrganizationSetting struct {
+	OrganizationID     uint32 `gorm:"primaryKey;autoIncrement:false"`
+	IdleTimeoutMinutes *int   // 0 never stops idle workspaces
+
+	// Stopped and failed workspaces are terminated after this many days in
+	// that status; 0 keeps them.
+	StoppedRetentionDays *int
+	FailedRetentionDays  *int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (OrganizationSetting) TableName() string {
+	return "organization_settings"
+}
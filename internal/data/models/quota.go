@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Quota limits the workspaces of an organization, or of one of its users
+// when UserID is set. A nil limit is unlimited and an empty
+// AllowedCategories allows every instance category.
+type Quota struct {
+	ID                   uint64 `gorm:"primaryKey"`
+	OrganizationID       uint32 `gorm:"not null"`
+	UserID               *uint64
+	MaxWorkspaces        *int
+	MaxRunningWorkspaces *int
+	MaxCPUCores          *int
+	MaxMemoryGB          *float64
+	AllowedCategories    []string `gorm:"type:text[]"`
+
+	User User `gorm:"foreignKey:UserID"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Quota) TableName() string {
+	return "quotas"
+}
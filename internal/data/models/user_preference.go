@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// UserPreference holds a user's workspace defaults and dotfiles. A user
+// without a row has no preferences.
+type UserPreference struct {
+	UserID                  uint64  `gorm:"primaryKey;autoIncrement:false"`
+	DotfilesRepository      string  `gorm:"type:text;not null;default:''"`
+	DotfilesInstallScript   string  `gorm:"type:text;not null;default:''"`
+	DefaultIDE              string  `gorm:"column:default_ide;type:varchar(50);not null;default:''"`
+	DefaultColor            string  `gorm:"type:varchar(30);not null;default:''"`
+	DefaultMachineConfigID  *uint64 `gorm:""`
+	DefaultGitAccessTokenID *uint64 `gorm:""`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (UserPreference) TableName() string {
+	return "user_preferences"
+}
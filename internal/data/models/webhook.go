@@ -0,0 +1,52 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// Webhook posts the status changes of an organization's workspaces to URL.
+// Events are the event names it is subscribed to, "workspace.<status>";
+// without any it gets them all. Secret signs the deliveries and is stored
+// encrypted.
+type Webhook struct {
+	ID             uint64   `gorm:"primaryKey"`
+	OrganizationID uint32   `gorm:"index;not null"`
+	URL            string   `gorm:"type:text;not null"`
+	Events         []string `gorm:"type:text[]"`
+	Secret         string   `gorm:"type:text;not null"`
+	Enabled        bool     `gorm:"type:boolean;not null;default:true"`
+	CreatedByID    uint64   `gorm:"not null"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook, with the
+// outcome of its latest attempt. Payload is the JSON body, kept so the
+// delivery can be sent again as it was.
+type WebhookDelivery struct {
+	ID             uint64                      `gorm:"primaryKey"`
+	WebhookID      uint64                      `gorm:"index;not null"`
+	WorkspaceID    uint64                      `gorm:"not null"`
+	Event          string                      `gorm:"type:varchar(50);not null"`
+	Payload        string                      `gorm:"type:text;not null"`
+	Status         enums.WebhookDeliveryStatus `gorm:"type:varchar(20);not null"`
+	Attempts       int                         `gorm:"not null;default:0"`
+	ResponseStatus *int
+	ResponseBody   string  `gorm:"type:text;not null;default:''"`
+	Error          string  `gorm:"type:text;not null;default:''"`
+	RedeliveryOfID *uint64 // the delivery this one sends again
+	DeliveredAt    *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
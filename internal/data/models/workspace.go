@@ -18,17 +18,46 @@ type Workspace struct {
 	OrganizationID           uint32                `gorm:"not null"`
 	GitPersonalAccessTokenID uint64                `gorm:"not null"`
 	Status                   enums.WorkspaceStatus `gorm:"type:varchar(50);not null"`
+	Version                  uint64                `gorm:"not null;default:0"`
+
+	// GitRefType and GitRef select what to check out; empty means the
+	// repository's default branch. Subfolder is the project's folder in a
+	// monorepo and DevcontainerPath an alternate devcontainer.json, both
+	// relative to the repository root.
+	GitRefType       enums.GitRefType `gorm:"type:varchar(20);not null;default:''"`
+	GitRef           string           `gorm:"type:varchar(255);not null;default:''"`
+	Subfolder        string           `gorm:"type:text;not null;default:''"`
+	DevcontainerPath string           `gorm:"type:text;not null;default:''"`
 
 	Tags              []string `gorm:"type:text[]"`
 	ProviderID        *uint64
 	WorkspaceConfigID *uint64
+	MachineConfigID   *uint64 // overrides the repository's machine config
+	TemplateID        *uint64 // the template the workspace was created from
 	LastRunAt         *time.Time
 
+	// LastActivityAt is the last time the reverse proxy saw traffic to the
+	// workspace; IdleWarnedAt is set once the user was warned it is about to
+	// be stopped for being idle. IdleTimeoutMinutes overrides the
+	// organization's idle timeout, 0 never stops the workspace.
+	LastActivityAt     *time.Time
+	IdleWarnedAt       *time.Time
+	IdleTimeoutMinutes *int
+
+	// Keep exempts the workspace from the organization's retention policy.
+	// ExpiryWarnedAt is set once the user was warned it is about to be
+	// terminated for having been stopped or failed too long, and PurgedAt
+	// once a deleted workspace's machine and DNS record are gone.
+	Keep           bool `gorm:"not null;default:false"`
+	ExpiryWarnedAt *time.Time
+	PurgedAt       *time.Time
+
 	Repository             Repository             `gorm:"foreignKey:RepositoryID"`
 	User                   User                   `gorm:"foreignKey:UserID"`
 	GitPersonalAccessToken GitPersonalAccessToken `gorm:"foreignKey:GitPersonalAccessTokenID"`
 	Provider               Provider               `gorm:"foreignKey:ProviderID"`
 	WorkspaceConfig        WorkspaceConfig        `gorm:"foreignKey:WorkspaceConfigID"`
+	MachineConfig          MachineConfig          `gorm:"foreignKey:MachineConfigID"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
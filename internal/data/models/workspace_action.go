@@ -0,0 +1,33 @@
+package models
+
+import (
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// WorkspaceAction is an entry of a workspace's action queue. At most one
+// action per workspace is running at a time.
+type WorkspaceAction struct {
+	ID          uint64                      `gorm:"primaryKey"`
+	WorkspaceID uint64                      `gorm:"index;not null"`
+	UserID      uint64                      `gorm:"not null"`
+	Action      constants.WorkspaceAction   `gorm:"type:varchar(50);not null"`
+	Status      enums.WorkspaceActionStatus `gorm:"type:varchar(50);not null"`
+	TaskID      string                      `gorm:"type:varchar(255)"`
+	Message     string                      `gorm:"type:text"`
+	CreatedAt   time.Time                   `gorm:"autoCreateTime"`
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+
+	// Actor triggered the action; UserID is the user it runs for.
+	Actor enums.WorkspaceActor `gorm:"type:varchar(20);not null;default:'user'"`
+	// Reason explains an action the user didn't ask for, e.g. an idle stop.
+	// It becomes the message of the status change the action starts with.
+	Reason string `gorm:"type:text;not null;default:''"`
+
+	// CancelRequestedAt is set when the action is cancelled through the
+	// cancel endpoint, which tells its worker the task was not just
+	// interrupted.
+	CancelRequestedAt *time.Time
+}
@@ -10,4 +10,7 @@ type WorkspaceLog struct {
 	Type        string    `gorm:"type:type"`
 	Time        string    `gorm:"type:time"`
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	// RunID is the run that wrote the line, 0 for lines from before runs
+	// were recorded.
+	RunID uint64
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// WorkspacePort is an application port of a workspace exposed through the
+// reverse proxy as "<port>-<fingerprint>.<base>". WorkerPort is the port on
+// the worker the proxy reaches it on, set while the port is forwarded.
+type WorkspacePort struct {
+	ID          uint64                        `gorm:"primaryKey"`
+	WorkspaceID uint64                        `gorm:"not null"`
+	Port        int                           `gorm:"not null"`
+	Label       string                        `gorm:"type:varchar(100);not null;default:''"`
+	Visibility  enums.WorkspacePortVisibility `gorm:"type:varchar(20);not null"`
+	Source      enums.WorkspacePortSource     `gorm:"type:varchar(20);not null"`
+	WorkerPort  *int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (WorkspacePort) TableName() string {
+	return "workspace_ports"
+}
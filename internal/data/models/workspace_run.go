@@ -0,0 +1,29 @@
+package models
+
+import (
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// WorkspaceRun is one attempt of a worker at a workspace action. The logs
+// and status events of the attempt carry its ID.
+type WorkspaceRun struct {
+	ID          uint64                    `gorm:"primaryKey"`
+	WorkspaceID uint64                    `gorm:"index;not null"`
+	ActionID    *uint64                   // nil for tasks enqueued before actions were queued
+	Action      constants.WorkspaceAction `gorm:"type:varchar(50);not null"`
+	Actor       enums.WorkspaceActor      `gorm:"type:varchar(20);not null"`
+	ActorUserID *uint64
+	TaskID      string                   `gorm:"type:varchar(255);not null;default:''"`
+	Attempt     int                      `gorm:"not null;default:1"`
+	Status      enums.WorkspaceRunStatus `gorm:"type:varchar(20);not null"`
+	ErrorCode   string                   `gorm:"type:varchar(50);not null;default:''"`
+	ErrorReason string                   `gorm:"type:text;not null;default:''"`
+	StartedAt   time.Time                `gorm:"not null"`
+	FinishedAt  *time.Time
+}
+
+func (WorkspaceRun) TableName() string {
+	return "workspace_runs"
+}
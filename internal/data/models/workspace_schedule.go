@@ -0,0 +1,31 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// WorkspaceSchedule starts and stops workspaces at fixed times of the week.
+// ScopeID is the ID of the workspace or template it belongs to. Days are
+// lowercase weekday abbreviations ("mon" to "sun") and the times are
+// "15:04" in Timezone; either time may be unset.
+type WorkspaceSchedule struct {
+	ID             uint64                       `gorm:"primaryKey"`
+	OrganizationID uint32                       `gorm:"not null"`
+	Scope          enums.WorkspaceScheduleScope `gorm:"type:varchar(20);not null"`
+	ScopeID        uint64                       `gorm:"not null"`
+	Days           []string                     `gorm:"type:text[]"`
+	StartTime      *string                      `gorm:"type:varchar(5)"`
+	StopTime       *string                      `gorm:"type:varchar(5)"`
+	Timezone       string                       `gorm:"type:varchar(64);not null;default:'UTC'"`
+	Enabled        bool                         `gorm:"type:boolean;not null;default:true"`
+	LastAppliedAt  *time.Time                   // when the scheduler last acted on it
+	CreatedByID    uint64                       `gorm:"not null"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (WorkspaceSchedule) TableName() string {
+	return "workspace_schedules"
+}
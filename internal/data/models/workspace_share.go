@@ -0,0 +1,25 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"time"
+)
+
+// WorkspaceShare grants a user of the workspace owner's organization a role
+// on the workspace.
+type WorkspaceShare struct {
+	ID          uint64              `gorm:"primaryKey"`
+	WorkspaceID uint64              `gorm:"not null"`
+	UserID      uint64              `gorm:"not null"`
+	Role        enums.WorkspaceRole `gorm:"type:varchar(20);not null"`
+	SharedByID  uint64              `gorm:"not null"`
+
+	User User `gorm:"foreignKey:UserID"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (WorkspaceShare) TableName() string {
+	return "workspace_shares"
+}
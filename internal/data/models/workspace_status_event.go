@@ -10,5 +10,16 @@ type WorkspaceStatusEvent struct {
 	WorkspaceID uint64                `gorm:"index;not null"`
 	Status      enums.WorkspaceStatus `gorm:"type:varchar(50);not null"`
 	Message     string                `gorm:"type:text"`
+	ErrorCode   string                `gorm:"type:varchar(50)"`
+	ErrorReason string                `gorm:"type:text"`
 	CreatedAt   time.Time             `gorm:"autoCreateTime"`
+
+	// Actor triggered the change, ActorUserID is the user when a user did.
+	// Both are empty on events recorded before actors were.
+	Actor       enums.WorkspaceActor `gorm:"type:varchar(20);not null;default:''"`
+	ActorUserID *uint64
+
+	// RunID is the run that made the change, nil for changes made outside
+	// of a worker run.
+	RunID *uint64
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"clusterix-code/internal/data/enums"
+	"gorm.io/gorm"
+	"time"
+)
+
+// WorkspaceTemplate is an organization's preset for new workspaces. Its
+// environment variables are stored as env vars of the template scope.
+type WorkspaceTemplate struct {
+	ID               uint64           `gorm:"primaryKey"`
+	OrganizationID   uint32           `gorm:"not null"`
+	Name             string           `gorm:"type:varchar(255);not null"`
+	Description      string           `gorm:"type:text;not null;default:''"`
+	RepositoryID     uint64           `gorm:"not null"`
+	MachineConfigID  *uint64          // overrides the repository's machine config
+	ProviderID       uint64           `gorm:"not null"`
+	Ide              string           `gorm:"type:varchar(50);not null;default:''"`
+	Color            string           `gorm:"type:varchar(30);not null;default:''"`
+	GitRefType       enums.GitRefType `gorm:"type:varchar(20);not null;default:''"`
+	GitRef           string           `gorm:"type:varchar(255);not null;default:''"`
+	Subfolder        string           `gorm:"type:text;not null;default:''"`
+	DevcontainerPath string           `gorm:"type:text;not null;default:''"`
+	Tags             []string         `gorm:"type:text[]"`
+	CreatedByID      uint64           `gorm:"not null"`
+
+	Repository    Repository    `gorm:"foreignKey:RepositoryID"`
+	MachineConfig MachineConfig `gorm:"foreignKey:MachineConfigID"`
+	Provider      Provider      `gorm:"foreignKey:ProviderID"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (WorkspaceTemplate) TableName() string {
+	return "workspace_templates"
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"clusterix-code/internal/utils/di"
 	"context"
+	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/google/uuid"
@@ -47,6 +48,14 @@ func (r *Repository[T]) RawQuery(ctx context.Context, query string, args ...inte
 	return &entity, nil
 }
 
+// advisoryXactLock takes the transaction-scoped advisory lock of the key in
+// the namespace. The namespaced key is hashed to the 64-bit lock key rather
+// than truncated, so a collision can only make unrelated keys wait on each
+// other.
+func advisoryXactLock(tx *gorm.DB, namespace string, key uint64) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", fmt.Sprintf("%s:%d", namespace, key)).Error
+}
+
 // Repository registry
 type Repositories struct {
 	User                   *UserRepository
@@ -57,7 +66,21 @@ type Repositories struct {
 	Workspace              *WorkspaceRepository
 	WorkspaceConfig        *WorkspaceConfigRepository
 	WorkspaceStatusEvent   *WorkspaceStatusEventRepository
+	WorkspaceAction        *WorkspaceActionRepository
 	WorkspaceLog           *WorkspaceLogRepository
+	EnvVar                 *EnvVarRepository
+	UserPreference         *UserPreferenceRepository
+	WorkspaceShare         *WorkspaceShareRepository
+	WorkspaceTemplate      *WorkspaceTemplateRepository
+	Quota                  *QuotaRepository
+	Budget                 *BudgetRepository
+	OrganizationSetting    *OrganizationSettingRepository
+	WorkspaceSchedule      *WorkspaceScheduleRepository
+	BulkOperation          *BulkOperationRepository
+	WorkspaceRun           *WorkspaceRunRepository
+	Webhook                *WebhookRepository
+	WebhookDelivery        *WebhookDeliveryRepository
+	WorkspacePort          *WorkspacePortRepository
 }
 
 func Provider(c *di.Container) (*Repositories, error) {
@@ -77,6 +100,20 @@ func NewRepositories(db *gorm.DB, mongoDB *mongo.Database) *Repositories {
 		Workspace:              NewWorkspaceRepository(db),
 		WorkspaceConfig:        NewWorkspaceConfigRepository(db),
 		WorkspaceStatusEvent:   NewWorkspaceStatusEventRepository(db),
+		WorkspaceAction:        NewWorkspaceActionRepository(db),
 		WorkspaceLog:           NewWorkspaceLogRepository(mongoDB),
+		EnvVar:                 NewEnvVarRepository(db),
+		UserPreference:         NewUserPreferenceRepository(db),
+		WorkspaceShare:         NewWorkspaceShareRepository(db),
+		WorkspaceTemplate:      NewWorkspaceTemplateRepository(db),
+		Quota:                  NewQuotaRepository(db),
+		Budget:                 NewBudgetRepository(db),
+		OrganizationSetting:    NewOrganizationSettingRepository(db),
+		WorkspaceSchedule:      NewWorkspaceScheduleRepository(db),
+		BulkOperation:          NewBulkOperationRepository(db),
+		WorkspaceRun:           NewWorkspaceRunRepository(db),
+		Webhook:                NewWebhookRepository(db),
+		WebhookDelivery:        NewWebhookDeliveryRepository(db),
+		WorkspacePort:          NewWorkspacePortRepository(db),
 	}
 }
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BudgetRepository struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// Get returns the organization's budget, or nil if it has none.
+func (r *BudgetRepository) Get(ctx context.Context, organizationID uint32) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		First(&budget).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *BudgetRepository) GetAll(ctx context.Context) ([]models.Budget, error) {
+	var budgets []models.Budget
+	err := r.db.WithContext(ctx).Order("id").Find(&budgets).Error
+	return budgets, err
+}
+
+// Save sets the organization's budget, replacing an existing one.
+func (r *BudgetRepository) Save(ctx context.Context, budget *models.Budget) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"monthly_amount", "policy", "updated_at"}),
+		}).
+		Create(budget).Error
+}
+
+// Delete removes the organization's budget, reporting whether it had one.
+func (r *BudgetRepository) Delete(ctx context.Context, organizationID uint32) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Delete(&models.Budget{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateAlert records the alert unless the threshold already alerted in
+// that month, and reports whether it did.
+func (r *BudgetRepository) CreateAlert(ctx context.Context, alert *models.BudgetAlert) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *BudgetRepository) GetAlerts(ctx context.Context, organizationID uint32, page, limit int) (pagination.Pagination, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.BudgetAlert{}).
+		Where("organization_id = ?", organizationID).
+		Order("id DESC")
+
+	return pagination.GormPaginate[models.BudgetAlert](query, page, limit)
+}
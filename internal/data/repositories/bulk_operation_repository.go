@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BulkOperationRepository struct {
+	db *gorm.DB
+}
+
+func NewBulkOperationRepository(db *gorm.DB) *BulkOperationRepository {
+	return &BulkOperationRepository{db: db}
+}
+
+// Create stores the operation together with its items.
+func (r *BulkOperationRepository) Create(ctx context.Context, operation *models.BulkOperation) error {
+	return r.db.WithContext(ctx).Create(operation).Error
+}
+
+// GetByID returns the operation with its items. An organizationID of 0
+// matches any organization.
+func (r *BulkOperationRepository) GetByID(ctx context.Context, organizationID uint32, id uint64) (*models.BulkOperation, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") })
+	if organizationID != 0 {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var operation models.BulkOperation
+	if err := query.First(&operation, id).Error; err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+// GetAll returns the organization's operations, latest first, without
+// their items.
+func (r *BulkOperationRepository) GetAll(ctx context.Context, organizationID uint32, page, limit int) (pagination.Pagination, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.BulkOperation{}).
+		Where("organization_id = ?", organizationID).
+		Order("created_at DESC")
+
+	return pagination.GormPaginate[models.BulkOperation](query, page, limit)
+}
+
+func (r *BulkOperationRepository) UpdateStatus(ctx context.Context, id uint64, status enums.BulkOperationStatus) error {
+	return r.db.WithContext(ctx).
+		Model(&models.BulkOperation{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+func (r *BulkOperationRepository) FinishItem(ctx context.Context, itemID uint64, status enums.BulkOperationItemStatus, message string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.BulkOperationItem{}).
+		Where("id = ?", itemID).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"message": message,
+		}).Error
+}
+
+// Complete counts the outcomes of the operation's items and marks it
+// completed.
+func (r *BulkOperationRepository) Complete(ctx context.Context, id uint64, at time.Time) error {
+	return r.finish(r.db.WithContext(ctx), id, enums.BulkOperationStatusCompleted, at)
+}
+
+// Fail marks the operation and the items it has not been applied to as
+// failed with the message.
+func (r *BulkOperationRepository) Fail(ctx context.Context, id uint64, message string, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.BulkOperationItem{}).
+			Where("bulk_operation_id = ? AND status = ?", id, enums.BulkOperationItemStatusPending).
+			Updates(map[string]interface{}{
+				"status":  enums.BulkOperationItemStatusFailed,
+				"message": message,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return r.finish(tx, id, enums.BulkOperationStatusFailed, at)
+	})
+}
+
+// finish counts the outcomes of the operation's items and gives it its
+// final status.
+func (r *BulkOperationRepository) finish(db *gorm.DB, id uint64, status enums.BulkOperationStatus, at time.Time) error {
+	count := func(itemStatus enums.BulkOperationItemStatus) *gorm.DB {
+		return r.db.Model(&models.BulkOperationItem{}).
+			Select("COUNT(*)").
+			Where("bulk_operation_id = ? AND status = ?", id, itemStatus)
+	}
+
+	return db.
+		Model(&models.BulkOperation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"submitted":   count(enums.BulkOperationItemStatusSubmitted),
+			"skipped":     count(enums.BulkOperationItemStatusSkipped),
+			"failed":      count(enums.BulkOperationItemStatusFailed),
+			"finished_at": at,
+		}).Error
+}
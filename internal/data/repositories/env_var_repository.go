@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type EnvVarRepository struct {
+	db *gorm.DB
+}
+
+func NewEnvVarRepository(db *gorm.DB) *EnvVarRepository {
+	return &EnvVarRepository{db: db}
+}
+
+func (r *EnvVarRepository) Create(ctx context.Context, envVar *models.EnvVar) error {
+	return r.db.WithContext(ctx).Create(envVar).Error
+}
+
+func (r *EnvVarRepository) Update(ctx context.Context, envVar *models.EnvVar) error {
+	return r.db.WithContext(ctx).Save(envVar).Error
+}
+
+func (r *EnvVarRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.EnvVar{}, id).Error
+}
+
+func (r *EnvVarRepository) GetByID(ctx context.Context, scope enums.EnvVarScope, scopeID uint64, id uint64) (*models.EnvVar, error) {
+	var envVar models.EnvVar
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND scope = ? AND scope_id = ?", id, scope, scopeID).
+		First(&envVar).Error
+	if err != nil {
+		return nil, err
+	}
+	return &envVar, nil
+}
+
+func (r *EnvVarRepository) GetByKey(ctx context.Context, scope enums.EnvVarScope, scopeID uint64, key string) (*models.EnvVar, error) {
+	var envVar models.EnvVar
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND scope_id = ? AND key = ?", scope, scopeID, key).
+		First(&envVar).Error
+	if err != nil {
+		return nil, err
+	}
+	return &envVar, nil
+}
+
+func (r *EnvVarRepository) GetByScope(ctx context.Context, scope enums.EnvVarScope, scopeID uint64) ([]models.EnvVar, error) {
+	var envVars []models.EnvVar
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		Order("key ASC").
+		Find(&envVars).Error
+	return envVars, err
+}
+
+// GetForWorkspace returns the variables of every scope a workspace inherits
+// from, in no particular order.
+// A templateID of 0 matches no template.
+func (r *EnvVarRepository) GetForWorkspace(ctx context.Context, organizationID uint32, repositoryID, templateID, workspaceID uint64) ([]models.EnvVar, error) {
+	var envVars []models.EnvVar
+	err := r.db.WithContext(ctx).
+		Where("(scope = ? AND scope_id = ?) OR (scope = ? AND scope_id = ?) OR (scope = ? AND scope_id = ?) OR (scope = ? AND scope_id = ?)",
+			enums.EnvVarScopeOrganization, organizationID,
+			enums.EnvVarScopeRepository, repositoryID,
+			enums.EnvVarScopeTemplate, templateID,
+			enums.EnvVarScopeWorkspace, workspaceID).
+		Find(&envVars).Error
+	return envVars, err
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationSettingRepository(db *gorm.DB) *OrganizationSettingRepository {
+	return &OrganizationSettingRepository{db: db}
+}
+
+// Get returns the organization's settings, or nil if it never set any.
+func (r *OrganizationSettingRepository) Get(ctx context.Context, organizationID uint32) (*models.OrganizationSetting, error) {
+	var setting models.OrganizationSetting
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// GetByOrganizationIDs returns the settings of the organizations that have
+// any, keyed by organization.
+func (r *OrganizationSettingRepository) GetByOrganizationIDs(ctx context.Context, organizationIDs []uint32) (map[uint32]models.OrganizationSetting, error) {
+	var settings []models.OrganizationSetting
+	if err := r.db.WithContext(ctx).Where("organization_id IN ?", organizationIDs).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint32]models.OrganizationSetting, len(settings))
+	for _, setting := range settings {
+		result[setting.OrganizationID] = setting
+	}
+	return result, nil
+}
+
+// Save replaces the organization's settings.
+func (r *OrganizationSettingRepository) Save(ctx context.Context, setting *models.OrganizationSetting) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"idle_timeout_minutes", "stopped_retention_days", "failed_retention_days", "updated_at"}),
+		}).
+		Create(setting).Error
+}
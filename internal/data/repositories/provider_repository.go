@@ -20,6 +20,14 @@ func NewProviderRepository(db *gorm.DB) *ProviderRepository {
 	}
 }
 
+func (r *ProviderRepository) GetByID(ctx context.Context, id uint64) (*models.Provider, error) {
+	var provider models.Provider
+	if err := r.db.WithContext(ctx).First(&provider, id).Error; err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
+
 func (r *ProviderRepository) GetAll(ctx context.Context, with []string, page, limit int) (pagination.Pagination, error) {
 	query := r.db.WithContext(ctx).Model(&models.Provider{})
 	query = preload.ApplyPreloads(query, with)
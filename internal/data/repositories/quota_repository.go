@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type QuotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB) *QuotaRepository {
+	return &QuotaRepository{db: db}
+}
+
+// Get returns the quota of the organization, or of one of its users when
+// userID is set, or nil if there is none.
+func (r *QuotaRepository) Get(ctx context.Context, organizationID uint32, userID *uint64) (*models.Quota, error) {
+	var quota models.Quota
+	query := r.db.WithContext(ctx).Where("organization_id = ?", organizationID)
+	if userID == nil {
+		query = query.Where("user_id IS NULL")
+	} else {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	err := query.First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// GetByOrganization returns the organization's quota and its users' quotas.
+func (r *QuotaRepository) GetByOrganization(ctx context.Context, organizationID uint32) ([]models.Quota, error) {
+	var quotas []models.Quota
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("organization_id = ?", organizationID).
+		Order("user_id NULLS FIRST").
+		Find(&quotas).Error
+	return quotas, err
+}
+
+func (r *QuotaRepository) Create(ctx context.Context, quota *models.Quota) error {
+	return r.db.WithContext(ctx).Create(quota).Error
+}
+
+func (r *QuotaRepository) Update(ctx context.Context, quota *models.Quota) error {
+	return r.db.WithContext(ctx).Omit("User").Save(quota).Error
+}
+
+func (r *QuotaRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.Quota{}, id).Error
+}
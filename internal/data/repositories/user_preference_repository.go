@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewUserPreferenceRepository(db *gorm.DB) *UserPreferenceRepository {
+	return &UserPreferenceRepository{db: db}
+}
+
+// GetByUserID returns the user's preferences, or nil if the user has none.
+func (r *UserPreferenceRepository) GetByUserID(ctx context.Context, userID uint64) (*models.UserPreference, error) {
+	var preference models.UserPreference
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Save creates or replaces the user's preferences.
+func (r *UserPreferenceRepository) Save(ctx context.Context, preference *models.UserPreference) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"dotfiles_repository", "dotfiles_install_script", "default_ide", "default_color",
+				"default_machine_config_id", "default_git_access_token_id", "updated_at",
+			}),
+		}).
+		Create(preference).Error
+}
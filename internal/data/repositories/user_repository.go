@@ -20,6 +20,14 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
+func (r *UserRepository) GetByID(ctx context.Context, id uint64) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) SyncUser(ctx context.Context, userDto *dto.AuthUserDto) (*models.User, error) {
 	user := models.User{
 		ID:             userDto.ID,
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// GetByID returns the webhook's delivery. A webhookID of 0 matches any
+// webhook.
+func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, webhookID uint64, id uint64) (*models.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx)
+	if webhookID != 0 {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+
+	var delivery models.WebhookDelivery
+	if err := query.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetByWebhookID returns the webhook's deliveries, latest first.
+func (r *WebhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID uint64, page, limit int) (pagination.Pagination, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.WebhookDelivery{}).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC")
+
+	return pagination.GormPaginate[models.WebhookDelivery](query, page, limit)
+}
+
+// UpdateAttempt stores the outcome of the delivery's latest attempt.
+func (r *WebhookDeliveryRepository) UpdateAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).
+		Model(delivery).
+		Select("status", "attempts", "response_status", "response_body", "error", "delivered_at").
+		Updates(delivery).Error
+}
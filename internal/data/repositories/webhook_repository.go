@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+// Delete removes the webhook together with its deliveries.
+func (r *WebhookRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.Webhook{}, id).Error
+}
+
+// GetByID returns the webhook. An organizationID of 0 matches any
+// organization.
+func (r *WebhookRepository) GetByID(ctx context.Context, organizationID uint32, id uint64) (*models.Webhook, error) {
+	query := r.db.WithContext(ctx)
+	if organizationID != 0 {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+
+	var webhook models.Webhook
+	if err := query.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) GetByOrganizationID(ctx context.Context, organizationID uint32) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("id ASC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetSubscribed returns the organization's enabled webhooks subscribed to
+// the event, including those subscribed to every event.
+func (r *WebhookRepository) GetSubscribed(ctx context.Context, organizationID uint32, event string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND enabled = ?", organizationID, true).
+		Where("COALESCE(cardinality(events), 0) = 0 OR ? = ANY(events)", event).
+		Order("id ASC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
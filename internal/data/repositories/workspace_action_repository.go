@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// workspaceActionLockNamespace keeps the advisory locks taken on a
+// workspace's action queue apart from any other advisory lock.
+const workspaceActionLockNamespace = "workspace_action"
+
+type WorkspaceActionRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceActionRepository(db *gorm.DB) *WorkspaceActionRepository {
+	return &WorkspaceActionRepository{db: db}
+}
+
+// WorkspaceActionTx holds the repositories a locked change to a workspace's
+// action queue writes through, all bound to its transaction.
+type WorkspaceActionTx struct {
+	Action      *WorkspaceActionRepository
+	Workspace   *WorkspaceRepository
+	StatusEvent *WorkspaceStatusEventRepository
+}
+
+// WithWorkspaceLock runs fn in a transaction holding the advisory lock of the
+// workspace's action queue, so every API instance and worker sees and
+// changes the queue one at a time.
+func (r *WorkspaceActionRepository) WithWorkspaceLock(ctx context.Context, workspaceID uint64, fn func(tx *WorkspaceActionTx) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := advisoryXactLock(tx, workspaceActionLockNamespace, workspaceID); err != nil {
+			return err
+		}
+		return fn(&WorkspaceActionTx{
+			Action:      &WorkspaceActionRepository{db: tx},
+			Workspace:   NewWorkspaceRepository(tx),
+			StatusEvent: NewWorkspaceStatusEventRepository(tx),
+		})
+	})
+}
+
+func (r *WorkspaceActionRepository) Create(ctx context.Context, action *models.WorkspaceAction) error {
+	return r.db.WithContext(ctx).Create(action).Error
+}
+
+func (r *WorkspaceActionRepository) Update(ctx context.Context, action *models.WorkspaceAction) error {
+	return r.db.WithContext(ctx).Save(action).Error
+}
+
+func (r *WorkspaceActionRepository) GetByID(ctx context.Context, id uint64) (*models.WorkspaceAction, error) {
+	var action models.WorkspaceAction
+	if err := r.db.WithContext(ctx).First(&action, id).Error; err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// GetPending returns the running and queued actions of the workspace in the
+// order they were submitted.
+func (r *WorkspaceActionRepository) GetPending(ctx context.Context, workspaceID uint64) ([]models.WorkspaceAction, error) {
+	var actions []models.WorkspaceAction
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND status IN ?", workspaceID, []enums.WorkspaceActionStatus{
+			enums.WorkspaceActionStatusRunning,
+			enums.WorkspaceActionStatusQueued,
+		}).
+		Order("id").
+		Find(&actions).Error
+	return actions, err
+}
+
+// GetNextQueued returns the oldest queued action of the workspace, or nil
+// when the queue is empty.
+func (r *WorkspaceActionRepository) GetNextQueued(ctx context.Context, workspaceID uint64) (*models.WorkspaceAction, error) {
+	var action models.WorkspaceAction
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND status = ?", workspaceID, enums.WorkspaceActionStatusQueued).
+		Order("id").
+		First(&action).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// Finish moves an action to a final status.
+func (r *WorkspaceActionRepository) Finish(ctx context.Context, id uint64, status enums.WorkspaceActionStatus, message string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspaceAction{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"message":     message,
+			"finished_at": time.Now(),
+		}).Error
+}
+
+// RequestCancel marks the action as cancelled by a user.
+func (r *WorkspaceActionRepository) RequestCancel(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspaceAction{}).
+		Where("id = ?", id).
+		Update("cancel_requested_at", time.Now()).Error
+}
+
+// GetRunningStartedBefore returns the running actions of every workspace
+// that were handed to a worker before the given time.
+func (r *WorkspaceActionRepository) GetRunningStartedBefore(ctx context.Context, before time.Time) ([]models.WorkspaceAction, error) {
+	var actions []models.WorkspaceAction
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND started_at < ?", enums.WorkspaceActionStatusRunning, before).
+		Order("id").
+		Find(&actions).Error
+	return actions, err
+}
+
+// FinishQueued moves every queued action of the workspace to a final status
+// and returns how many it touched.
+func (r *WorkspaceActionRepository) FinishQueued(ctx context.Context, workspaceID uint64, status enums.WorkspaceActionStatus, message string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.WorkspaceAction{}).
+		Where("workspace_id = ? AND status = ?", workspaceID, enums.WorkspaceActionStatusQueued).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"message":     message,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -49,6 +49,26 @@ func (r *WorkspaceLogRepository) GetLatestLogs(ctx context.Context, workspaceID
 	return logs, nil
 }
 
+// GetRunLogs returns the log lines of a run, oldest first.
+func (r *WorkspaceLogRepository) GetRunLogs(ctx context.Context, workspaceID, runID uint64) ([]*models.WorkspaceLog, error) {
+	filter := bson.M{"workspaceid": workspaceID, "runid": runID}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdat", Value: 1}}).
+		SetLimit(5000)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var logs []*models.WorkspaceLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
 func (r *WorkspaceLogRepository) Create(ctx context.Context, log *models.WorkspaceLog) error {
 	_, err := r.collection.InsertOne(ctx, log)
 	return err
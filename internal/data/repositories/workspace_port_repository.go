@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type WorkspacePortRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspacePortRepository(db *gorm.DB) *WorkspacePortRepository {
+	return &WorkspacePortRepository{db: db}
+}
+
+func (r *WorkspacePortRepository) Create(ctx context.Context, port *models.WorkspacePort) error {
+	return r.db.WithContext(ctx).Create(port).Error
+}
+
+// Update saves the port's declaration. The worker port is left to the
+// worker.
+func (r *WorkspacePortRepository) Update(ctx context.Context, port *models.WorkspacePort) error {
+	return r.db.WithContext(ctx).
+		Model(port).
+		Select("label", "visibility", "source").
+		Updates(port).Error
+}
+
+func (r *WorkspacePortRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.WorkspacePort{}, id).Error
+}
+
+func (r *WorkspacePortRepository) GetByPort(ctx context.Context, workspaceID uint64, port int) (*models.WorkspacePort, error) {
+	var workspacePort models.WorkspacePort
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND port = ?", workspaceID, port).
+		First(&workspacePort).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workspacePort, nil
+}
+
+func (r *WorkspacePortRepository) GetByWorkspaceID(ctx context.Context, workspaceID uint64) ([]models.WorkspacePort, error) {
+	var ports []models.WorkspacePort
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ?", workspaceID).
+		Order("port ASC").
+		Find(&ports).Error
+	return ports, err
+}
+
+// GetPortNumbers returns the numbers of the ports the workspace exposes.
+func (r *WorkspacePortRepository) GetPortNumbers(ctx context.Context, workspaceID uint64) ([]int, error) {
+	var numbers []int
+	err := r.db.WithContext(ctx).
+		Model(&models.WorkspacePort{}).
+		Where("workspace_id = ?", workspaceID).
+		Pluck("port", &numbers).Error
+	return numbers, err
+}
+
+func (r *WorkspacePortRepository) CountByWorkspaceID(ctx context.Context, workspaceID uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.WorkspacePort{}).
+		Where("workspace_id = ?", workspaceID).
+		Count(&count).Error
+	return count, err
+}
+
+// SetWorkerPort records the port on the worker the port is forwarded to.
+func (r *WorkspacePortRepository) SetWorkerPort(ctx context.Context, id uint64, workerPort int) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspacePort{}).
+		Where("id = ?", id).
+		Update("worker_port", workerPort).Error
+}
+
+// ClearWorkerPort records that the port is no longer forwarded to the
+// worker port, unless a newer forward already replaced it.
+func (r *WorkspacePortRepository) ClearWorkerPort(ctx context.Context, id uint64, workerPort int) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspacePort{}).
+		Where("id = ? AND worker_port = ?", id, workerPort).
+		Update("worker_port", nil).Error
+}
+
+// ClearAllWorkerPorts records that no port is forwarded anywhere.
+func (r *WorkspacePortRepository) ClearAllWorkerPorts(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspacePort{}).
+		Where("worker_port IS NOT NULL").
+		Update("worker_port", nil).Error
+}
+
+// GetRunningWorkspaceIDs returns the running workspaces that expose ports.
+func (r *WorkspacePortRepository) GetRunningWorkspaceIDs(ctx context.Context) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.WithContext(ctx).
+		Model(&models.WorkspacePort{}).
+		Distinct("workspace_ports.workspace_id").
+		Joins("JOIN workspaces ON workspaces.id = workspace_ports.workspace_id").
+		Where("workspaces.status = ? AND workspaces.deleted_at IS NULL", enums.WorkspaceStatusRunning).
+		Pluck("workspace_ports.workspace_id", &ids).Error
+	return ids, err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/data/models"
 	"clusterix-code/internal/utils/pagination"
 	"clusterix-code/internal/utils/preload"
@@ -8,8 +9,13 @@ import (
 	"gorm.io/gorm"
 
 	"context"
+	"time"
 )
 
+// workspaceQuotaLockNamespace keeps the advisory locks taken on an
+// organization's quotas apart from any other advisory lock.
+const workspaceQuotaLockNamespace = "workspace_quota"
+
 type WorkspaceRepository struct {
 	*Repository[models.Workspace]
 }
@@ -20,12 +26,27 @@ func NewWorkspaceRepository(db *gorm.DB) *WorkspaceRepository {
 	}
 }
 
+// Transaction runs fn with a repository bound to a new transaction.
+func (r *WorkspaceRepository) Transaction(ctx context.Context, fn func(tx *WorkspaceRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewWorkspaceRepository(tx))
+	})
+}
+
+// LockQuota takes the advisory lock of the organization's quotas. The
+// repository must be bound to a transaction, which holds the lock until it
+// ends.
+func (r *WorkspaceRepository) LockQuota(ctx context.Context, organizationID uint32) error {
+	return advisoryXactLock(r.db.WithContext(ctx), workspaceQuotaLockNamespace, uint64(organizationID))
+}
+
 func (r *WorkspaceRepository) GetByID(ctx context.Context, id uint64) (*models.Workspace, error) {
 	var workspace models.Workspace
 	err := r.db.WithContext(ctx).
 		Preload("User").
 		Preload("Repository").
 		Preload("Repository.MachineConfig").
+		Preload("MachineConfig").
 		Preload("WorkspaceConfig").
 		First(&workspace, id).Error
 	if err != nil {
@@ -37,8 +58,10 @@ func (r *WorkspaceRepository) GetByID(ctx context.Context, id uint64) (*models.W
 func (r *WorkspaceRepository) GetByIDIncludingDeleted(ctx context.Context, id uint64) (*models.Workspace, error) {
 	var workspace models.Workspace
 	err := r.db.WithContext(ctx).
+		Unscoped().
 		Preload("Repository").
 		Preload("Repository.MachineConfig").
+		Preload("MachineConfig").
 		Preload("WorkspaceConfig").
 		Preload("GitPersonalAccessToken").
 		First(&workspace, id).Error
@@ -48,6 +71,24 @@ func (r *WorkspaceRepository) GetByIDIncludingDeleted(ctx context.Context, id ui
 	return &workspace, nil
 }
 
+// GetByIDsIncludingDeleted loads the workspaces with their owner, repository
+// and machine configs, including soft-deleted ones.
+func (r *WorkspaceRepository) GetByIDsIncludingDeleted(ctx context.Context, ids []uint64) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Repository", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Repository.MachineConfig").
+		Preload("MachineConfig").
+		Where("id IN ?", ids).
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
 func (r *WorkspaceRepository) GetByFingerprint(ctx context.Context, fingerprint string) (*models.Workspace, error) {
 	var workspace models.Workspace
 	err := r.db.WithContext(ctx).
@@ -83,6 +124,109 @@ func (r *WorkspaceRepository) Search(ctx context.Context, userId uint64, search
 	return pagination.GormPaginate[models.Workspace](query, page, limit)
 }
 
+// GetSharedWorkspaces returns the workspaces other users shared with the
+// user.
+func (r *WorkspaceRepository) GetSharedWorkspaces(ctx context.Context, userId uint64, with []string, page, limit int) (pagination.Pagination, error) {
+	shared := r.db.Table("workspace_shares").
+		Select("workspace_id").
+		Where("user_id = ?", userId)
+
+	query := r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("id IN (?)", shared)
+
+	query = preload.ApplyPreloads(query, with)
+
+	return pagination.GormPaginate[models.Workspace](query, page, limit)
+}
+
+// WorkspaceUsage is what a set of workspaces counts against a quota. Only
+// active workspaces count as running and use CPU cores and memory.
+type WorkspaceUsage struct {
+	Workspaces        int64
+	RunningWorkspaces int64
+	CPUCores          int64
+	MemoryGB          float64
+}
+
+// GetUsage sums up the usage of the organization's workspaces, or of one of
+// its users' when userID is set. The workspace excludeID is left out.
+func (r *WorkspaceRepository) GetUsage(ctx context.Context, organizationID uint32, userID *uint64, excludeID uint64) (WorkspaceUsage, error) {
+	var usage WorkspaceUsage
+	query := r.db.WithContext(ctx).
+		Table("workspaces AS w").
+		Select(`COUNT(*) AS workspaces,
+			COUNT(*) FILTER (WHERE w.status IN ?) AS running_workspaces,
+			COALESCE(SUM(mc.cpu_cores) FILTER (WHERE w.status IN ?), 0) AS cpu_cores,
+			COALESCE(SUM(mc.memory_gb) FILTER (WHERE w.status IN ?), 0) AS memory_gb`,
+			enums.ActiveWorkspaceStatuses, enums.ActiveWorkspaceStatuses, enums.ActiveWorkspaceStatuses).
+		Joins("LEFT JOIN repositories AS r ON r.id = w.repository_id").
+		Joins("LEFT JOIN machine_configs AS mc ON mc.id = COALESCE(w.machine_config_id, r.machine_config_id)").
+		Where("w.deleted_at IS NULL AND w.status <> ?", enums.WorkspaceStatusTerminated).
+		Where("w.organization_id = ? AND w.id <> ?", organizationID, excludeID)
+	if userID != nil {
+		query = query.Where("w.user_id = ?", *userID)
+	}
+
+	err := query.Scan(&usage).Error
+	return usage, err
+}
+
+// GetByOrganizationAndStatuses returns the organization's workspaces in any
+// of the statuses.
+func (r *WorkspaceRepository) GetByOrganizationAndStatuses(ctx context.Context, organizationID uint32, statuses []enums.WorkspaceStatus) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND status IN ?", organizationID, statuses).
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// GetByIDs returns the workspaces with the given IDs; missing ones are
+// left out.
+func (r *WorkspaceRepository) GetByIDs(ctx context.Context, ids []uint64) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	if len(ids) == 0 {
+		return workspaces, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("id IN ?", ids).
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// GetByTemplateID returns the workspaces created from the template.
+func (r *WorkspaceRepository) GetByTemplateID(ctx context.Context, templateID uint64) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.WithContext(ctx).
+		Where("template_id = ?", templateID).
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// GetNotTerminated returns every workspace that has not reached the terminated
+// status, including soft-deleted ones whose termination is still pending.
+func (r *WorkspaceRepository) GetNotTerminated(ctx context.Context) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("status <> ?", enums.WorkspaceStatusTerminated).
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
 func (r *WorkspaceRepository) DeleteWorkspace(ctx context.Context, workspaceId string) error {
 	if err := r.db.WithContext(ctx).Delete(&models.Workspace{}, workspaceId).Error; err != nil {
 		return err
@@ -90,11 +234,157 @@ func (r *WorkspaceRepository) DeleteWorkspace(ctx context.Context, workspaceId s
 	return nil
 }
 
-func (r *WorkspaceRepository) UpdateStatus(ctx context.Context, workspaceID uint64, status string) error {
+// Update saves the workspace details. Status, version and activity are left
+// out so a stale copy cannot overwrite what a worker or the reverse proxy
+// wrote in the meantime.
+func (r *WorkspaceRepository) Update(ctx context.Context, workspace *models.Workspace) error {
+	return r.db.WithContext(ctx).Omit("Status", "Version", "LastActivityAt", "IdleWarnedAt", "ExpiryWarnedAt", "PurgedAt").Save(workspace).Error
+}
+
+// GetStatusByID loads only the status and version of a workspace, including
+// soft-deleted ones.
+func (r *WorkspaceRepository) GetStatusByID(ctx context.Context, id uint64) (*models.Workspace, error) {
+	var workspace models.Workspace
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Select("id", "status", "version").
+		First(&workspace, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// UpdateStatusWithVersion sets the status only if the row still carries the
+// expected version and reports whether it did.
+func (r *WorkspaceRepository) UpdateStatusWithVersion(ctx context.Context, workspaceID uint64, status string, version uint64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.Workspace{}).
+		Where("id = ? AND version = ?", workspaceID, version).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordActivity sets the last activity of the workspace with the
+// fingerprint and clears its idle warning.
+func (r *WorkspaceRepository) RecordActivity(ctx context.Context, fingerprint string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("fingerprint = ?", fingerprint).
+		Updates(map[string]interface{}{
+			"last_activity_at": at,
+			"idle_warned_at":   nil,
+		}).Error
+}
+
+// IdleWorkspace is a running workspace with the time it became idle: its
+// last activity, or when it started running if it had none since.
+type IdleWorkspace struct {
+	ID                 uint64
+	UserID             uint64
+	OrganizationID     uint32
+	Ide                string
+	IdleTimeoutMinutes *int
+	IdleWarnedAt       *time.Time
+	IdleSince          time.Time
+}
+
+func (r *WorkspaceRepository) GetIdleCandidates(ctx context.Context) ([]IdleWorkspace, error) {
+	var workspaces []IdleWorkspace
+	err := r.db.WithContext(ctx).
+		Table("workspaces AS w").
+		Select(`w.id, w.user_id, w.organization_id, w.ide, w.idle_timeout_minutes, w.idle_warned_at,
+			GREATEST(
+				COALESCE(w.last_activity_at, 'epoch'),
+				COALESCE((SELECT MAX(e.created_at) FROM workspace_status_events AS e
+					WHERE e.workspace_id = w.id AND e.status = ?), 'epoch')
+			) AS idle_since`, enums.WorkspaceStatusRunning).
+		Where("w.deleted_at IS NULL AND w.status = ?", enums.WorkspaceStatusRunning).
+		Scan(&workspaces).Error
+	return workspaces, err
+}
+
+func (r *WorkspaceRepository) MarkIdleWarned(ctx context.Context, workspaceID uint64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("id = ?", workspaceID).
+		Update("idle_warned_at", at).Error
+}
+
+func (r *WorkspaceRepository) UpdateIdleTimeout(ctx context.Context, workspaceID uint64, minutes *int) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("id = ?", workspaceID).
+		Update("idle_timeout_minutes", minutes).Error
+}
+
+// ExpiringWorkspace is a stopped or failed workspace with the time it
+// entered that status.
+type ExpiringWorkspace struct {
+	ID             uint64
+	UserID         uint64
+	OrganizationID uint32
+	Status         enums.WorkspaceStatus
+	ExpiryWarnedAt *time.Time
+	StatusSince    time.Time
+}
+
+// GetExpiryCandidates returns the stopped and failed workspaces that are not
+// kept.
+func (r *WorkspaceRepository) GetExpiryCandidates(ctx context.Context) ([]ExpiringWorkspace, error) {
+	var workspaces []ExpiringWorkspace
+	err := r.db.WithContext(ctx).
+		Table("workspaces AS w").
+		Select(`w.id, w.user_id, w.organization_id, w.status, w.expiry_warned_at,
+			COALESCE((SELECT MAX(e.created_at) FROM workspace_status_events AS e
+				WHERE e.workspace_id = w.id AND e.status = w.status), w.updated_at) AS status_since`).
+		Where("w.deleted_at IS NULL AND w.keep = ? AND w.status IN ?",
+			false, []enums.WorkspaceStatus{enums.WorkspaceStatusStopped, enums.WorkspaceStatusFailed}).
+		Scan(&workspaces).Error
+	return workspaces, err
+}
+
+func (r *WorkspaceRepository) MarkExpiryWarned(ctx context.Context, workspaceID uint64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("id = ?", workspaceID).
+		Update("expiry_warned_at", at).Error
+}
+
+func (r *WorkspaceRepository) UpdateKeep(ctx context.Context, workspaceID uint64, keep bool) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("id = ?", workspaceID).
+		Update("keep", keep).Error
+}
+
+// GetUnpurged returns the deleted workspaces whose machine or DNS record
+// may still exist.
+func (r *WorkspaceRepository) GetUnpurged(ctx context.Context) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND purged_at IS NULL").
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepository) MarkPurged(ctx context.Context, workspaceID uint64, at time.Time) error {
 	return r.db.WithContext(ctx).
+		Unscoped().
 		Model(&models.Workspace{}).
 		Where("id = ?", workspaceID).
-		Update("status", status).Error
+		Update("purged_at", at).Error
 }
 
 func (r *WorkspaceRepository) UpdateURL(ctx context.Context, workspaceID uint64, url string) error {
@@ -103,3 +393,75 @@ func (r *WorkspaceRepository) UpdateURL(ctx context.Context, workspaceID uint64,
 		Where("id = ?", workspaceID).
 		Update("url", url).Error
 }
+
+// WorkspaceFilter narrows down the workspaces of an organization. Zero
+// values don't filter; MachineConfigID matches the machine config the
+// workspace runs on, its own or else its repository's.
+type WorkspaceFilter struct {
+	UserID          uint64
+	Status          enums.WorkspaceStatus
+	MachineConfigID uint64
+	RepositoryID    uint64
+	TemplateID      uint64
+	Search          string
+}
+
+func (r *WorkspaceRepository) filterOrganizationWorkspaces(ctx context.Context, organizationID uint32, filter WorkspaceFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("organization_id = ?", organizationID)
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.MachineConfigID != 0 {
+		query = query.Where(`COALESCE(workspaces.machine_config_id,
+			(SELECT repositories.machine_config_id FROM repositories WHERE repositories.id = workspaces.repository_id)) = ?`,
+			filter.MachineConfigID)
+	}
+	if filter.RepositoryID != 0 {
+		query = query.Where("repository_id = ?", filter.RepositoryID)
+	}
+	if filter.TemplateID != 0 {
+		query = query.Where("template_id = ?", filter.TemplateID)
+	}
+	if filter.Search != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Search+"%")
+	}
+	return query
+}
+
+// GetOrganizationWorkspaces returns the organization's workspaces matching
+// the filter, whoever owns them.
+func (r *WorkspaceRepository) GetOrganizationWorkspaces(ctx context.Context, organizationID uint32, filter WorkspaceFilter, with []string, page, limit int) (pagination.Pagination, error) {
+	query := r.filterOrganizationWorkspaces(ctx, organizationID, filter).Order("id ASC")
+
+	query = preload.ApplyPreloads(query, with)
+
+	return pagination.GormPaginate[models.Workspace](query, page, limit)
+}
+
+// GetOrganizationWorkspaceIDs returns the IDs of the organization's
+// workspaces matching the filter.
+func (r *WorkspaceRepository) GetOrganizationWorkspaceIDs(ctx context.Context, organizationID uint32, filter WorkspaceFilter) ([]uint64, error) {
+	var ids []uint64
+	err := r.filterOrganizationWorkspaces(ctx, organizationID, filter).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateOwner hands the workspace over to another user, who clones with
+// their own git access token.
+func (r *WorkspaceRepository) UpdateOwner(ctx context.Context, workspaceID, userID, gitPersonalAccessTokenID uint64) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Workspace{}).
+		Where("id = ?", workspaceID).
+		Updates(map[string]interface{}{
+			"user_id":                      userID,
+			"git_personal_access_token_id": gitPersonalAccessTokenID,
+		}).Error
+}
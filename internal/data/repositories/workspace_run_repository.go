@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkspaceRunRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceRunRepository(db *gorm.DB) *WorkspaceRunRepository {
+	return &WorkspaceRunRepository{db: db}
+}
+
+func (r *WorkspaceRunRepository) Create(ctx context.Context, run *models.WorkspaceRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// Finish records the outcome of a running run.
+func (r *WorkspaceRunRepository) Finish(ctx context.Context, id uint64, status enums.WorkspaceRunStatus, errorCode, errorReason string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspaceRun{}).
+		Where("id = ? AND status = ?", id, enums.WorkspaceRunStatusRunning).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"error_code":   errorCode,
+			"error_reason": errorReason,
+			"finished_at":  at,
+		}).Error
+}
+
+// InterruptRunning marks the workspace's runs still running as interrupted.
+func (r *WorkspaceRunRepository) InterruptRunning(ctx context.Context, workspaceID uint64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspaceRun{}).
+		Where("workspace_id = ? AND status = ?", workspaceID, enums.WorkspaceRunStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      enums.WorkspaceRunStatusInterrupted,
+			"finished_at": at,
+		}).Error
+}
+
+// GetRunning returns the workspace's running run, or nil when no worker is
+// running one of its actions.
+func (r *WorkspaceRunRepository) GetRunning(ctx context.Context, workspaceID uint64) (*models.WorkspaceRun, error) {
+	var run models.WorkspaceRun
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND status = ?", workspaceID, enums.WorkspaceRunStatusRunning).
+		Order("id DESC").
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *WorkspaceRunRepository) GetByID(ctx context.Context, workspaceID, id uint64) (*models.WorkspaceRun, error) {
+	var run models.WorkspaceRun
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ?", workspaceID).
+		First(&run, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetByWorkspaceID returns the workspace's runs, latest first.
+func (r *WorkspaceRunRepository) GetByWorkspaceID(ctx context.Context, workspaceID uint64, page, limit int) (pagination.Pagination, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.WorkspaceRun{}).
+		Where("workspace_id = ?", workspaceID).
+		Order("id DESC")
+
+	return pagination.GormPaginate[models.WorkspaceRun](query, page, limit)
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkspaceScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceScheduleRepository(db *gorm.DB) *WorkspaceScheduleRepository {
+	return &WorkspaceScheduleRepository{db: db}
+}
+
+func (r *WorkspaceScheduleRepository) Create(ctx context.Context, schedule *models.WorkspaceSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *WorkspaceScheduleRepository) Update(ctx context.Context, schedule *models.WorkspaceSchedule) error {
+	return r.db.WithContext(ctx).Save(schedule).Error
+}
+
+func (r *WorkspaceScheduleRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.WorkspaceSchedule{}, id).Error
+}
+
+func (r *WorkspaceScheduleRepository) GetByID(ctx context.Context, scope enums.WorkspaceScheduleScope, scopeID uint64, id uint64) (*models.WorkspaceSchedule, error) {
+	var schedule models.WorkspaceSchedule
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND scope = ? AND scope_id = ?", id, scope, scopeID).
+		First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *WorkspaceScheduleRepository) GetByScope(ctx context.Context, scope enums.WorkspaceScheduleScope, scopeID uint64) ([]models.WorkspaceSchedule, error) {
+	var schedules []models.WorkspaceSchedule
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		Order("id ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *WorkspaceScheduleRepository) GetEnabled(ctx context.Context) ([]models.WorkspaceSchedule, error) {
+	var schedules []models.WorkspaceSchedule
+	err := r.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Order("id ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// GetScheduledWorkspaceIDs returns the workspaces with schedules of their
+// own, enabled or not.
+func (r *WorkspaceScheduleRepository) GetScheduledWorkspaceIDs(ctx context.Context) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.WithContext(ctx).
+		Model(&models.WorkspaceSchedule{}).
+		Where("scope = ?", enums.WorkspaceScheduleScopeWorkspace).
+		Distinct().
+		Pluck("scope_id", &ids).Error
+	return ids, err
+}
+
+// MarkApplied records when the scheduler acted on the schedule, leaving
+// updated_at alone.
+func (r *WorkspaceScheduleRepository) MarkApplied(ctx context.Context, id uint64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.WorkspaceSchedule{}).
+		Where("id = ?", id).
+		UpdateColumn("last_applied_at", at).Error
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkspaceShareRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceShareRepository(db *gorm.DB) *WorkspaceShareRepository {
+	return &WorkspaceShareRepository{db: db}
+}
+
+func (r *WorkspaceShareRepository) GetByWorkspaceID(ctx context.Context, workspaceID uint64) ([]models.WorkspaceShare, error) {
+	var shares []models.WorkspaceShare
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("workspace_id = ?", workspaceID).
+		Order("id").
+		Find(&shares).Error
+	return shares, err
+}
+
+// Get returns the user's share of the workspace, or nil if the workspace
+// isn't shared with the user.
+func (r *WorkspaceShareRepository) Get(ctx context.Context, workspaceID, userID uint64) (*models.WorkspaceShare, error) {
+	var share models.WorkspaceShare
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&share).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Save shares the workspace with the user, replacing the role of an
+// existing share.
+func (r *WorkspaceShareRepository) Save(ctx context.Context, share *models.WorkspaceShare) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "shared_by_id", "updated_at"}),
+		}).
+		Create(share).Error
+}
+
+// Delete unshares the workspace, reporting whether it was shared.
+func (r *WorkspaceShareRepository) Delete(ctx context.Context, workspaceID, userID uint64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Delete(&models.WorkspaceShare{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,8 +1,12 @@
 package repositories
 
 import (
+	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
 	"context"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -18,3 +22,64 @@ func NewWorkspaceStatusEventRepository(db *gorm.DB) *WorkspaceStatusEventReposit
 func (r *WorkspaceStatusEventRepository) Create(ctx context.Context, event *models.WorkspaceStatusEvent) error {
 	return r.db.WithContext(ctx).Create(event).Error
 }
+
+// GetLatestFailure returns the most recent failed event of the workspace, or
+// nil when it never failed.
+func (r *WorkspaceStatusEventRepository) GetLatestFailure(ctx context.Context, workspaceID uint64) (*models.WorkspaceStatusEvent, error) {
+	var event models.WorkspaceStatusEvent
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND status = ?", workspaceID, enums.WorkspaceStatusFailed).
+		Order("id DESC").
+		First(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetForPeriod returns the status events of the organization's workspaces
+// recorded before to, starting with the last event of each workspace before
+// from so its status at from is known. Events are ordered per workspace.
+func (r *WorkspaceStatusEventRepository) GetForPeriod(ctx context.Context, organizationID uint32, from, to time.Time) ([]models.WorkspaceStatusEvent, error) {
+	previous := r.db.Table("workspace_status_events AS p").
+		Select("MAX(p.id)").
+		Where("p.workspace_id = e.workspace_id AND p.created_at < ?", from)
+
+	var events []models.WorkspaceStatusEvent
+	err := r.db.WithContext(ctx).
+		Table("workspace_status_events AS e").
+		Select("e.*").
+		Joins("JOIN workspaces AS w ON w.id = e.workspace_id").
+		Where("w.organization_id = ? AND e.created_at < ?", organizationID, to).
+		Where("e.created_at >= ? OR e.id = (?)", from, previous).
+		Order("e.workspace_id, e.created_at, e.id").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// WorkspaceStatusEventSpan is a status event with the time the workspace
+// left the status, nil while it is still in it.
+type WorkspaceStatusEventSpan struct {
+	models.WorkspaceStatusEvent
+	EndedAt *time.Time
+}
+
+// GetTimeline returns the workspace's status events, latest first, each with
+// the time of the event that followed it.
+func (r *WorkspaceStatusEventRepository) GetTimeline(ctx context.Context, workspaceID uint64, page, limit int) (pagination.Pagination, error) {
+	spans := r.db.Table("workspace_status_events").
+		Select("*, LEAD(created_at) OVER (ORDER BY created_at, id) AS ended_at").
+		Where("workspace_id = ?", workspaceID)
+
+	query := r.db.WithContext(ctx).
+		Table("(?) AS e", spans).
+		Order("e.created_at DESC, e.id DESC")
+
+	return pagination.GormPaginate[WorkspaceStatusEventSpan](query, page, limit)
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
+	"clusterix-code/internal/utils/preload"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type WorkspaceTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceTemplateRepository(db *gorm.DB) *WorkspaceTemplateRepository {
+	return &WorkspaceTemplateRepository{db: db}
+}
+
+func (r *WorkspaceTemplateRepository) GetTemplates(ctx context.Context, organizationId uint32, search string, with []string, page, limit int) (pagination.Pagination, error) {
+	query := r.db.WithContext(ctx).
+		Model(&models.WorkspaceTemplate{}).
+		Where("organization_id = ?", organizationId)
+
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	query = preload.ApplyPreloads(query, with)
+
+	return pagination.GormPaginate[models.WorkspaceTemplate](query, page, limit)
+}
+
+func (r *WorkspaceTemplateRepository) GetByID(ctx context.Context, id uint64, with []string) (*models.WorkspaceTemplate, error) {
+	var template models.WorkspaceTemplate
+
+	query := r.db.WithContext(ctx).Model(&models.WorkspaceTemplate{})
+
+	query = preload.ApplyPreloads(query, with)
+
+	if err := query.First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *WorkspaceTemplateRepository) Create(ctx context.Context, template *models.WorkspaceTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+func (r *WorkspaceTemplateRepository) Update(ctx context.Context, template *models.WorkspaceTemplate) error {
+	return r.db.WithContext(ctx).Save(template).Error
+}
+
+func (r *WorkspaceTemplateRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.WorkspaceTemplate{}, id).Error
+}
@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleApplyRetentionTask(ctx context.Context, t *asynq.Task, retentionSvc *services.RetentionService) error {
+	if err := retentionSvc.Run(ctx); err != nil {
+		return fmt.Errorf("workspace retention failed: %w", err)
+	}
+	return nil
+}
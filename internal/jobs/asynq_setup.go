@@ -25,5 +25,14 @@ func NewAsynqServer() *asynq.Server {
 			"default":  6,
 			"critical": 10,
 		},
+		RetryDelayFunc: RetryDelay,
 	})
 }
+
+func NewAsynqScheduler() *asynq.Scheduler {
+	opt := asynq.RedisClientOpt{
+		Addr:     fmt.Sprintf("%s:%s", os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT")),
+		Password: os.Getenv("REDIS_PASSWORD"),
+	}
+	return asynq.NewScheduler(opt, nil)
+}
@@ -1,29 +1,39 @@
 package jobs
 
 import (
+	"clusterix-code/internal/api/requests"
 	"clusterix-code/internal/constants"
 	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/services"
+	"clusterix-code/internal/services/devpod"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/hibiken/asynq"
+	"log"
+	"os"
+	"strconv"
 )
 
-// PublishLogMessage publishes a log message to the message queue
-func PublishLogMessage(
+// PublishLogEvent publishes an action event of a run to the workspace's log
+// channel
+func PublishLogEvent(
 	publisherSvc *services.PublisherService,
 	workspaceID uint64,
-	message string,
+	runID uint64,
+	event devpod.Event,
 ) error {
-	messageTime, messageType, messageText := getClearLog(message)
-
 	body := dto.Message{
 		EventType: constants.WorkspaceLogs,
 		Channel:   fmt.Sprintf("workspace_%d_logs", workspaceID),
 		Data: map[string]interface{}{
 			"workspace_id": workspaceID,
-			"time":         messageTime,
-			"type":         messageType,
-			"text":         messageText,
+			"time":         event.Time.Format("15:04:05"),
+			"type":         event.Level,
+			"text":         event.Message,
+			"run_id":       runID,
 		},
 	}
 
@@ -35,3 +45,279 @@ func PublishLogMessage(
 	publisherSvc.Publish(constants.CLUSTERIX_CODE_V1_EXCHANGE, constants.WORKSPACE_LOG_HANDLER_QUEUE, payload)
 	return nil
 }
+
+// publishEvents returns an event handler that publishes every event of a
+// run to the workspace's log channel.
+func publishEvents(publisherSvc *services.PublisherService, workspaceID uint64, runID uint64) devpod.EventHandler {
+	return func(event devpod.Event) {
+		if err := PublishLogEvent(publisherSvc, workspaceID, runID, event); err != nil {
+			log.Printf("Failed to publish log message: %v", err)
+		}
+	}
+}
+
+// startEventHandler returns the event handler of every action that brings a
+// workspace up (start, restart and rebuild). It records the devpod machine,
+// and once the IDE is up it maps its port, publishes the workspace URL and
+// marks the workspace running. *machineCreated is set when devpod creates a
+// machine, so a cancelled action knows to clean it up.
+func startEventHandler(
+	ctx context.Context,
+	workspaceSvc *services.WorkspaceService,
+	publisherSvc *services.PublisherService,
+	workspaceConfigSvc *services.WorkspaceConfigService,
+	workspaceID uint64,
+	runID uint64,
+	machineCreated *bool,
+) devpod.EventHandler {
+	workspace, _ := workspaceSvc.GetWorkspaceIncludingDeleted(ctx, workspaceID)
+	publish := publishEvents(publisherSvc, workspaceID, runID)
+
+	exposeIDE := func(event devpod.Event) {
+		fmt.Println("Workspace URL:", event.URL)
+
+		mapping, err := services.MapWorkspace(strconv.FormatUint(workspaceID, 10), event.Port)
+		if err != nil {
+			log.Printf("Failed to map workspace port: %v\n", err)
+			return
+		}
+
+		if err := workspaceSvc.UpdateWorkspaceStatus(ctx, workspaceID, enums.WorkspaceStatusRunning, "Workspace is running by worker"); err != nil {
+			log.Printf("Failed to update workspace status: %v", err)
+		}
+
+		worker_base_url := os.Getenv("REVERSE_PROXY_BASE_URL")
+		publicURL := fmt.Sprintf("%s.%s/?folder=/workspaces/%d", workspace.Fingerprint, worker_base_url, workspace.ID)
+
+		if err := workspaceSvc.UpdateWorkspaceURL(ctx, workspaceID, publicURL); err != nil {
+			log.Printf("Failed to update workspace URL: %v\n", err)
+		}
+
+		var workspaceConfigRequest requests.UpdateWorkspaceConfigRequest
+		workspaceConfigRequest.ID = workspace.WorkspaceConfig.ID
+		workspaceConfigRequest.WorkerPort = mapping.ExternalPort
+		_, err = workspaceConfigSvc.UpdateWorkspaceConfig(ctx, workspaceConfigRequest)
+		if err != nil {
+			log.Printf("Failed to set worker port: %v\n", err)
+		}
+	}
+
+	return func(event devpod.Event) {
+		switch event.Type {
+		case devpod.EventMachineCreated:
+			*machineCreated = true
+			fmt.Println("Machine Name:", event.MachineName)
+			var workspaceConfigRequest requests.UpdateWorkspaceConfigRequest
+			workspaceConfigRequest.ID = workspace.WorkspaceConfig.ID
+			workspaceConfigRequest.DevpodMachine = event.MachineName
+			_, err := workspaceConfigSvc.UpdateWorkspaceConfig(ctx, workspaceConfigRequest)
+			if err != nil {
+				log.Printf("Failed to set machine name: %v\n", err)
+			}
+		case devpod.EventIDEURL:
+			exposeIDE(event)
+		}
+
+		publish(event)
+	}
+}
+
+// markStarted marks a workspace running once devpod up has succeeded.
+// Browser IDEs are marked running when their URL is exposed; SSH-only
+// workspaces print no URL, so devpod finishing is the only sign they are
+// up.
+func markStarted(ctx context.Context, workspaceSvc *services.WorkspaceService, workspaceID uint64) {
+	workspace, err := workspaceSvc.GetWorkspaceIncludingDeleted(ctx, workspaceID)
+	if err != nil {
+		log.Printf("Failed to get workspace: %v", err)
+		return
+	}
+	if enums.IDE(workspace.IDE).Kind() != enums.IDEKindSSH {
+		return
+	}
+	if workspace.Status == string(enums.WorkspaceStatusRunning) {
+		return
+	}
+
+	if err := workspaceSvc.UpdateWorkspaceStatus(ctx, workspaceID, enums.WorkspaceStatusRunning, "Workspace is running by worker"); err != nil {
+		log.Printf("Failed to update workspace status: %v", err)
+	}
+}
+
+// isCancelled reports whether the task was cancelled through the cancel
+// endpoint rather than having failed or timed out. A context cancelled
+// without the endpoint marking the action, e.g. by a worker shutting down,
+// is an interruption the task is retried after.
+func isCancelled(ctx context.Context, workspaceSvc *services.WorkspaceService, actionID uint64) bool {
+	if !errors.Is(ctx.Err(), context.Canceled) || actionID == 0 {
+		return false
+	}
+
+	requested, err := workspaceSvc.IsWorkspaceActionCancelRequested(context.WithoutCancel(ctx), actionID)
+	if err != nil {
+		log.Printf("Failed to load workspace action %d: %v", actionID, err)
+		return false
+	}
+	return requested
+}
+
+// isInterrupted reports whether the task's context was cancelled without
+// the task being cancelled.
+func isInterrupted(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// handleCancelledAction settles a workspace whose action task was cancelled.
+// The task context is already done, so cleanup runs on a detached context.
+// A machine created by the cancelled run is deleted rather than left behind
+// half-provisioned.
+func handleCancelledAction(
+	ctx context.Context,
+	workspaceSvc *services.WorkspaceService,
+	publisherSvc *services.PublisherService,
+	workspaceID uint64,
+	runID uint64,
+	userID uint64,
+	action constants.WorkspaceAction,
+	machineCreated bool,
+) error {
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	if machineCreated {
+		publish := publishEvents(publisherSvc, workspaceID, runID)
+		if err := workspaceSvc.RunWorkspaceAction(cleanupCtx, workspaceID, userID, publish, constants.ActionTerminate); err != nil {
+			log.Printf("Failed to clean up machine of cancelled workspace %d: %v", workspaceID, err)
+		}
+	}
+
+	message := fmt.Sprintf("Workspace %s was cancelled", action)
+	if err := workspaceSvc.UpdateWorkspaceStatus(cleanupCtx, workspaceID, enums.WorkspaceStatusCancelled, message); err != nil {
+		log.Printf("Failed to update workspace status: %v", err)
+	}
+
+	return fmt.Errorf("workspace %s cancelled: %w", action, asynq.SkipRetry)
+}
+
+// handleActionFailure decides whether a failed action is retried. An
+// interrupted action always goes back to asynq. While the failure class has
+// retries left the error goes back to asynq, which backs off through
+// RetryDelay. After that the workspace is failed with the classified code
+// and reason, and the task is not retried again.
+func handleActionFailure(
+	ctx context.Context,
+	workspaceSvc *services.WorkspaceService,
+	publisherSvc *services.PublisherService,
+	workspaceID uint64,
+	runID uint64,
+	action constants.WorkspaceAction,
+	err error,
+) error {
+	if isInterrupted(ctx) {
+		return fmt.Errorf("workspace %s interrupted: %w", action, err)
+	}
+
+	var actionErr *devpod.ActionError
+	if !errors.As(err, &actionErr) {
+		actionErr = &devpod.ActionError{Code: devpod.FailureUnknown, Reason: err.Error(), Err: err}
+	}
+
+	publish := publishEvents(publisherSvc, workspaceID, runID)
+	policy := retryPolicyFor(actionErr.Code)
+	retried, _ := asynq.GetRetryCount(ctx)
+	if retried < policy.MaxRetries {
+		publish(devpod.NewEvent("warn", fmt.Sprintf("Workspace %s failed with %s, retrying in %s: %s",
+			action, actionErr.Code, policy.Delay(retried), actionErr.Reason)))
+		return fmt.Errorf("workspace %s failed: %w", action, err)
+	}
+
+	publish(devpod.NewEvent("error", fmt.Sprintf("Workspace %s failed with %s: %s", action, actionErr.Code, actionErr.Reason)))
+
+	if err := workspaceSvc.FailWorkspace(context.WithoutCancel(ctx), workspaceID, string(actionErr.Code), actionErr.Reason); err != nil {
+		log.Printf("Failed to update workspace status: %v", err)
+	}
+	return fmt.Errorf("workspace %s failed: %w: %w", action, err, asynq.SkipRetry)
+}
+
+// startRun records the run of the task's action and returns its ID. A run
+// that can't be recorded doesn't hold back the action; its logs are then
+// left untagged.
+func startRun(ctx context.Context, workspaceSvc *services.WorkspaceService, workspaceID, actionID uint64, action constants.WorkspaceAction) uint64 {
+	taskID, _ := asynq.GetTaskID(ctx)
+	retried, _ := asynq.GetRetryCount(ctx)
+
+	runID, err := workspaceSvc.StartWorkspaceRun(ctx, workspaceID, actionID, action, taskID, retried+1)
+	if err != nil {
+		log.Printf("Failed to record run of workspace %d: %v", workspaceID, err)
+		return 0
+	}
+	return runID
+}
+
+// finishRun records the outcome of the task's run. Unlike the action, every
+// attempt has its own run, so a run is failed even when the task is retried.
+func finishRun(ctx context.Context, workspaceSvc *services.WorkspaceService, runID, actionID uint64, err error) {
+	if runID == 0 {
+		return
+	}
+
+	status := enums.WorkspaceRunStatusSucceeded
+	var code, reason string
+	switch {
+	case err == nil:
+	case isCancelled(ctx, workspaceSvc, actionID):
+		status = enums.WorkspaceRunStatusCancelled
+	default:
+		status = enums.WorkspaceRunStatusFailed
+		reason = err.Error()
+		var actionErr *devpod.ActionError
+		if errors.As(err, &actionErr) {
+			code, reason = string(actionErr.Code), actionErr.Reason
+		}
+	}
+
+	if err := workspaceSvc.FinishWorkspaceRun(context.WithoutCancel(ctx), runID, status, code, reason); err != nil {
+		log.Printf("Failed to finish workspace run %d: %v", runID, err)
+	}
+}
+
+// finishAction records the outcome of the task's workspace action once asynq
+// won't run the task again, which lets the next queued action of the
+// workspace through. A task that will be retried, or was interrupted, keeps
+// its action running.
+func finishAction(ctx context.Context, workspaceSvc *services.WorkspaceService, actionID uint64, err error) {
+	if actionID == 0 {
+		return
+	}
+
+	status := enums.WorkspaceActionStatusCompleted
+	message := ""
+	switch {
+	case err == nil:
+	case isCancelled(ctx, workspaceSvc, actionID):
+		status = enums.WorkspaceActionStatusCancelled
+		message = err.Error()
+	case isInterrupted(ctx):
+		return
+	case errors.Is(err, asynq.SkipRetry) || isLastAttempt(ctx):
+		status = enums.WorkspaceActionStatusFailed
+		message = err.Error()
+	default:
+		return
+	}
+
+	if err := workspaceSvc.FinishWorkspaceAction(context.WithoutCancel(ctx), actionID, status, message); err != nil {
+		log.Printf("Failed to finish workspace action %d: %v", actionID, err)
+	}
+}
+
+func isLastAttempt(ctx context.Context) bool {
+	retried, ok := asynq.GetRetryCount(ctx)
+	if !ok {
+		return true
+	}
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	if !ok {
+		return true
+	}
+	return retried >= maxRetry
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/tasks"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleDeliverWebhookTask(ctx context.Context, t *asynq.Task, webhookSvc *services.WebhookService) error {
+	var p tasks.DeliverWebhookPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return webhookSvc.Deliver(ctx, p.DeliveryID, isLastAttempt(ctx))
+}
@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleEvaluateBudgetsTask(ctx context.Context, t *asynq.Task, budgetEvaluatorSvc *services.BudgetEvaluatorService) error {
+	if err := budgetEvaluatorSvc.Evaluate(ctx); err != nil {
+		return fmt.Errorf("budget evaluation failed: %w", err)
+	}
+	return nil
+}
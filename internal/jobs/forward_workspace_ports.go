@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/tasks"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleForwardWorkspacePortsTask(ctx context.Context, t *asynq.Task, workspacePortSvc *services.WorkspacePortService) error {
+	var p tasks.ForwardWorkspacePortsPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return workspacePortSvc.SyncForwards(ctx, p.WorkspaceID, p.ReadDevcontainer)
+}
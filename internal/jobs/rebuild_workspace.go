@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hibiken/asynq"
-	"log"
 )
 
 func NewRebuildWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
@@ -22,46 +21,43 @@ func NewRebuildWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
 	return asynq.NewTask(tasks.TaskRebuildWorkspace, payload), nil
 }
 
-func HandleRebuildWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService, publisherSvc *services.PublisherService) error {
+func HandleRebuildWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService,
+	publisherSvc *services.PublisherService, workspaceConfigSvc *services.WorkspaceConfigService) (err error) {
 	var p tasks.RebuildWorkspacePayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("invalid payload: %w", err)
 	}
+	runID := startRun(ctx, workspaceSvc, p.WorkspaceID, p.ActionID, constants.ActionRebuild)
+	defer func() {
+		finishRun(ctx, workspaceSvc, runID, p.ActionID, err)
+		finishAction(ctx, workspaceSvc, p.ActionID, err)
+	}()
 
 	if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusCreating, "Workspace creation started by worker"); err != nil {
-		return fmt.Errorf("failed to update workspace status: %w", err)
-	}
-
-	successCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
+		if services.IsInvalidStatusTransition(err) {
+			return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
 		}
-
-		return nil
+		return fmt.Errorf("failed to update workspace status: %w", err)
 	}
 
-	failureCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		return nil
-	}
+	// machineCreated tells a cancelled rebuild whether it left a new machine
+	// behind.
+	machineCreated := false
+	onEvent := startEventHandler(ctx, workspaceSvc, publisherSvc, workspaceConfigSvc, p.WorkspaceID, runID, &machineCreated)
 
 	if err := workspaceSvc.RunWorkspaceAction(
 		ctx,
 		p.WorkspaceID,
 		p.UserID,
-		successCallback,
-		failureCallback,
+		onEvent,
 		constants.ActionRebuild,
 	); err != nil {
-		return fmt.Errorf("workspace creation failed: %w", err)
+		if isCancelled(ctx, workspaceSvc, p.ActionID) {
+			return handleCancelledAction(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, p.UserID, constants.ActionRebuild, machineCreated)
+		}
+		return handleActionFailure(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, constants.ActionRebuild, err)
 	}
 
+	markStarted(ctx, workspaceSvc, p.WorkspaceID)
 	return nil
 }
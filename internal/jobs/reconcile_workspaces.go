@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleReconcileWorkspacesTask(ctx context.Context, t *asynq.Task, reconcilerSvc *services.ReconcilerService) error {
+	if err := reconcilerSvc.Reconcile(ctx); err != nil {
+		return fmt.Errorf("workspace reconciliation failed: %w", err)
+	}
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hibiken/asynq"
-	"log"
 )
 
 func NewRestartWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
@@ -22,46 +21,43 @@ func NewRestartWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
 	return asynq.NewTask(tasks.TaskRestartWorkspace, payload), nil
 }
 
-func HandleRestartWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService, publisherSvc *services.PublisherService) error {
+func HandleRestartWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService,
+	publisherSvc *services.PublisherService, workspaceConfigSvc *services.WorkspaceConfigService) (err error) {
 	var p tasks.RestartWorkspacePayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("invalid payload: %w", err)
 	}
+	runID := startRun(ctx, workspaceSvc, p.WorkspaceID, p.ActionID, constants.ActionRestart)
+	defer func() {
+		finishRun(ctx, workspaceSvc, runID, p.ActionID, err)
+		finishAction(ctx, workspaceSvc, p.ActionID, err)
+	}()
 
 	if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusCreating, "Workspace creation started by worker"); err != nil {
-		return fmt.Errorf("failed to update workspace status: %w", err)
-	}
-
-	successCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
+		if services.IsInvalidStatusTransition(err) {
+			return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
 		}
-
-		return nil
+		return fmt.Errorf("failed to update workspace status: %w", err)
 	}
 
-	failureCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		return nil
-	}
+	// machineCreated tells a cancelled restart whether it left a machine
+	// behind.
+	machineCreated := false
+	onEvent := startEventHandler(ctx, workspaceSvc, publisherSvc, workspaceConfigSvc, p.WorkspaceID, runID, &machineCreated)
 
 	if err := workspaceSvc.RunWorkspaceAction(
 		ctx,
 		p.WorkspaceID,
 		p.UserID,
-		successCallback,
-		failureCallback,
+		onEvent,
 		constants.ActionRestart,
 	); err != nil {
-		return fmt.Errorf("workspace creation failed: %w", err)
+		if isCancelled(ctx, workspaceSvc, p.ActionID) {
+			return handleCancelledAction(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, p.UserID, constants.ActionRestart, machineCreated)
+		}
+		return handleActionFailure(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, constants.ActionRestart, err)
 	}
 
+	markStarted(ctx, workspaceSvc, p.WorkspaceID)
 	return nil
 }
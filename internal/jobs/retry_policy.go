@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services/devpod"
+	"clusterix-code/internal/tasks"
+	"errors"
+	"github.com/hibiken/asynq"
+	"time"
+)
+
+// RetryPolicy bounds how often, and how soon, a workspace action that failed
+// with a given failure class is retried.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// retryPolicies retries the failures that can clear up on their own and
+// fails fast on the ones that need the user to change something.
+var retryPolicies = map[devpod.FailureCode]RetryPolicy{
+	devpod.FailureCloudQuota:   {MaxRetries: 3, BaseDelay: 5 * time.Minute, MaxDelay: 30 * time.Minute},
+	devpod.FailureAuth:         {MaxRetries: 0},
+	devpod.FailureGitClone:     {MaxRetries: 1, BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Second},
+	devpod.FailureDevcontainer: {MaxRetries: 0},
+	devpod.FailureNetwork:      {MaxRetries: 5, BaseDelay: 10 * time.Second, MaxDelay: 5 * time.Minute},
+	devpod.FailureTimeout:      {MaxRetries: 2, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute},
+	devpod.FailureUnknown:      {MaxRetries: 2, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute},
+}
+
+// webhookRetryPolicy backs off failed webhook deliveries, giving endpoints
+// that are down a few hours to come back. The number of retries is set per
+// delivery.
+var webhookRetryPolicy = RetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: time.Hour}
+
+func retryPolicyFor(code devpod.FailureCode) RetryPolicy {
+	if policy, ok := retryPolicies[code]; ok {
+		return policy
+	}
+	return retryPolicies[devpod.FailureUnknown]
+}
+
+// Delay is the exponential backoff before retry n, counted from zero.
+func (p RetryPolicy) Delay(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < n && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// RetryDelay is the worker's asynq RetryDelayFunc. Classified devpod failures
+// back off according to their class and webhook deliveries according to
+// webhookRetryPolicy; anything else uses asynq's default.
+func RetryDelay(n int, err error, t *asynq.Task) time.Duration {
+	if t.Type() == tasks.TaskDeliverWebhook {
+		return webhookRetryPolicy.Delay(n)
+	}
+	var actionErr *devpod.ActionError
+	if errors.As(err, &actionErr) {
+		return retryPolicyFor(actionErr.Code).Delay(n)
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, t)
+}
@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/tasks"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleRunBulkOperationTask(ctx context.Context, t *asynq.Task, adminSvc *services.WorkspaceAdminService) error {
+	var p tasks.RunBulkOperationPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if err := adminSvc.RunBulkOperation(ctx, p.OperationID); err != nil {
+		return fmt.Errorf("bulk operation %d failed: %w", p.OperationID, err)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleRunWorkspaceSchedulesTask(ctx context.Context, t *asynq.Task, workspaceScheduleSvc *services.WorkspaceScheduleService) error {
+	if err := workspaceScheduleSvc.RunSchedules(ctx); err != nil {
+		return fmt.Errorf("workspace schedules failed: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package jobs
 
 import (
-	"clusterix-code/internal/api/requests"
 	"clusterix-code/internal/constants"
 	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/services"
@@ -10,9 +9,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hibiken/asynq"
-	"log"
-	"os"
-	"strconv"
 )
 
 func NewStartWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
@@ -26,94 +22,41 @@ func NewStartWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
 }
 
 func HandleStartWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService,
-	publisherSvc *services.PublisherService, workspaceConfigSvc *services.WorkspaceConfigService) error {
+	publisherSvc *services.PublisherService, workspaceConfigSvc *services.WorkspaceConfigService) (err error) {
 	var p tasks.StartWorkspacePayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("invalid payload: %w", err)
 	}
+	runID := startRun(ctx, workspaceSvc, p.WorkspaceID, p.ActionID, constants.ActionStart)
+	defer func() {
+		finishRun(ctx, workspaceSvc, runID, p.ActionID, err)
+		finishAction(ctx, workspaceSvc, p.ActionID, err)
+	}()
 
 	if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusStarting, "Workspace is starting by worker"); err != nil {
-		return fmt.Errorf("failed to update workspace status: %w", err)
-	}
-
-	workspace, _ := workspaceSvc.GetWorkspace(ctx, p.WorkspaceID)
-
-	successCallback := func(message string, logType string) error {
-
-		if machineName, found := devpodParser.ExtractMachineName(message); found {
-			fmt.Println("Machine Name:", machineName)
-			var workspaceConfigRequest requests.UpdateWorkspaceConfigRequest
-			workspaceConfigRequest.ID = workspace.WorkspaceConfig.ID
-			workspaceConfigRequest.DevpodMachine = machineName
-			_, err := workspaceConfigSvc.UpdateWorkspaceConfig(ctx, workspaceConfigRequest)
-			if err != nil {
-				log.Printf("Failed to set machine name: %v\n", err)
-			}
+		if services.IsInvalidStatusTransition(err) {
+			return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
 		}
-
-
-		if rawURL, found := devpodParser.ExtractURL(message); found {
-			fmt.Println("Workspace URL:", rawURL)
-
-			internalPort := devpodParser.ExtractPortFromURL(rawURL)
-
-			mapping, err := services.MapWorkspace(strconv.FormatUint(p.WorkspaceID, 10), internalPort)
-			if err != nil {
-				log.Printf("Failed to map workspace port: %v\n", err)
-				return nil
-			}
-
-			if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusRunning, "Workspace is running by worker"); err != nil {
-				fmt.Errorf("failed to update workspace status: %w", err)
-			}
-
-			worker_base_url := os.Getenv("REVERSE_PROXY_BASE_URL")
-			publicURL := fmt.Sprintf("%s.%s/?folder=/workspaces/%d", workspace.Fingerprint, worker_base_url, workspace.ID)
-
-			if err := workspaceSvc.UpdateWorkspaceURL(ctx, p.WorkspaceID, publicURL); err != nil {
-				log.Printf("Failed to update workspace URL: %v\n", err)
-			}
-
-			var workspaceConfigRequest requests.UpdateWorkspaceConfigRequest
-			workspaceConfigRequest.ID = workspace.WorkspaceConfig.ID
-			workspaceConfigRequest.WorkerPort = mapping.ExternalPort
-			_, err = workspaceConfigSvc.UpdateWorkspaceConfig(ctx, workspaceConfigRequest)
-			if err != nil {
-				log.Printf("Failed to set worker port: %v\n", err)
-			}
-		}
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		return nil
+		return fmt.Errorf("failed to update workspace status: %w", err)
 	}
 
-	failureCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusFailed, "Workspace is failed by worker"); err != nil {
-			fmt.Errorf("failed to update workspace status: %w", err)
-		}
-
-		return nil
-	}
+	// machineCreated tells a cancelled start whether it left a machine behind.
+	machineCreated := false
+	onEvent := startEventHandler(ctx, workspaceSvc, publisherSvc, workspaceConfigSvc, p.WorkspaceID, runID, &machineCreated)
 
 	if err := workspaceSvc.RunWorkspaceAction(
 		ctx,
 		p.WorkspaceID,
 		p.UserID,
-		successCallback,
-		failureCallback,
+		onEvent,
 		constants.ActionStart,
 	); err != nil {
-		return fmt.Errorf("workspace creation failed: %w", err)
+		if isCancelled(ctx, workspaceSvc, p.ActionID) {
+			return handleCancelledAction(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, p.UserID, constants.ActionStart, machineCreated)
+		}
+		return handleActionFailure(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, constants.ActionStart, err)
 	}
 
+	markStarted(ctx, workspaceSvc, p.WorkspaceID)
 	return nil
 }
@@ -0,0 +1,271 @@
+package jobs
+
+import (
+	"clusterix-code/internal/config"
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/db"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/services"
+	"clusterix-code/internal/services/devpod"
+	"clusterix-code/internal/tasks"
+	"clusterix-code/internal/utils/rabbitmq"
+	"clusterix-code/internal/websocket"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// The tests below run a start task through the worker's handler and
+// WorkspaceService.RunWorkspaceAction, with the fake backend standing in
+// for devpod. They need a database and RabbitMQ, e.g. the docker-compose
+// services, and are skipped unless TEST_DATABASE_URL and TEST_RABBITMQ_URL
+// point at them. The database is migrated up; rows are left behind.
+
+func TestHandleStartWorkspaceTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		failActions []string
+		wantErr     bool
+		wantEvents  []enums.WorkspaceStatus
+		wantCode    string
+		wantRun     enums.WorkspaceRunStatus
+		wantAction  enums.WorkspaceActionStatus
+	}{
+		{
+			name:       "started",
+			wantEvents: []enums.WorkspaceStatus{enums.WorkspaceStatusStarting, enums.WorkspaceStatusRunning},
+			wantRun:    enums.WorkspaceRunStatusSucceeded,
+			wantAction: enums.WorkspaceActionStatusCompleted,
+		},
+		{
+			name:        "auth failed",
+			failActions: []string{"start:" + string(devpod.FailureAuth)},
+			wantErr:     true,
+			wantEvents:  []enums.WorkspaceStatus{enums.WorkspaceStatusStarting, enums.WorkspaceStatusFailed},
+			wantCode:    string(devpod.FailureAuth),
+			wantRun:     enums.WorkspaceRunStatusFailed,
+			wantAction:  enums.WorkspaceActionStatusFailed,
+		},
+		{
+			name:        "devcontainer build failed",
+			failActions: []string{"start:" + string(devpod.FailureDevcontainer)},
+			wantErr:     true,
+			wantEvents:  []enums.WorkspaceStatus{enums.WorkspaceStatusStarting, enums.WorkspaceStatusFailed},
+			wantCode:    string(devpod.FailureDevcontainer),
+			wantRun:     enums.WorkspaceRunStatusFailed,
+			wantAction:  enums.WorkspaceActionStatusFailed,
+		},
+	}
+
+	database := newTestDatabase(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svcs := newTestServices(t, database, tt.failActions)
+			workspace := createTestWorkspace(t, database)
+
+			action := models.WorkspaceAction{
+				WorkspaceID: workspace.ID,
+				UserID:      workspace.UserID,
+				Action:      constants.ActionStart,
+				Status:      enums.WorkspaceActionStatusRunning,
+				Actor:       enums.WorkspaceActorUser,
+			}
+			if err := database.Create(&action).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			task, err := tasks.NewStartWorkspaceTask(workspace.ID, workspace.UserID, action.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = HandleStartWorkspaceTask(ctx, task, svcs.Workspace, svcs.Publisher, svcs.WorkspaceConfig)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HandleStartWorkspaceTask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, asynq.SkipRetry) {
+				t.Errorf("HandleStartWorkspaceTask() error = %v, want it not retried", err)
+			}
+
+			var events []models.WorkspaceStatusEvent
+			if err := database.Where("workspace_id = ?", workspace.ID).Order("id").Find(&events).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != len(tt.wantEvents) {
+				t.Fatalf("got %d status events, want %v", len(events), tt.wantEvents)
+			}
+			for i, event := range events {
+				if event.Status != tt.wantEvents[i] {
+					t.Errorf("event %d status = %s, want %s", i, event.Status, tt.wantEvents[i])
+				}
+				if event.RunID == nil {
+					t.Errorf("event %d has no run", i)
+				}
+			}
+			last := events[len(events)-1]
+			if last.ErrorCode != tt.wantCode {
+				t.Errorf("error code = %q, want %q", last.ErrorCode, tt.wantCode)
+			}
+			if tt.wantCode != "" && last.ErrorReason != "fake backend configured to fail start" {
+				t.Errorf("error reason = %q", last.ErrorReason)
+			}
+
+			var stored models.Workspace
+			if err := database.Preload("WorkspaceConfig").First(&stored, workspace.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.wantEvents[len(tt.wantEvents)-1]; stored.Status != want {
+				t.Errorf("workspace status = %s, want %s", stored.Status, want)
+			}
+			wantMachine := fmt.Sprintf("fake-%d", workspace.ID)
+			if machine := stored.WorkspaceConfig.DevpodMachine; machine == nil || *machine != wantMachine {
+				t.Errorf("devpod machine = %v, want %s", machine, wantMachine)
+			}
+
+			var run models.WorkspaceRun
+			if err := database.Where("workspace_id = ?", workspace.ID).First(&run).Error; err != nil {
+				t.Fatal(err)
+			}
+			if run.Status != tt.wantRun || run.ErrorCode != tt.wantCode {
+				t.Errorf("run = %s %q, want %s %q", run.Status, run.ErrorCode, tt.wantRun, tt.wantCode)
+			}
+			if run.ActionID == nil || *run.ActionID != action.ID {
+				t.Errorf("run action = %v, want %d", run.ActionID, action.ID)
+			}
+
+			if err := database.First(&action, action.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if action.Status != tt.wantAction {
+				t.Errorf("action status = %s, want %s", action.Status, tt.wantAction)
+			}
+		})
+	}
+}
+
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" || os.Getenv("TEST_RABBITMQ_URL") == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_RABBITMQ_URL are not set")
+	}
+
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Warn)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RunMigrateUp(database); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+// newTestServices wires the services as the worker does, on the fake
+// backend failing failActions.
+func newTestServices(t *testing.T, database *gorm.DB, failActions []string) *services.Services {
+	t.Helper()
+
+	conn, err := amqp.Dial(os.Getenv("TEST_RABBITMQ_URL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// Workspace logs live in MongoDB, which the start task doesn't use. The
+	// client only connects once used, so no server is needed.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:27017"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mongoClient.Disconnect(context.Background()) })
+
+	// Nothing listens on the Redis port either: the tasks a start enqueues,
+	// such as forwarding the workspace's ports, fail fast and are logged.
+	return services.NewServices(&services.ServiceConfig{
+		Repositories: repositories.NewRepositories(database, mongoClient.Database("test")),
+		RabbitMQ:     &rabbitmq.RabbitMQ{Conn: conn},
+		Hub:          websocket.NewHub(),
+		Redis:        config.RedisConfig{Host: "127.0.0.1", Port: 1},
+		Backend: config.WorkspaceBackendConfig{
+			Driver:          devpod.BackendFake,
+			FakeFailActions: failActions,
+		},
+	})
+}
+
+// createTestWorkspace creates a stopped SSH-only workspace, with the rows it
+// depends on, in an organization of its own.
+func createTestWorkspace(t *testing.T, database *gorm.DB) models.Workspace {
+	t.Helper()
+
+	suffix := time.Now().UnixNano()
+	organizationID := uint32(suffix % 1_000_000_000)
+
+	user := models.User{FullName: "Test User", OrganizationID: uint64(organizationID), IsActive: true}
+	mustCreate(t, database, &user)
+
+	machineConfig := models.MachineConfig{
+		InstanceType: fmt.Sprintf("test.%d", suffix),
+		Category:     "test",
+		CPUCores:     2,
+		MemoryGB:     4,
+	}
+	mustCreate(t, database, &machineConfig)
+
+	repository := models.Repository{
+		Title:           "app",
+		MachineConfigID: machineConfig.ID,
+		RepositoryURL:   "https://example.com/org/app.git",
+		CreatedByID:     user.ID,
+		OrganizationID:  organizationID,
+	}
+	mustCreate(t, database, &repository)
+
+	token := models.GitPersonalAccessToken{Title: "test", Token: "token", UserID: user.ID}
+	mustCreate(t, database, &token)
+
+	workspace := models.Workspace{
+		Title:                    "test",
+		Color:                    "blue",
+		Ide:                      string(enums.IDENone),
+		RepositoryID:             repository.ID,
+		UserID:                   user.ID,
+		Fingerprint:              fmt.Sprintf("test-%d", suffix),
+		OrganizationID:           organizationID,
+		GitPersonalAccessTokenID: token.ID,
+		Status:                   enums.WorkspaceStatusStopped,
+	}
+	mustCreate(t, database, &workspace)
+
+	workspaceConfig := models.WorkspaceConfig{WorkspaceID: &workspace.ID}
+	mustCreate(t, database, &workspaceConfig)
+	workspace.WorkspaceConfigID = &workspaceConfig.ID
+	if err := database.Model(&workspace).Update("workspace_config_id", workspaceConfig.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return workspace
+}
+
+func mustCreate(t *testing.T, database *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := database.Omit(clause.Associations).Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
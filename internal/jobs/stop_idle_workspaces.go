@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"clusterix-code/internal/services"
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+func HandleStopIdleWorkspacesTask(ctx context.Context, t *asynq.Task, idleSvc *services.IdleService) error {
+	if err := idleSvc.StopIdleWorkspaces(ctx); err != nil {
+		return fmt.Errorf("idle workspace check failed: %w", err)
+	}
+	return nil
+}
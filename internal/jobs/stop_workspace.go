@@ -4,6 +4,7 @@ import (
 	"clusterix-code/internal/constants"
 	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/services"
+	"clusterix-code/internal/services/devpod"
 	"clusterix-code/internal/tasks"
 	"context"
 	"encoding/json"
@@ -23,55 +24,46 @@ func NewStopWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
 	return asynq.NewTask(tasks.TaskStopWorkspace, payload), nil
 }
 
-func HandleStopWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService, publisherSvc *services.PublisherService) error {
+func HandleStopWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService, publisherSvc *services.PublisherService) (err error) {
 	var p tasks.StopWorkspacePayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("invalid payload: %w", err)
 	}
+	runID := startRun(ctx, workspaceSvc, p.WorkspaceID, p.ActionID, constants.ActionStop)
+	defer func() {
+		finishRun(ctx, workspaceSvc, runID, p.ActionID, err)
+		finishAction(ctx, workspaceSvc, p.ActionID, err)
+	}()
 
 	if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusStopping, "Workspace stopping started by worker"); err != nil {
+		if services.IsInvalidStatusTransition(err) {
+			return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+		}
 		return fmt.Errorf("failed to update workspace status: %w", err)
 	}
 
-	successCallback := func(message string, logType string) error {
-		if devpodParser.IsSuccessStop(message) {
+	publish := publishEvents(publisherSvc, p.WorkspaceID, runID)
+	onEvent := func(event devpod.Event) {
+		if event.Type == devpod.EventPhase && event.Phase == devpod.PhaseStopped {
 			if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusStopped, "Workspace is stopped by worker"); err != nil {
-				fmt.Errorf("failed to update workspace status: %w", err)
+				log.Printf("Failed to update workspace status: %v", err)
 			}
 		}
 
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		return nil
-	}
-
-	failureCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusFailed, "Workspace is failed by worker"); err != nil {
-			fmt.Errorf("failed to update workspace status: %w", err)
-		}
-
-		return nil
+		publish(event)
 	}
 
 	if err := workspaceSvc.RunWorkspaceAction(
 		ctx,
 		p.WorkspaceID,
 		p.UserID,
-		successCallback,
-		failureCallback,
+		onEvent,
 		constants.ActionStop,
 	); err != nil {
-		return fmt.Errorf("workspace creation failed: %w", err)
+		if isCancelled(ctx, workspaceSvc, p.ActionID) {
+			return handleCancelledAction(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, p.UserID, constants.ActionStop, false)
+		}
+		return handleActionFailure(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, constants.ActionStop, err)
 	}
 
 	return nil
@@ -4,6 +4,7 @@ import (
 	"clusterix-code/internal/constants"
 	"clusterix-code/internal/data/enums"
 	"clusterix-code/internal/services"
+	"clusterix-code/internal/services/devpod"
 	"clusterix-code/internal/tasks"
 	"context"
 	"encoding/json"
@@ -23,55 +24,46 @@ func NewTerminateWorkspaceTask(workspaceID uint64) (*asynq.Task, error) {
 	return asynq.NewTask(tasks.TaskTerminateWorkspace, payload), nil
 }
 
-func HandleTerminateWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService, publisherSvc *services.PublisherService) error {
+func HandleTerminateWorkspaceTask(ctx context.Context, t *asynq.Task, workspaceSvc *services.WorkspaceService, publisherSvc *services.PublisherService) (err error) {
 	var p tasks.TerminateWorkspacePayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("invalid payload: %w", err)
 	}
+	runID := startRun(ctx, workspaceSvc, p.WorkspaceID, p.ActionID, constants.ActionTerminate)
+	defer func() {
+		finishRun(ctx, workspaceSvc, runID, p.ActionID, err)
+		finishAction(ctx, workspaceSvc, p.ActionID, err)
+	}()
 
 	if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusTerminating, "Workspace terminating started by worker"); err != nil {
+		if services.IsInvalidStatusTransition(err) {
+			return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+		}
 		return fmt.Errorf("failed to update workspace status: %w", err)
 	}
 
-	successCallback := func(message string, logType string) error {
-		if devpodParser.IsSuccessDelete(message) {
+	publish := publishEvents(publisherSvc, p.WorkspaceID, runID)
+	onEvent := func(event devpod.Event) {
+		if event.Type == devpod.EventPhase && event.Phase == devpod.PhaseDeleted {
 			if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusTerminated, "Workspace is terminated by worker"); err != nil {
-				fmt.Errorf("failed to update workspace status: %w", err)
+				log.Printf("Failed to update workspace status: %v", err)
 			}
 		}
 
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		return nil
-	}
-
-	failureCallback := func(message string, logType string) error {
-		fmt.Println(message)
-
-		if err := PublishLogMessage(publisherSvc, p.WorkspaceID, message); err != nil {
-			log.Printf("Failed to publish log message: %v", err)
-		}
-
-		if err := workspaceSvc.UpdateWorkspaceStatus(ctx, p.WorkspaceID, enums.WorkspaceStatusFailed, "Workspace is failed by worker"); err != nil {
-			fmt.Errorf("failed to update workspace status: %w", err)
-		}
-
-		return nil
+		publish(event)
 	}
 
 	if err := workspaceSvc.RunWorkspaceAction(
 		ctx,
 		p.WorkspaceID,
 		p.UserID,
-		successCallback,
-		failureCallback,
+		onEvent,
 		constants.ActionTerminate,
 	); err != nil {
-		return fmt.Errorf("workspace terminating failed: %w", err)
+		if isCancelled(ctx, workspaceSvc, p.ActionID) {
+			return handleCancelledAction(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, p.UserID, constants.ActionTerminate, false)
+		}
+		return handleActionFailure(ctx, workspaceSvc, publisherSvc, p.WorkspaceID, runID, constants.ActionTerminate, err)
 	}
 	
 	return nil
@@ -22,8 +22,24 @@ type Services struct {
 	Workspace              *WorkspaceService
 	WorkspaceConfig        *WorkspaceConfigService
 	WorkspaceLog           *WorkspaceLogService
+	EnvVar                 *EnvVarService
+	UserPreference         *UserPreferenceService
+	WorkspaceShare         *WorkspaceShareService
+	WorkspaceTemplate      *WorkspaceTemplateService
+	Quota                  *QuotaService
+	Usage                  *UsageService
+	Budget                 *BudgetService
+	BudgetEvaluator        *BudgetEvaluatorService
+	OrganizationSetting    *OrganizationSettingService
+	Idle                   *IdleService
+	WorkspaceSchedule      *WorkspaceScheduleService
+	Retention              *RetentionService
+	WorkspaceAdmin         *WorkspaceAdminService
+	Webhook                *WebhookService
+	WorkspacePort          *WorkspacePortService
 	Socket                 *SocketService
-	Devpod                 *devpod.DevpodService
+	Backend                devpod.WorkspaceBackend
+	Reconciler             *ReconcilerService
 }
 
 type ServiceConfig struct {
@@ -32,6 +48,12 @@ type ServiceConfig struct {
 	ApiClients   *api_clients.APIClients
 	Hub          *websocket.Hub
 	Redis        config.RedisConfig
+	Backend      config.WorkspaceBackendConfig
+	Reconciler   config.ReconcilerConfig
+	Secrets      config.SecretsConfig
+	Idle         config.IdleConfig
+	Retention    config.RetentionConfig
+	Webhooks     config.WebhooksConfig
 }
 
 func Provider(c *di.Container) (*Services, error) {
@@ -49,6 +71,12 @@ func Provider(c *di.Container) (*Services, error) {
 		ApiClients:   apiClients,
 		Hub:          hub,
 		Redis:        cfg.Redis,
+		Backend:      cfg.WorkspaceBackend,
+		Reconciler:   cfg.Reconciler,
+		Secrets:      cfg.Secrets,
+		Idle:         cfg.Idle,
+		Retention:    cfg.Retention,
+		Webhooks:     cfg.Webhooks,
 	}), nil
 }
 
@@ -57,19 +85,26 @@ func NewServices(config *ServiceConfig) *Services {
 		RabbitMQ: config.RabbitMQ,
 	})
 
-	devpodService := devpod.NewDevpodService()
+	backend := devpod.NewWorkspaceBackend(config.Backend)
 	fmt.Println(fmt.Sprintf("%s:%d", config.Redis.Host, config.Redis.Port))
-	asynqClient := asynq.NewClient(asynq.RedisClientOpt{
+	redisOpt := asynq.RedisClientOpt{
 		Addr:     fmt.Sprintf("%s:%d", config.Redis.Host, config.Redis.Port),
 		Username: config.Redis.Username,
 		Password: config.Redis.Password,
-	})
+	}
+	asynqClient := asynq.NewClient(redisOpt)
+	asynqInspector := asynq.NewInspector(redisOpt)
 
 	socketService := NewSocketService(&SocketServiceConfig{
 		Hub: config.Hub,
 	})
 	config.Hub.MessageRouter = socketService
 
+	workspaceShareService := NewWorkspaceShareService(&WorkspaceShareServiceConfig{
+		Repositories: config.Repositories,
+	})
+	config.Hub.Authorizer = workspaceShareService
+
 	workspaceConfigService := NewWorkspaceConfigService(&WorkspaceConfigServiceConfig{
 		Repositories: config.Repositories,
 	})
@@ -78,6 +113,58 @@ func NewServices(config *ServiceConfig) *Services {
 		Repositories: config.Repositories,
 	})
 
+	envVarService := NewEnvVarService(&EnvVarServiceConfig{
+		Repositories:  config.Repositories,
+		EncryptionKey: config.Secrets.EncryptionKey,
+	})
+
+	userPreferenceService := NewUserPreferenceService(&UserPreferenceServiceConfig{
+		Repositories: config.Repositories,
+	})
+
+	quotaService := NewQuotaService(&QuotaServiceConfig{
+		Repositories: config.Repositories,
+	})
+
+	usageService := NewUsageService(&UsageServiceConfig{
+		Repositories: config.Repositories,
+	})
+
+	budgetService := NewBudgetService(&BudgetServiceConfig{
+		Repositories: config.Repositories,
+		Usage:        usageService,
+	})
+
+	webhookService := NewWebhookService(&WebhookServiceConfig{
+		Repositories:  config.Repositories,
+		AsynqClient:   asynqClient,
+		EncryptionKey: config.Secrets.EncryptionKey,
+		Webhooks:      config.Webhooks,
+	})
+
+	workspacePortService := NewWorkspacePortService(&WorkspacePortServiceConfig{
+		Repositories: config.Repositories,
+		Backend:      backend,
+		AsynqClient:  asynqClient,
+	})
+
+	workspaceService := NewWorkspaceService(&WorkspaceServiceConfig{
+		Repositories:    config.Repositories,
+		Publisher:       publisher,
+		Socket:          socketService,
+		WorkspaceConfig: workspaceConfigService,
+		EnvVar:          envVarService,
+		UserPreference:  userPreferenceService,
+		Quota:           quotaService,
+		Budget:          budgetService,
+		Webhook:         webhookService,
+		WorkspacePort:   workspacePortService,
+		Backend:         backend,
+		AsynqClient:     asynqClient,
+		AsynqInspector:  asynqInspector,
+		ActionTimeouts:  WorkspaceActionTimeouts(config.Backend.Devpod),
+	})
+
 	return &Services{
 		Publisher: publisher,
 		User: NewUserService(&UserServiceConfig{
@@ -95,17 +182,58 @@ func NewServices(config *ServiceConfig) *Services {
 		Repository: NewRepositoryService(&RepositoryServiceConfig{
 			Repositories: config.Repositories,
 		}),
-		Workspace: NewWorkspaceService(&WorkspaceServiceConfig{
-			Repositories:    config.Repositories,
-			Publisher:       publisher,
-			Socket:          socketService,
-			WorkspaceConfig: workspaceConfigService,
-			Devpod:          devpodService,
-			AsynqClient:     asynqClient,
-		}),
+		Workspace:       workspaceService,
 		WorkspaceConfig: workspaceConfigService,
 		WorkspaceLog:    workspaceLogService,
+		EnvVar:          envVarService,
+		UserPreference:  userPreferenceService,
+		WorkspaceShare:  workspaceShareService,
 		Socket:          socketService,
-		Devpod:          devpodService,
+		Backend:         backend,
+		WorkspaceTemplate: NewWorkspaceTemplateService(&WorkspaceTemplateServiceConfig{
+			Repositories: config.Repositories,
+		}),
+		Quota:  quotaService,
+		Usage:  usageService,
+		Budget: budgetService,
+		BudgetEvaluator: NewBudgetEvaluatorService(&BudgetEvaluatorServiceConfig{
+			Repositories: config.Repositories,
+			Budget:       budgetService,
+			Workspace:    workspaceService,
+			Publisher:    publisher,
+		}),
+		OrganizationSetting: NewOrganizationSettingService(&OrganizationSettingServiceConfig{
+			Repositories: config.Repositories,
+		}),
+		Idle: NewIdleService(&IdleServiceConfig{
+			Repositories: config.Repositories,
+			Workspace:    workspaceService,
+			Publisher:    publisher,
+			Idle:         config.Idle,
+		}),
+		WorkspaceSchedule: NewWorkspaceScheduleService(&WorkspaceScheduleServiceConfig{
+			Repositories: config.Repositories,
+			Workspace:    workspaceService,
+		}),
+		Retention: NewRetentionService(&RetentionServiceConfig{
+			Repositories: config.Repositories,
+			Workspace:    workspaceService,
+			Publisher:    publisher,
+			Backend:      backend,
+			Retention:    config.Retention,
+		}),
+		WorkspaceAdmin: NewWorkspaceAdminService(&WorkspaceAdminServiceConfig{
+			Repositories: config.Repositories,
+			Workspace:    workspaceService,
+			AsynqClient:  asynqClient,
+		}),
+		Webhook:       webhookService,
+		WorkspacePort: workspacePortService,
+		Reconciler: NewReconcilerService(&ReconcilerServiceConfig{
+			Repositories:          config.Repositories,
+			Workspace:             workspaceService,
+			Backend:               backend,
+			GarbageCollectOrphans: config.Reconciler.GarbageCollectOrphans,
+		}),
 	}
 }
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// budgetStoppedStatuses are the statuses the stop policy stops workspaces
+// in. A pending workspace has no action that could boot it yet, and a
+// stopping one is already on its way down.
+var budgetStoppedStatuses = []enums.WorkspaceStatus{
+	enums.WorkspaceStatusStarting,
+	enums.WorkspaceStatusCreating,
+	enums.WorkspaceStatusRunning,
+	enums.WorkspaceStatusRestarting,
+	enums.WorkspaceStatusRebuilding,
+}
+
+type BudgetEvaluatorServiceConfig struct {
+	Repositories *repositories.Repositories
+	Budget       *BudgetService
+	Workspace    *WorkspaceService
+	Publisher    *PublisherService
+}
+
+// BudgetEvaluatorService periodically compares what organizations spent
+// with their budgets, raises the threshold alerts and applies the stop
+// policy.
+type BudgetEvaluatorService struct {
+	budgetRepository    *repositories.BudgetRepository
+	workspaceRepository *repositories.WorkspaceRepository
+	budgetService       *BudgetService
+	workspaceService    *WorkspaceService
+	publisherService    *PublisherService
+}
+
+func NewBudgetEvaluatorService(config *BudgetEvaluatorServiceConfig) *BudgetEvaluatorService {
+	return &BudgetEvaluatorService{
+		budgetRepository:    config.Repositories.Budget,
+		workspaceRepository: config.Repositories.Workspace,
+		budgetService:       config.Budget,
+		workspaceService:    config.Workspace,
+		publisherService:    config.Publisher,
+	}
+}
+
+// Evaluate checks every budget. A failing organization is logged and does
+// not keep the others from being evaluated.
+func (s *BudgetEvaluatorService) Evaluate(ctx context.Context) error {
+	budgets, err := s.budgetRepository.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load budgets: %w", err)
+	}
+
+	for _, budget := range budgets {
+		if err := s.evaluateBudget(ctx, budget); err != nil {
+			log.Printf("[budgets] failed to evaluate the budget of organization %d: %v", budget.OrganizationID, err)
+		}
+	}
+	return nil
+}
+
+func (s *BudgetEvaluatorService) evaluateBudget(ctx context.Context, budget models.Budget) error {
+	spending, err := s.budgetService.Spending(ctx, budget)
+	if err != nil {
+		return err
+	}
+
+	for _, threshold := range enums.BudgetAlertThresholds {
+		if spending.Percent < float64(threshold) {
+			break
+		}
+		alert := models.BudgetAlert{
+			OrganizationID: budget.OrganizationID,
+			Period:         spending.Period,
+			Threshold:      threshold,
+			MonthlyAmount:  budget.MonthlyAmount,
+			Cost:           spending.Cost,
+		}
+		created, err := s.budgetRepository.CreateAlert(ctx, &alert)
+		if err != nil {
+			return fmt.Errorf("failed to record the %d%% alert: %w", threshold, err)
+		}
+		if created {
+			log.Printf("[budgets] organization %d reached %d%% of its budget (%.2f of %.2f)",
+				budget.OrganizationID, threshold, spending.Cost, budget.MonthlyAmount)
+			s.notify(alert, budget.Policy)
+		}
+	}
+
+	if spending.Exceeded && budget.Policy == enums.BudgetPolicyStop {
+		return s.stopRunningWorkspaces(ctx, budget.OrganizationID)
+	}
+	return nil
+}
+
+// stopRunningWorkspaces stops the organization's workspaces that run a
+// machine or are booting one. A stop submitted while the workspace is
+// booting queues behind that action.
+func (s *BudgetEvaluatorService) stopRunningWorkspaces(ctx context.Context, organizationID uint32) error {
+	workspaces, err := s.workspaceRepository.GetByOrganizationAndStatuses(ctx, organizationID, budgetStoppedStatuses)
+	if err != nil {
+		return fmt.Errorf("failed to load running workspaces: %w", err)
+	}
+
+	for _, workspace := range workspaces {
+		if _, err := s.workspaceService.SubmitWorkspaceAction(ctx, workspace.ID, workspace.UserID, constants.ActionStop, enums.WorkspaceActorBudget); err != nil {
+			log.Printf("[budgets] failed to stop workspace %d over budget: %v", workspace.ID, err)
+			continue
+		}
+		log.Printf("[budgets] stopping workspace %d, organization %d is over budget", workspace.ID, organizationID)
+	}
+	return nil
+}
+
+// notify sends the alert to the organization's budget channel.
+func (s *BudgetEvaluatorService) notify(alert models.BudgetAlert, policy enums.BudgetPolicy) {
+	body := dto.Message{
+		EventType: constants.BudgetAlert,
+		Channel:   fmt.Sprintf("organization_%d_budget", alert.OrganizationID),
+		Data: map[string]interface{}{
+			"alert":  dto.ToBudgetAlertDTO(alert),
+			"policy": policy,
+		},
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to marshal message: %v", err)
+		return
+	}
+	s.publisherService.Publish(constants.CLUSTERIX_CODE_V1_EXCHANGE, constants.WORKSPACE_LOG_HANDLER_QUEUE, payload)
+}
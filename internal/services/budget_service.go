@@ -0,0 +1,128 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"fmt"
+)
+
+type BudgetServiceConfig struct {
+	Repositories *repositories.Repositories
+	Usage        *UsageService
+}
+
+// BudgetService manages the monthly budgets of organizations and what
+// they spent of them.
+type BudgetService struct {
+	budgetRepository *repositories.BudgetRepository
+	usageService     *UsageService
+}
+
+func NewBudgetService(config *BudgetServiceConfig) *BudgetService {
+	return &BudgetService{
+		budgetRepository: config.Repositories.Budget,
+		usageService:     config.Usage,
+	}
+}
+
+// GetBudget returns the organization's budget with this month's spending.
+func (s *BudgetService) GetBudget(ctx context.Context, organizationID uint32) (dto.BudgetDTO, error) {
+	budget, err := s.budgetRepository.Get(ctx, organizationID)
+	if err != nil {
+		return dto.BudgetDTO{}, err
+	}
+	if budget == nil {
+		return dto.BudgetDTO{}, internalErrors.NewNotFoundError("budget")
+	}
+	return s.toBudgetDTO(ctx, *budget)
+}
+
+func (s *BudgetService) SetBudget(ctx context.Context, organizationID uint32, req requests.SetBudgetRequest) (dto.BudgetDTO, error) {
+	budget := models.Budget{
+		OrganizationID: organizationID,
+		MonthlyAmount:  *req.MonthlyAmount,
+		Policy:         enums.BudgetPolicy(req.Policy),
+	}
+	if err := s.budgetRepository.Save(ctx, &budget); err != nil {
+		return dto.BudgetDTO{}, err
+	}
+	return s.GetBudget(ctx, organizationID)
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, organizationID uint32) error {
+	deleted, err := s.budgetRepository.Delete(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return internalErrors.NewNotFoundError("budget")
+	}
+	return nil
+}
+
+func (s *BudgetService) GetAlerts(ctx context.Context, organizationID uint32, page, limit int) (pagination.Pagination, error) {
+	alerts, err := s.budgetRepository.GetAlerts(ctx, organizationID, page, limit)
+	if err != nil {
+		return alerts, err
+	}
+	alerts.Data = dto.ToBudgetAlertDTOs(alerts.Data.([]models.BudgetAlert))
+	return alerts, nil
+}
+
+// CheckWorkspaceStart refuses to start workspaces of an organization that
+// spent its budget when its policy says so.
+func (s *BudgetService) CheckWorkspaceStart(ctx context.Context, organizationID uint32) error {
+	budget, err := s.budgetRepository.Get(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	if budget == nil || !budget.Policy.BlocksStarts() {
+		return nil
+	}
+
+	spending, err := s.Spending(ctx, *budget)
+	if err != nil {
+		return err
+	}
+	if spending.Exceeded {
+		return internalErrors.NewBudgetExceededError(
+			fmt.Sprintf("The organization spent its monthly budget of %.2f", budget.MonthlyAmount),
+			map[string]any{"period": spending.Period, "monthly_amount": budget.MonthlyAmount, "cost": spending.Cost, "policy": budget.Policy})
+	}
+	return nil
+}
+
+// Spending is what the organization spent of the budget this month.
+func (s *BudgetService) Spending(ctx context.Context, budget models.Budget) (dto.BudgetSpendDTO, error) {
+	usage, err := s.usageService.GetUsage(ctx, budget.OrganizationID, requests.UsageRequest{})
+	if err != nil {
+		return dto.BudgetSpendDTO{}, err
+	}
+
+	percent := usage.Cost / budget.MonthlyAmount * 100
+	return dto.BudgetSpendDTO{
+		Period:   usage.From.Format(budgetPeriodLayout),
+		Cost:     usage.Cost,
+		Percent:  dto.RoundUsage(percent),
+		Exceeded: percent >= 100,
+	}, nil
+}
+
+const budgetPeriodLayout = "2006-01"
+
+func (s *BudgetService) toBudgetDTO(ctx context.Context, budget models.Budget) (dto.BudgetDTO, error) {
+	spending, err := s.Spending(ctx, budget)
+	if err != nil {
+		return dto.BudgetDTO{}, err
+	}
+
+	budgetDTO := dto.ToBudgetDTO(budget)
+	budgetDTO.Spending = &spending
+	return budgetDTO, nil
+}
@@ -0,0 +1,96 @@
+package devpod
+
+import (
+	"clusterix-code/internal/config"
+	"clusterix-code/internal/data/dto"
+	"context"
+	"time"
+)
+
+const (
+	BackendDevpod = "devpod"
+	BackendFake   = "fake"
+)
+
+// WorkspaceState is the state of a workspace as reported by its backend.
+type WorkspaceState string
+
+const (
+	WorkspaceStateRunning  WorkspaceState = "Running"
+	WorkspaceStateStopped  WorkspaceState = "Stopped"
+	WorkspaceStateBusy     WorkspaceState = "Busy"
+	WorkspaceStateNotFound WorkspaceState = "NotFound"
+	// WorkspaceStateUnknown is a workspace the backend lists but whose
+	// state could not be read.
+	WorkspaceStateUnknown WorkspaceState = "Unknown"
+)
+
+// PortForward is a port of a workspace forwarded to LocalPort on the
+// worker's loopback interface.
+type PortForward struct {
+	LocalPort int
+	// Done is closed once the forward stopped, because its context is done
+	// or the connection to the workspace dropped.
+	Done <-chan struct{}
+}
+
+// WorkspaceInfo is a workspace known to the backend.
+type WorkspaceInfo struct {
+	ID    string
+	State WorkspaceState
+}
+
+// WorkspaceBackend provisions and manages the machines behind a workspace.
+// Every action streams its progress to onEvent as typed events; onEvent may
+// be nil.
+type WorkspaceBackend interface {
+	StartWorkspace(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, onEvent EventHandler) error
+	StopWorkspace(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, onEvent EventHandler) error
+	RestartWorkspace(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, onEvent EventHandler) error
+	RebuildWorkspace(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, onEvent EventHandler) error
+	TerminateWorkspace(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, onEvent EventHandler) error
+	WorkspaceStatus(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) (WorkspaceState, error)
+	ListWorkspaces(ctx context.Context) ([]WorkspaceInfo, error)
+	// DeleteDNSRecord removes the workspace's DNS record and those of its
+	// Ports, which terminating it already does. A missing record is not an
+	// error.
+	DeleteDNSRecord(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) error
+	// DeletePortDNSRecord removes the DNS record ForwardPort created for the
+	// port. A missing record is not an error.
+	DeletePortDNSRecord(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port int) error
+	// DevcontainerPorts returns the ports the forwardPorts of the running
+	// workspace's devcontainer.json declare on the workspace itself.
+	DevcontainerPorts(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) ([]int, error)
+	// ForwardPort forwards a port of the running workspace to the worker
+	// until ctx is done, and gives the port its DNS record.
+	ForwardPort(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port int) (*PortForward, error)
+}
+
+// NewWorkspaceBackend returns the backend selected by cfg.Driver, falling
+// back to the devpod CLI when the driver is empty or unknown.
+func NewWorkspaceBackend(cfg config.WorkspaceBackendConfig) WorkspaceBackend {
+	switch cfg.Driver {
+	case BackendFake:
+		return NewFakeBackend(&FakeBackendConfig{
+			StepDelay:   cfg.FakeStepDelay,
+			FailActions: cfg.FakeFailActions,
+		})
+	default:
+		return NewDevpodService(&DevpodServiceConfig{
+			Runner: NewDevpodRunner(&DevpodRunnerConfig{
+				Binary:  cfg.Devpod.Binary,
+				Home:    cfg.Devpod.Home,
+				Context: cfg.Devpod.Context,
+				Timeouts: map[string]time.Duration{
+					"up":     cfg.Devpod.UpTimeout,
+					"stop":   cfg.Devpod.StopTimeout,
+					"delete": cfg.Devpod.DeleteTimeout,
+					"status": cfg.Devpod.StatusTimeout,
+					"list":   cfg.Devpod.StatusTimeout,
+				},
+			}),
+			Provider:          cfg.Devpod.Provider,
+			InactivityTimeout: cfg.Devpod.InactivityTimeout,
+		})
+	}
+}
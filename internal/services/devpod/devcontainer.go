@@ -0,0 +1,100 @@
+package devpod
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// devcontainerConfig is the part of a devcontainer.json the worker reads.
+type devcontainerConfig struct {
+	ForwardPorts []json.RawMessage `json:"forwardPorts"`
+}
+
+// parseForwardPorts returns the ports a devcontainer.json forwards from the
+// workspace itself: plain numbers and "localhost:<port>" entries. Ports of
+// other containers, such as "db:5432", are skipped.
+func parseForwardPorts(data []byte) ([]int, error) {
+	var config devcontainerConfig
+	if err := json.Unmarshal(standardizeJSONC(data), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse devcontainer.json: %w", err)
+	}
+
+	var ports []int
+	for _, raw := range config.ForwardPorts {
+		var port int
+		if err := json.Unmarshal(raw, &port); err != nil {
+			var entry string
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				continue
+			}
+			host, value, found := strings.Cut(entry, ":")
+			if !found {
+				host, value = "localhost", entry
+			}
+			if host != "localhost" && host != "127.0.0.1" {
+				continue
+			}
+			if port, err = strconv.Atoi(value); err != nil {
+				continue
+			}
+		}
+		if port >= 1 && port <= 65535 {
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// standardizeJSONC turns the JSON with comments and trailing commas that
+// devcontainer.json files are written in into plain JSON.
+func standardizeJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString, escaped := false, false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += 2 + end + 1
+			}
+		case c == ']' || c == '}':
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
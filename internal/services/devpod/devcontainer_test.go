@@ -0,0 +1,128 @@
+package devpod
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStandardizeJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "line comment",
+			input: "{\n  // the name\n  \"name\": \"app\"\n}",
+			want:  `{"name":"app"}`,
+		},
+		{
+			name:  "block comment",
+			input: `{ /* the name, "quoted" */ "name": "app" }`,
+			want:  `{"name":"app"}`,
+		},
+		{
+			name:  "unterminated block comment",
+			input: `{"name": "app"} /* trailing`,
+			want:  `{"name":"app"}`,
+		},
+		{
+			name:  "comment markers inside strings",
+			input: `{"url": "http://example.com/*path*/", "note": "a // b"}`,
+			want:  `{"note":"a // b","url":"http://example.com/*path*/"}`,
+		},
+		{
+			name:  "escaped quote inside string",
+			input: `{"name": "a \"b\" // c"}`,
+			want:  `{"name":"a \"b\" // c"}`,
+		},
+		{
+			name:  "trailing commas",
+			input: "{\"ports\": [3000, 8080,\n],\n\"features\": {\"a\": {},},\n}",
+			want:  `{"features":{"a":{}},"ports":[3000,8080]}`,
+		},
+		{
+			name:  "comma before comment and closing bracket",
+			input: "{\"ports\": [3000, // the app\n]}",
+			want:  `{"ports":[3000]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got interface{}
+			if err := json.Unmarshal(standardizeJSONC([]byte(tt.input)), &got); err != nil {
+				t.Fatalf("standardized JSON does not parse: %v\n%s", err, standardizeJSONC([]byte(tt.input)))
+			}
+			normalized, _ := json.Marshal(got)
+			if string(normalized) != tt.want {
+				t.Errorf("got %s, want %s", normalized, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseForwardPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:  "numbers",
+			input: `{"forwardPorts": [3000, 8080]}`,
+			want:  []int{3000, 8080},
+		},
+		{
+			name:  "localhost entries",
+			input: `{"forwardPorts": ["localhost:3000", "127.0.0.1:5173", "8080"]}`,
+			want:  []int{3000, 5173, 8080},
+		},
+		{
+			name:  "other containers are skipped",
+			input: `{"forwardPorts": ["db:5432", "redis:6379", 3000]}`,
+			want:  []int{3000},
+		},
+		{
+			name:  "invalid ports are skipped",
+			input: `{"forwardPorts": [0, 70000, "localhost:abc", true, 3000]}`,
+			want:  []int{3000},
+		},
+		{
+			name: "comments and trailing commas",
+			input: `{
+				// Ports the app listens on
+				"forwardPorts": [
+					3000, /* web */
+					"localhost:8080",
+					"db:5432",
+				],
+			}`,
+			want: []int{3000, 8080},
+		},
+		{
+			name:  "no forwardPorts",
+			input: `{"name": "app"}`,
+			want:  nil,
+		},
+		{
+			name:    "invalid JSON",
+			input:   `{"forwardPorts": [3000`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseForwardPorts([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,30 +2,46 @@ package devpod
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
-	"strings"
 	"time"
 )
 
-type logLevel int
-
-const (
-	InfoLog logLevel = iota
-	DoneLog
-	FatalLog
-)
+// ProviderAWS is the devpod AWS provider, the only one that takes the
+// AWS_* options.
+const ProviderAWS = "aws"
+
+type DevpodServiceConfig struct {
+	Runner *DevpodRunner
+	// Provider is the devpod provider workspaces are created with.
+	Provider string
+	// InactivityTimeout lets the AWS provider stop SSH-only workspaces
+	// nobody is connected to, e.g. "10m"; empty never stops them. Browser
+	// IDE workspaces are left to the idle service.
+	InactivityTimeout string
+}
 
-type DevpodService struct{}
+type DevpodService struct {
+	runner            *DevpodRunner
+	provider          string
+	inactivityTimeout string
+}
 
-func NewDevpodService() *DevpodService {
-	_ = ensureAWSProvider()
-	if err := setDevpodIdleTimeout(); err != nil {
+func NewDevpodService(config *DevpodServiceConfig) *DevpodService {
+	s := &DevpodService{
+		runner:            config.Runner,
+		provider:          config.Provider,
+		inactivityTimeout: config.InactivityTimeout,
+	}
+	_ = s.ensureProvider()
+	if err := s.setDevpodIdleTimeout(); err != nil {
 		fmt.Printf("[DevpodService] WARNING: failed to set idle timeout: %v\n", err)
 	}
-	return &DevpodService{}
+	return s
 }
 
 func forwardPortWithSocat(workspaceID, userID uint64, internalPort string) {
@@ -70,38 +86,11 @@ func forwardPortWithSocat(workspaceID, userID uint64, internalPort string) {
 	processLog(workspaceID, userID, "LOG", false, "Workspace available at http://localhost:%d/?folder=...", externalPort)
 }
 
-func handleCallback(callback func(string, string) error, workspaceID, userID uint64, line string, logType string) {
-	if callback != nil {
-		if err := callback(line, logType); err != nil {
-			processLog(workspaceID, userID, "LOG", true, "onRunning callback error: %v", err)
-		}
-	}
-}
-
 func processLog(workspaceID uint64, userID uint64, logType string, isUserLog bool, format string, args ...interface{}) {
 	//message := fmt.Sprintf(format, args...)
 	//log.Printf("[devpod-%d] [%s] %s", workspaceID, logType, message)
 }
 
-func processLogWithLevel(level logLevel, workspaceID, userID uint64, message string) {
-	var styled, logType string
-	switch level {
-	case DoneLog:
-		logType = "DONE"
-		styled = fmt.Sprintf("✅ \033[1;32m[%s] %s\033[0m", logType, message)
-	case FatalLog:
-		logType = "FATAL"
-		styled = fmt.Sprintf("🚨 \033[1;31m[%s] %s\033[0m", logType, message)
-	case InfoLog:
-		logType = "INFO"
-		styled = fmt.Sprintf("🔸 [%s] %s", logType, message)
-	default:
-		logType = "LOG"
-		styled = message
-	}
-	processLog(workspaceID, userID, logType, true, styled)
-}
-
 func waitForPort(port string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
@@ -127,26 +116,26 @@ func getAvailablePort(start int) (int, error) {
 	return 0, fmt.Errorf("no available ports")
 }
 
-func ensureAWSProvider() error {
-	cmd := exec.Command("devpod", "provider", "add", "aws",
-		"--option", "AWS_REGION="+os.Getenv("AWS_REGION"),
-		"--option", "AWS_ACCESS_KEY_ID="+os.Getenv("AWS_ACCESS_KEY_ID"),
-		"--option", "AWS_SECRET_ACCESS_KEY="+os.Getenv("AWS_SECRET_ACCESS_KEY"),
-		"--option", "subnetId="+os.Getenv("AWS_SUBNET_ID"),
-		"--option", "securityGroupIds="+os.Getenv("AWS_SECURITY_GROUP_IDS"),
-		"--option", "INACTIVITY_TIMEOUT=10m",
-		"--option", "AWS_INSTANCE_TYPE=t2.nano",
-	)
-	// cmd := exec.Command("devpod", "provider", "add", "docker")
-	cmd.Env = os.Environ()
-	_, _ = cmd.CombinedOutput()
+// ensureProvider adds the configured provider. The AWS provider is
+// configured from the AWS_* environment variables.
+func (s *DevpodService) ensureProvider() error {
+	args := []string{"add", s.provider}
+	if s.provider == ProviderAWS {
+		args = append(args,
+			"--option", "AWS_REGION="+os.Getenv("AWS_REGION"),
+			"--option", "AWS_ACCESS_KEY_ID="+os.Getenv("AWS_ACCESS_KEY_ID"),
+			"--option", "AWS_SECRET_ACCESS_KEY="+os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			"--option", "subnetId="+os.Getenv("AWS_SUBNET_ID"),
+			"--option", "securityGroupIds="+os.Getenv("AWS_SECURITY_GROUP_IDS"),
+			"--option", "AWS_INSTANCE_TYPE=t2.nano",
+		)
+	}
+	_, _ = s.runner.Output(context.Background(), "provider", args...)
 	return nil
 }
 
-func setDevpodIdleTimeout() error {
-	cmd := exec.Command("devpod", "context", "set-options", "-o", "EXIT_AFTER_TIMEOUT=false")
-	cmd.Env = os.Environ()
-	output, err := cmd.CombinedOutput()
+func (s *DevpodService) setDevpodIdleTimeout() error {
+	output, err := s.runner.Output(context.Background(), "context", "set-options", "-o", "EXIT_AFTER_TIMEOUT=false")
 	if err != nil {
 		return fmt.Errorf("failed to set devpod idle timeout: %w\noutput: %s", err, string(output))
 	}
@@ -154,14 +143,27 @@ func setDevpodIdleTimeout() error {
 	return nil
 }
 
-func isDoneLine(line string) bool {
-	return strings.Contains(line, "done")
-}
-
-func isInfoLine(line string) bool {
-	return strings.Contains(line, "info")
-}
+// maxEventLineSize bounds a single line of devpod output. Longer lines are
+// not decoded, but the rest of the output is still drained.
+const maxEventLineSize = 1024 * 1024
+
+// streamEvents decodes devpod output line by line, masks secrets in it and
+// hands every event to emit. It reads until the output is closed, so the
+// command never blocks on a full pipe, even once a line can't be scanned.
+func streamEvents(output io.Reader, workspaceID uint64, secrets []string, emit func(Event)) {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 64*1024), maxEventLineSize)
+
+	for scanner.Scan() {
+		event, ok := ParseEvent(scanner.Text())
+		if !ok {
+			continue
+		}
+		emit(redactEvent(event, secrets))
+	}
 
-func isFatalLine(line string) bool {
-	return strings.Contains(line, "fatal")
+	if err := scanner.Err(); err != nil {
+		processLog(workspaceID, 0, "LOG", true, "scanner error: %v", err)
+		_, _ = io.Copy(io.Discard, output)
+	}
 }
@@ -0,0 +1,192 @@
+package devpod
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventType tells what a line of devpod output reports about an action.
+type EventType string
+
+const (
+	EventLog            EventType = "log"
+	EventMachineCreated EventType = "machine_created"
+	EventIDEURL         EventType = "ide_url"
+	EventPhase          EventType = "phase"
+	EventError          EventType = "error"
+)
+
+// Phase is a step of an action, reported when devpod enters or completes it.
+type Phase string
+
+const (
+	PhaseCloning  Phase = "cloning"
+	PhaseBuilding Phase = "building"
+	PhaseStarted  Phase = "started"
+	PhaseStopped  Phase = "stopped"
+	PhaseDeleted  Phase = "deleted"
+)
+
+// Event is one decoded line of devpod output.
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Level   string
+	Message string
+
+	// MachineName is set on EventMachineCreated.
+	MachineName string
+	// URL and Port are set on EventIDEURL; Port is the local port devpod
+	// forwards the IDE to.
+	URL  string
+	Port int
+	// Phase is set on EventPhase.
+	Phase Phase
+}
+
+// EventHandler receives the events of an action as devpod prints them.
+type EventHandler func(event Event)
+
+// jsonLogLine is a line of `devpod --log-output json`.
+type jsonLogLine struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+var (
+	textLogPattern       = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\s+(\w+)\s+(.+)$`)
+	machineCreatePattern = regexp.MustCompile(`Create machine '([^']+)'`)
+	ideURLPattern        = regexp.MustCompile(`Successfully opened (http[^\s]+)`)
+)
+
+var phasePatterns = []struct {
+	phase   Phase
+	pattern string
+}{
+	{PhaseCloning, "cloning repository"},
+	{PhaseCloning, "clone repository"},
+	{PhaseBuilding, "building devcontainer"},
+	{PhaseBuilding, "build devcontainer"},
+	{PhaseStarted, "successfully started workspace"},
+	{PhaseStopped, "successfully stopped"},
+	{PhaseDeleted, "successfully deleted workspace"},
+}
+
+// ParseEvent decodes a line of devpod output. JSON log lines are decoded
+// directly; plain text lines, from older devpod versions or from the tools
+// devpod runs, fall back to the "15:04:05 level message" layout. Blank lines
+// yield no event.
+func ParseEvent(line string) (Event, bool) {
+	line = cleanOutputLine(line)
+	if line == "" {
+		return Event{}, false
+	}
+
+	event, ok := parseJSONLine(line)
+	if !ok {
+		event = parseTextLine(line)
+	}
+	classifyEvent(&event)
+	return event, true
+}
+
+// NewEvent builds the event of a message that did not come from devpod's
+// output, such as the fake backend's or the worker's own messages.
+func NewEvent(level, message string) Event {
+	event := Event{Time: time.Now(), Level: level, Message: message}
+	classifyEvent(&event)
+	return event
+}
+
+func parseJSONLine(line string) (Event, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return Event{}, false
+	}
+
+	var entry jsonLogLine
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Message == "" {
+		return Event{}, false
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	return Event{
+		Time:    entry.Time,
+		Level:   strings.ToLower(entry.Level),
+		Message: cleanOutputLine(entry.Message),
+	}, true
+}
+
+func parseTextLine(line string) Event {
+	event := Event{Time: time.Now(), Message: line}
+	if match := textLogPattern.FindStringSubmatch(line); match != nil {
+		event.Level = strings.ToLower(match[2])
+		event.Message = match[3]
+	}
+	return event
+}
+
+// classifyEvent sets the type of an event, and the fields that come with
+// it, from its level and message.
+func classifyEvent(event *Event) {
+	event.Type = EventLog
+
+	if event.Level == "fatal" || event.Level == "error" {
+		event.Type = EventError
+		return
+	}
+
+	if match := machineCreatePattern.FindStringSubmatch(event.Message); match != nil {
+		event.Type = EventMachineCreated
+		event.MachineName = match[1]
+		return
+	}
+
+	if match := ideURLPattern.FindStringSubmatch(event.Message); match != nil {
+		event.Type = EventIDEURL
+		event.URL = match[1]
+		event.Port = urlPort(match[1])
+		return
+	}
+
+	lower := strings.ToLower(event.Message)
+	for _, phase := range phasePatterns {
+		if strings.Contains(lower, phase.pattern) {
+			event.Type = EventPhase
+			event.Phase = phase.phase
+			return
+		}
+	}
+}
+
+// redactedValue replaces secrets in devpod output.
+const redactedValue = "********"
+
+// redactEvent masks every secret in the event's message and URL.
+func redactEvent(event Event, secrets []string) Event {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		event.Message = strings.ReplaceAll(event.Message, secret, redactedValue)
+		event.URL = strings.ReplaceAll(event.URL, secret, redactedValue)
+	}
+	return event
+}
+
+func urlPort(rawURL string) int {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	port, err := strconv.Atoi(parsedURL.Port())
+	if err != nil {
+		return 0
+	}
+	return port
+}
@@ -0,0 +1,141 @@
+package devpod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FailureCode classifies why a devpod action failed.
+type FailureCode string
+
+const (
+	FailureCloudQuota   FailureCode = "CLOUD_QUOTA_EXCEEDED"
+	FailureAuth         FailureCode = "AUTH_FAILED"
+	FailureGitClone     FailureCode = "GIT_CLONE_FAILED"
+	FailureDevcontainer FailureCode = "DEVCONTAINER_BUILD_FAILED"
+	FailureNetwork      FailureCode = "NETWORK_ERROR"
+	FailureTimeout      FailureCode = "TIMEOUT"
+	FailureUnknown      FailureCode = "UNKNOWN"
+)
+
+const maxRecordedOutputLines = 50
+
+// failurePatterns are matched, in order, against the lowercased output of a
+// failed action. The first class with a matching pattern wins, so specific
+// classes come before the broad network and timeout ones.
+var failurePatterns = []struct {
+	code     FailureCode
+	patterns []string
+}{
+	{FailureCloudQuota, []string{
+		"vcpulimitexceeded", "instancelimitexceeded", "insufficientinstancecapacity",
+		"quota", "limit exceeded",
+	}},
+	{FailureAuth, []string{
+		"authfailure", "unauthorizedoperation", "invalidclienttokenid", "signaturedoesnotmatch",
+		"expiredtoken", "authentication failed", "permission denied", "access denied", "unauthorized",
+	}},
+	{FailureGitClone, []string{
+		"git clone", "clone repository", "repository not found", "fatal: repository", "could not read from remote repository",
+		"couldn't find remote ref", "remote: not found",
+	}},
+	{FailureDevcontainer, []string{
+		"devcontainer", "dockerfile", "docker build", "build image", "error building",
+		"failed to build", "postcreatecommand", "lifecycle hook",
+	}},
+	{FailureNetwork, []string{
+		"connection refused", "connection reset", "no such host", "network is unreachable",
+		"tls handshake", "dial tcp", "i/o timeout",
+	}},
+	{FailureTimeout, []string{
+		"timed out", "timeout", "deadline exceeded",
+	}},
+}
+
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// ActionError is a failed devpod action with its failure class and a reason
+// fit to show to the user. ExitCode is devpod's exit status, or -1 when
+// devpod did not exit on its own.
+type ActionError struct {
+	Code     FailureCode
+	Reason   string
+	ExitCode int
+	Err      error
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Reason)
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyFailure derives the failure class of an action from the events it
+// printed and the error it ended with. The reason is the message that gave
+// the class away, or the first error message when nothing matched.
+func ClassifyFailure(ctx context.Context, events []Event, err error) *ActionError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &ActionError{Code: FailureTimeout, Reason: "devpod did not finish in time", Err: err}
+	}
+
+	// Only errors are classified; progress messages mention builds and
+	// clones on every successful run too.
+	candidates := make([]string, 0, len(events)+1)
+	for _, event := range events {
+		if isErrorEvent(event) {
+			candidates = append(candidates, event.Message)
+		}
+	}
+	if err != nil {
+		candidates = append(candidates, err.Error())
+	}
+
+	for _, class := range failurePatterns {
+		for _, line := range candidates {
+			lower := strings.ToLower(line)
+			for _, pattern := range class.patterns {
+				if strings.Contains(lower, pattern) {
+					return &ActionError{Code: class.code, Reason: line, Err: err}
+				}
+			}
+		}
+	}
+
+	reason := "devpod exited with an error"
+	if len(candidates) > 0 {
+		reason = candidates[0]
+	}
+	return &ActionError{Code: FailureUnknown, Reason: reason, Err: err}
+}
+
+// isErrorEvent reports whether devpod logged the event at fatal or error
+// level, or a tool it ran (git, docker) printed it as an error.
+func isErrorEvent(event Event) bool {
+	if event.Type == EventError {
+		return true
+	}
+	lower := strings.ToLower(event.Message)
+	return strings.HasPrefix(lower, "fatal:") || strings.HasPrefix(lower, "error:")
+}
+
+func cleanOutputLine(line string) string {
+	return strings.TrimSpace(ansiEscapePattern.ReplaceAllString(line, ""))
+}
+
+// outputTail keeps the last events printed by a devpod command so a failure
+// can be classified once the command exits.
+type outputTail struct {
+	events []Event
+}
+
+func (t *outputTail) add(event Event) {
+	t.events = append(t.events, event)
+	if len(t.events) > maxRecordedOutputLines {
+		t.events = t.events[len(t.events)-maxRecordedOutputLines:]
+	}
+}
@@ -0,0 +1,148 @@
+package devpod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name       string
+		timedOut   bool
+		events     []Event
+		err        error
+		wantCode   FailureCode
+		wantReason string
+	}{
+		{
+			name:       "deadline exceeded",
+			timedOut:   true,
+			events:     []Event{NewEvent("error", "git clone failed")},
+			err:        exitErr,
+			wantCode:   FailureTimeout,
+			wantReason: "devpod did not finish in time",
+		},
+		{
+			name: "error event",
+			events: []Event{
+				NewEvent("info", "Creating machine"),
+				NewEvent("error", "VcpuLimitExceeded: You have requested more vCPU capacity"),
+			},
+			err:        exitErr,
+			wantCode:   FailureCloudQuota,
+			wantReason: "VcpuLimitExceeded: You have requested more vCPU capacity",
+		},
+		{
+			name: "progress messages are ignored",
+			events: []Event{
+				NewEvent("info", "Cloning repository"),
+				NewEvent("info", "Building devcontainer"),
+				NewEvent("error", "dial tcp 10.0.0.1:22: connection refused"),
+			},
+			err:        exitErr,
+			wantCode:   FailureNetwork,
+			wantReason: "dial tcp 10.0.0.1:22: connection refused",
+		},
+		{
+			name: "tool errors without a level",
+			events: []Event{
+				NewEvent("", "fatal: repository 'https://example.com/app.git/' not found"),
+			},
+			err:        exitErr,
+			wantCode:   FailureGitClone,
+			wantReason: "fatal: repository 'https://example.com/app.git/' not found",
+		},
+		{
+			name: "earlier class wins over later lines",
+			events: []Event{
+				NewEvent("error", "i/o timeout"),
+				NewEvent("error", "permission denied (publickey)"),
+			},
+			err:        exitErr,
+			wantCode:   FailureAuth,
+			wantReason: "permission denied (publickey)",
+		},
+		{
+			name:       "exit error",
+			err:        errors.New("signal: killed: timed out waiting for agent"),
+			wantCode:   FailureTimeout,
+			wantReason: "signal: killed: timed out waiting for agent",
+		},
+		{
+			name: "unmatched error falls back to the first one",
+			events: []Event{
+				NewEvent("error", "something odd happened"),
+				NewEvent("error", "and then something else"),
+			},
+			err:        exitErr,
+			wantCode:   FailureUnknown,
+			wantReason: "something odd happened",
+		},
+		{
+			name:       "no output",
+			wantCode:   FailureUnknown,
+			wantReason: "devpod exited with an error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timedOut {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, time.Now())
+				defer cancel()
+			}
+
+			got := ClassifyFailure(ctx, tt.events, tt.err)
+
+			if got.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", got.Code, tt.wantCode)
+			}
+			if got.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", got.Reason, tt.wantReason)
+			}
+			if got.Err != tt.err {
+				t.Errorf("Err = %v, want %v", got.Err, tt.err)
+			}
+		})
+	}
+}
+
+func TestClassifyFailurePatterns(t *testing.T) {
+	for _, class := range failurePatterns {
+		for _, pattern := range class.patterns {
+			t.Run(fmt.Sprintf("%s/%s", class.code, pattern), func(t *testing.T) {
+				message := "devpod up: " + strings.ToUpper(pattern) + " while starting"
+				got := ClassifyFailure(context.Background(), []Event{NewEvent("error", message)}, nil)
+
+				if got.Code != class.code {
+					t.Errorf("Code = %s, want %s", got.Code, class.code)
+				}
+				if got.Reason != message {
+					t.Errorf("Reason = %q, want %q", got.Reason, message)
+				}
+			})
+		}
+	}
+}
+
+func TestOutputTail(t *testing.T) {
+	tail := &outputTail{}
+	for i := 0; i < maxRecordedOutputLines+10; i++ {
+		tail.add(NewEvent("info", fmt.Sprintf("line %d", i)))
+	}
+
+	if len(tail.events) != maxRecordedOutputLines {
+		t.Fatalf("len(events) = %d, want %d", len(tail.events), maxRecordedOutputLines)
+	}
+	if got := tail.events[0].Message; got != "line 10" {
+		t.Errorf("first event = %q, want %q", got, "line 10")
+	}
+}
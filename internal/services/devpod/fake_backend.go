@@ -0,0 +1,304 @@
+package devpod
+
+import (
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FakeBackendConfig struct {
+	// StepDelay is the pause between two scripted log lines.
+	StepDelay time.Duration
+	// FailActions lists the actions (start, stop, ...) that should fail,
+	// optionally with a failure code: "start:CLOUD_QUOTA_EXCEEDED".
+	FailActions []string
+}
+
+// FakeBackend is an in-process WorkspaceBackend that replays scripted devpod
+// output instead of running the devpod CLI. It lets the worker, the asynq
+// handlers and the status transitions run without devpod or AWS.
+type FakeBackend struct {
+	stepDelay   time.Duration
+	failActions map[constants.WorkspaceAction]FailureCode
+
+	mu     sync.Mutex
+	states map[uint64]WorkspaceState
+	ides   map[uint64]net.Listener
+}
+
+func NewFakeBackend(config *FakeBackendConfig) *FakeBackend {
+	failActions := make(map[constants.WorkspaceAction]FailureCode)
+	for _, entry := range config.FailActions {
+		action, code, found := strings.Cut(entry, ":")
+		if action == "" {
+			continue
+		}
+		if !found {
+			code = string(FailureUnknown)
+		}
+		failActions[constants.WorkspaceAction(action)] = FailureCode(code)
+	}
+
+	return &FakeBackend{
+		stepDelay:   config.StepDelay,
+		failActions: failActions,
+		states:      make(map[uint64]WorkspaceState),
+		ides:        make(map[uint64]net.Listener),
+	}
+}
+
+func (b *FakeBackend) StartWorkspace(
+	ctx context.Context,
+	devpodWorkspaceDTO dto.DevpodWorkspace,
+	onEvent EventHandler,
+) error {
+	workspaceID := devpodWorkspaceDTO.DevpodWorkspaceId
+	b.setState(workspaceID, WorkspaceStateBusy)
+
+	script := []string{
+		fmt.Sprintf("Using fake backend for workspace %d", workspaceID),
+		fmt.Sprintf("Create machine 'fake-%d' with provider 'fake'", workspaceID),
+		fmt.Sprintf("Cloning repository %s", gitSource(devpodWorkspaceDTO.RepositoryUrl, devpodWorkspaceDTO)),
+		"Building devcontainer...",
+	}
+	if devpodWorkspaceDTO.DotfilesRepository != "" {
+		script = append(script, fmt.Sprintf("Installing dotfiles %s", devpodWorkspaceDTO.DotfilesRepository))
+	}
+	if err := b.play(ctx, constants.ActionStart, script, onEvent); err != nil {
+		b.setState(workspaceID, WorkspaceStateStopped)
+		return err
+	}
+
+	b.emit(onEvent, "done", "Successfully started workspace")
+
+	// Like devpod, only browser IDEs are opened on the worker.
+	if enums.IDE(devpodWorkspaceDTO.DevpodWorkspaceIde).Kind() == enums.IDEKindBrowser {
+		port, err := b.openIDE(workspaceID)
+		if err != nil {
+			b.setState(workspaceID, WorkspaceStateStopped)
+			return fmt.Errorf("failed to open fake IDE: %w", err)
+		}
+		b.emit(onEvent, "info", fmt.Sprintf("Successfully opened http://127.0.0.1:%d/?folder=/workspaces/%d", port, workspaceID))
+	}
+
+	b.setState(workspaceID, WorkspaceStateRunning)
+	return nil
+}
+
+func (b *FakeBackend) StopWorkspace(
+	ctx context.Context,
+	devpodWorkspaceDTO dto.DevpodWorkspace,
+	onEvent EventHandler,
+) error {
+	workspaceID := devpodWorkspaceDTO.DevpodWorkspaceId
+
+	script := []string{
+		fmt.Sprintf("Stopping workspace %d", workspaceID),
+	}
+	if err := b.play(ctx, constants.ActionStop, script, onEvent); err != nil {
+		return err
+	}
+
+	b.closeIDE(workspaceID)
+	b.setState(workspaceID, WorkspaceStateStopped)
+	b.emit(onEvent, "done", "Successfully stopped workspace")
+	return nil
+}
+
+func (b *FakeBackend) RestartWorkspace(
+	ctx context.Context,
+	devpodWorkspaceDTO dto.DevpodWorkspace,
+	onEvent EventHandler,
+) error {
+	if err := b.StopWorkspace(ctx, devpodWorkspaceDTO, onEvent); err != nil {
+		return fmt.Errorf("failed to stop workspace for restart: %w", err)
+	}
+	return b.StartWorkspace(ctx, devpodWorkspaceDTO, onEvent)
+}
+
+func (b *FakeBackend) RebuildWorkspace(
+	ctx context.Context,
+	devpodWorkspaceDTO dto.DevpodWorkspace,
+	onEvent EventHandler,
+) error {
+	if err := b.TerminateWorkspace(ctx, devpodWorkspaceDTO, onEvent); err != nil {
+		return fmt.Errorf("failed to terminate workspace for rebuild: %w", err)
+	}
+	return b.StartWorkspace(ctx, devpodWorkspaceDTO, onEvent)
+}
+
+func (b *FakeBackend) TerminateWorkspace(
+	ctx context.Context,
+	devpodWorkspaceDTO dto.DevpodWorkspace,
+	onEvent EventHandler,
+) error {
+	workspaceID := devpodWorkspaceDTO.DevpodWorkspaceId
+
+	script := []string{
+		fmt.Sprintf("Deleting machine 'fake-%d'", workspaceID),
+	}
+	if err := b.play(ctx, constants.ActionTerminate, script, onEvent); err != nil {
+		return err
+	}
+
+	b.closeIDE(workspaceID)
+	b.mu.Lock()
+	delete(b.states, workspaceID)
+	b.mu.Unlock()
+	b.emit(onEvent, "done", fmt.Sprintf("Successfully deleted workspace '%d'", workspaceID))
+	return nil
+}
+
+// DeleteDNSRecord has nothing to do; fake workspaces get no DNS record.
+func (b *FakeBackend) DeleteDNSRecord(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) error {
+	return nil
+}
+
+// DeletePortDNSRecord has nothing to do; fake ports get no DNS record.
+func (b *FakeBackend) DeletePortDNSRecord(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port int) error {
+	return nil
+}
+
+func (b *FakeBackend) WorkspaceStatus(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) (WorkspaceState, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.states[devpodWorkspaceDTO.DevpodWorkspaceId]
+	if !ok {
+		return WorkspaceStateNotFound, nil
+	}
+	return state, nil
+}
+
+func (b *FakeBackend) ListWorkspaces(ctx context.Context) ([]WorkspaceInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	workspaces := make([]WorkspaceInfo, 0, len(b.states))
+	for workspaceID, state := range b.states {
+		workspaces = append(workspaces, WorkspaceInfo{
+			ID:    strconv.FormatUint(workspaceID, 10),
+			State: state,
+		})
+	}
+	return workspaces, nil
+}
+
+// play emits the scripted lines one step at a time and fails the action with
+// a fatal line and the configured failure code when it is listed in
+// FailActions.
+func (b *FakeBackend) play(
+	ctx context.Context,
+	action constants.WorkspaceAction,
+	script []string,
+	onEvent EventHandler,
+) error {
+	for _, line := range script {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.stepDelay):
+		}
+		b.emit(onEvent, "info", line)
+	}
+
+	if code, ok := b.failActions[action]; ok {
+		message := fmt.Sprintf("fake backend configured to fail %s", action)
+		b.emit(onEvent, "fatal", message)
+		return &ActionError{Code: code, Reason: message}
+	}
+	return nil
+}
+
+// emit hands a scripted message to onEvent as devpod would report it, so
+// the job handlers treat fake and real output alike.
+func (b *FakeBackend) emit(onEvent EventHandler, level string, text string) {
+	if onEvent == nil {
+		return
+	}
+	onEvent(NewEvent(level, text))
+}
+
+// DevcontainerPorts reports no forwarded ports; fake workspaces have no
+// devcontainer.json.
+func (b *FakeBackend) DevcontainerPorts(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) ([]int, error) {
+	return nil, nil
+}
+
+// ForwardPort serves a placeholder page standing in for the application on
+// the workspace's port until ctx is done.
+func (b *FakeBackend) ForwardPort(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port int) (*PortForward, error) {
+	workspaceID := devpodWorkspaceDTO.DevpodWorkspaceId
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "fake workspace %d port %d\n", workspaceID, port)
+	})
+	server := &http.Server{Handler: mux}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+		close(done)
+	}()
+	return &PortForward{LocalPort: listener.Addr().(*net.TCPAddr).Port, Done: done}, nil
+}
+
+// openIDE serves a placeholder page standing in for the workspace IDE and
+// returns the local port it listens on.
+func (b *FakeBackend) openIDE(workspaceID uint64) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if listener, ok := b.ides[workspaceID]; ok {
+		return listener.Addr().(*net.TCPAddr).Port, nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "fake workspace %d\n", workspaceID)
+	})
+	go func() {
+		_ = http.Serve(listener, mux)
+	}()
+
+	b.ides[workspaceID] = listener
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (b *FakeBackend) closeIDE(workspaceID uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if listener, ok := b.ides[workspaceID]; ok {
+		_ = listener.Close()
+		delete(b.ides, workspaceID)
+	}
+}
+
+func (b *FakeBackend) setState(workspaceID uint64, state WorkspaceState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.states[workspaceID] = state
+}
@@ -0,0 +1,102 @@
+package devpod
+
+import (
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/utils/aws"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// devcontainerReadTimeout bounds reading a workspace's devcontainer.json.
+	devcontainerReadTimeout = time.Minute
+	// forwardStartPort is the first local port forwarded workspace ports
+	// are given.
+	forwardStartPort = 30000
+	// forwardReadyTimeout is how long a new forward gets to start listening.
+	forwardReadyTimeout = 30 * time.Second
+)
+
+// DevcontainerPorts reads the workspace's devcontainer.json over ssh, from
+// the configured path or the default locations in the workspace folder.
+func (s *DevpodService) DevcontainerPorts(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, devcontainerReadTimeout)
+	defer cancel()
+
+	command := "cat .devcontainer/devcontainer.json 2>/dev/null || cat .devcontainer.json"
+	if devpodWorkspaceDTO.DevcontainerPath != "" {
+		command = "cat " + shellQuote(devpodWorkspaceDTO.DevcontainerPath)
+	}
+
+	output, err := s.runner.Output(ctx, "ssh", fmt.Sprintf("%d", devpodWorkspaceDTO.DevpodWorkspaceId),
+		"--start-services=false", "--command", command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read devcontainer.json: %w", err)
+	}
+	return parseForwardPorts(output)
+}
+
+// ForwardPort tunnels a free local port to the workspace's port over devpod
+// ssh, which only listens on the loopback interface. The port's subdomain
+// gets the same record as the workspace's, pointing at the reverse proxy.
+func (s *DevpodService) ForwardPort(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port int) (*PortForward, error) {
+	if devpodWorkspaceDTO.Fingerprint != "" {
+		if err := aws.CreateARecord(portSubdomain(port, devpodWorkspaceDTO.Fingerprint)); err != nil {
+			log.Printf("[devpod-%d] failed to create DNS record of port %d: %v", devpodWorkspaceDTO.DevpodWorkspaceId, port, err)
+		}
+	}
+
+	localPort, err := getAvailablePort(forwardStartPort)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cmd, err := s.runner.Start(ctx, "ssh", fmt.Sprintf("%d", devpodWorkspaceDTO.DevpodWorkspaceId),
+		"--forward-local", fmt.Sprintf("%d:localhost:%d", localPort, port),
+		"--start-services=false",
+		"--command", "sleep infinity")
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			log.Printf("[devpod-%d] forward of port %d stopped: %v", devpodWorkspaceDTO.DevpodWorkspaceId, port, err)
+		}
+		cancel()
+		close(done)
+	}()
+
+	if err := waitForPort(strconv.Itoa(localPort), forwardReadyTimeout); err != nil {
+		cancel()
+		<-done
+		return nil, fmt.Errorf("failed to forward port %d: %w", port, err)
+	}
+	return &PortForward{LocalPort: localPort, Done: done}, nil
+}
+
+// DeletePortDNSRecord removes the record ForwardPort created for the port.
+func (s *DevpodService) DeletePortDNSRecord(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port int) error {
+	if devpodWorkspaceDTO.Fingerprint == "" {
+		return nil
+	}
+	return aws.DeleteARecord(portSubdomain(port, devpodWorkspaceDTO.Fingerprint))
+}
+
+// portSubdomain is the subdomain the reverse proxy serves a workspace port
+// on.
+func portSubdomain(port int, fingerprint string) string {
+	return fmt.Sprintf("%d-%s", port, fingerprint)
+}
+
+// shellQuote quotes a value for a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,22 @@
+package devpod
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// processWaitDelay bounds how long Wait keeps reading the pipes of a killed
+// devpod process whose children still hold them open.
+const processWaitDelay = 10 * time.Second
+
+// killProcessGroupOnCancel runs cmd in its own process group and kills the
+// whole group when the command's context is cancelled. devpod spawns provider
+// and ssh helpers that would otherwise outlive it and keep the machine busy.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = processWaitDelay
+}
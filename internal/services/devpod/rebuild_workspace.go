@@ -11,12 +11,11 @@ import (
 func (s *DevpodService) RebuildWorkspace(
 	ctx context.Context,
 	devpodWorkspaceDTO dto.DevpodWorkspace,
-	onSuccess func(string, string) error,
-	onFailure func(string, string) error,
+	onEvent EventHandler,
 ) error {
-	if err := s.TerminateWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure); err != nil {
+	if err := s.TerminateWorkspace(ctx, devpodWorkspaceDTO, onEvent); err != nil {
 		return fmt.Errorf("failed to terminate workspace for rebuild: %w", err)
 	}
 
-	return s.StartWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+	return s.StartWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 }
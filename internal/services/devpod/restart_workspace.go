@@ -11,11 +11,10 @@ import (
 func (s *DevpodService) RestartWorkspace(
 	ctx context.Context,
 	devpodWorkspaceDTO dto.DevpodWorkspace,
-	onSuccess func(string, string) error,
-	onFailure func(string, string) error,
+	onEvent EventHandler,
 ) error {
-	if err := s.StopWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure); err != nil {
+	if err := s.StopWorkspace(ctx, devpodWorkspaceDTO, onEvent); err != nil {
 		return fmt.Errorf("failed to stop workspace for restart: %w", err)
 	}
-	return s.StartWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+	return s.StartWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 }
@@ -0,0 +1,228 @@
+package devpod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a devpod command, as recorded in the command metrics.
+const (
+	OutcomeSuccess   = "success"
+	OutcomeFailure   = "failure"
+	OutcomeTimeout   = "timeout"
+	OutcomeCancelled = "cancelled"
+)
+
+var (
+	commandsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "devpod_commands_total",
+			Help: "Total number of devpod commands by subcommand and outcome",
+		},
+		[]string{"action", "outcome"},
+	)
+
+	commandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "devpod_command_duration_seconds",
+			Help:    "Duration of devpod commands",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+		},
+		[]string{"action"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(commandsTotal)
+	prometheus.MustRegister(commandDuration)
+}
+
+type DevpodRunnerConfig struct {
+	// Binary is the devpod executable, a path or a name looked up in PATH.
+	Binary string
+	// Home is exported as DEVPOD_HOME when set, so every command shares the
+	// same providers, contexts and workspace state.
+	Home string
+	// Context is the devpod context commands run in; empty uses devpod's
+	// current context.
+	Context string
+	// Timeouts bounds each subcommand, keyed by its name ("up", "stop", ...).
+	// Subcommands without an entry run until their context is done.
+	Timeouts map[string]time.Duration
+}
+
+// DevpodRunner runs devpod subcommands with the configured binary,
+// environment and timeouts, and records their outcome.
+type DevpodRunner struct {
+	config *DevpodRunnerConfig
+}
+
+func NewDevpodRunner(config *DevpodRunnerConfig) *DevpodRunner {
+	if config.Binary == "" {
+		config.Binary = "devpod"
+	}
+	return &DevpodRunner{config: config}
+}
+
+// Stream runs a devpod subcommand that reports progress, such as up, stop or
+// delete. Its output is requested as JSON and handed to onEvent as it is
+// printed. A non-zero exit is returned as a classified *ActionError.
+func (r *DevpodRunner) Stream(ctx context.Context, workspaceID uint64, onEvent EventHandler, action string, args ...string) error {
+	return r.StreamRedacted(ctx, workspaceID, nil, onEvent, action, args...)
+}
+
+// StreamRedacted is Stream for commands that handle secrets. Every secret
+// is masked in the output before it reaches onEvent or a failure reason.
+func (r *DevpodRunner) StreamRedacted(ctx context.Context, workspaceID uint64, secrets []string, onEvent EventHandler, action string, args ...string) error {
+	ctx, cancel := r.withTimeout(ctx, action)
+	defer cancel()
+
+	cmd := r.command(ctx, action, append(args, "--log-output", "json")...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stderr pipe: %w", err)
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		r.record(ctx, action, start, err)
+		return fmt.Errorf("failed to run devpod %s: %w", action, err)
+	}
+
+	// Both pipes are read at once so devpod can't block writing to either,
+	// and are read to the end before Wait closes them. Once the command is
+	// killed, helpers still holding the pipes get processWaitDelay before
+	// Wait closes them anyway.
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		tail = &outputTail{}
+	)
+	emit := func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		tail.add(event)
+		if onEvent != nil {
+			onEvent(event)
+		}
+	}
+	for _, output := range []io.Reader{stdout, stderr} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			streamEvents(output, workspaceID, secrets, emit)
+		}()
+	}
+	readersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(readersDone)
+	}()
+	select {
+	case <-readersDone:
+	case <-ctx.Done():
+		select {
+		case <-readersDone:
+		case <-time.After(processWaitDelay):
+		}
+	}
+
+	err = cmd.Wait()
+	<-readersDone
+	r.record(ctx, action, start, err)
+
+	if err != nil {
+		actionErr := ClassifyFailure(ctx, tail.events, err)
+		actionErr.ExitCode = exitCode(err)
+		log.Printf("[devpod-%d] devpod %s exited with status %d: %v", workspaceID, action, actionErr.ExitCode, err)
+		return actionErr
+	}
+
+	log.Printf("[devpod-%d] devpod %s exited successfully", workspaceID, action)
+	return nil
+}
+
+// Output runs a devpod subcommand and returns what it printed to stdout, for
+// commands with machine-readable output such as status and list. A non-zero
+// exit is returned as an *exec.ExitError carrying the command's stderr.
+func (r *DevpodRunner) Output(ctx context.Context, action string, args ...string) ([]byte, error) {
+	ctx, cancel := r.withTimeout(ctx, action)
+	defer cancel()
+
+	start := time.Now()
+	output, err := r.command(ctx, action, args...).Output()
+	r.record(ctx, action, start, err)
+	return output, err
+}
+
+// Start starts a devpod subcommand that runs until ctx is done, such as an
+// ssh tunnel, and returns once it started. The caller waits for it.
+func (r *DevpodRunner) Start(ctx context.Context, action string, args ...string) (*exec.Cmd, error) {
+	cmd := r.command(ctx, action, args...)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run devpod %s: %w", action, err)
+	}
+	return cmd, nil
+}
+
+func (r *DevpodRunner) command(ctx context.Context, action string, args ...string) *exec.Cmd {
+	cmdArgs := append([]string{action}, args...)
+	if r.config.Context != "" {
+		cmdArgs = append(cmdArgs, "--context", r.config.Context)
+	}
+
+	cmd := exec.CommandContext(ctx, r.config.Binary, cmdArgs...)
+	cmd.Env = os.Environ()
+	if r.config.Home != "" {
+		cmd.Env = append(cmd.Env, "DEVPOD_HOME="+r.config.Home)
+	}
+	killProcessGroupOnCancel(cmd)
+	return cmd
+}
+
+func (r *DevpodRunner) withTimeout(ctx context.Context, action string) (context.Context, context.CancelFunc) {
+	if timeout := r.config.Timeouts[action]; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (r *DevpodRunner) record(ctx context.Context, action string, start time.Time, err error) {
+	outcome := OutcomeSuccess
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		outcome = OutcomeTimeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		outcome = OutcomeCancelled
+	default:
+		outcome = OutcomeFailure
+	}
+
+	commandsTotal.WithLabelValues(action, outcome).Inc()
+	commandDuration.WithLabelValues(action).Observe(time.Since(start).Seconds())
+}
+
+// exitCode is the exit status of a finished command, or -1 when it did not
+// exit on its own.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
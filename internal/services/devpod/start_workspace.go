@@ -1,24 +1,28 @@
 package devpod
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"log"
-	"os/exec"
+	"os"
+	"path"
 	"strings"
 
 	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/utils/aws"
 )
 
 // StartWorkspace is your existing CreateWorkspace, just renamed.
 func (s *DevpodService) StartWorkspace(
 	ctx context.Context,
 	devpodWorkspaceDTO dto.DevpodWorkspace,
-	onSuccess func(string, string) error,
-	onFailure func(string, string) error,
+	onEvent EventHandler,
 ) error {
+	if err := aws.CreateARecord(devpodWorkspaceDTO.Fingerprint); err != nil {
+		log.Printf("[devpod-%d] [%s] %s", devpodWorkspaceDTO.DevpodWorkspaceId, "LOG", fmt.Sprintf("failed to create DNS record: %v", err))
+	}
+
 	repoURL := devpodWorkspaceDTO.RepositoryUrl
 
 	if strings.HasPrefix(repoURL, "https://") {
@@ -27,90 +31,105 @@ func (s *DevpodService) StartWorkspace(
 		repoURL = fmt.Sprintf("git:%s@%s", devpodWorkspaceDTO.AccessToken, repoURL)
 	}
 
-	cmd := exec.CommandContext(ctx,
-		"./binaries/devpod-cli-linux-amd64", "up",
-		repoURL,
+	args := []string{
+		gitSource(repoURL, devpodWorkspaceDTO),
 		"--id", fmt.Sprintf("%d", devpodWorkspaceDTO.DevpodWorkspaceId),
-		"--ide", "openvscode",
-		"--provider-option", fmt.Sprintf("AWS_INSTANCE_TYPE=%s", devpodWorkspaceDTO.AWSInstanceType),
-	)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
+		"--provider", s.provider,
+		"--ide", devpodWorkspaceDTO.DevpodWorkspaceIde,
 	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
+
+	if s.provider == ProviderAWS && devpodWorkspaceDTO.AWSInstanceType != "" {
+		args = append(args, "--provider-option", fmt.Sprintf("AWS_INSTANCE_TYPE=%s", devpodWorkspaceDTO.AWSInstanceType))
 	}
 
-	if ctx.Err() != nil {
-		log.Printf("[devpod-%d] [%s] %s", devpodWorkspaceDTO.DevpodWorkspaceId, "LOG", fmt.Sprintf("context already canceled: %v", ctx.Err()))
+	// The reverse proxy never sees the traffic of SSH-only workspaces, so the
+	// idle service can't tell when they are idle; the provider's inactivity
+	// timeout, which watches SSH connections, stops them instead.
+	if s.provider == ProviderAWS && s.inactivityTimeout != "" &&
+		enums.IDE(devpodWorkspaceDTO.DevpodWorkspaceIde).Kind() != enums.IDEKindBrowser {
+		args = append(args, "--provider-option", "INACTIVITY_TIMEOUT="+s.inactivityTimeout)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start devpod: %w", err)
+	if devpodWorkspaceDTO.DevcontainerPath != "" {
+		args = append(args, "--devcontainer-path", devpodWorkspaceDTO.DevcontainerPath)
 	}
 
-	doneChan := make(chan struct{})
-	fatalOccurred := new(bool)
+	if devpodWorkspaceDTO.DotfilesRepository != "" {
+		args = append(args, "--dotfiles", devpodWorkspaceDTO.DotfilesRepository)
+		if devpodWorkspaceDTO.DotfilesScript != "" {
+			args = append(args, "--dotfiles-script", devpodWorkspaceDTO.DotfilesScript)
+		}
+	}
 
-	go func() {
-		scanner := bufio.NewScanner(io.MultiReader(stdout, stderr))
-		handleStartWorkspaceLogs(scanner, devpodWorkspaceDTO.DevpodWorkspaceId, devpodWorkspaceDTO.UserId, onSuccess, onFailure, doneChan, fatalOccurred)
-	}()
+	// Only browser IDEs are opened on the worker, which exposes them to the
+	// user.
+	if enums.IDE(devpodWorkspaceDTO.DevpodWorkspaceIde).Kind() != enums.IDEKindBrowser {
+		args = append(args, "--open-ide=false")
+	}
 
-	err = cmd.Wait()
-	<-doneChan
+	if len(devpodWorkspaceDTO.Env) > 0 {
+		envFile, err := writeEnvFile(devpodWorkspaceDTO.Env)
+		if err != nil {
+			return fmt.Errorf("failed to write workspace env file: %w", err)
+		}
+		defer os.Remove(envFile)
+		args = append(args, "--workspace-env-file", envFile)
+	}
 
-	if err != nil {
-		if *fatalOccurred {
-			log.Printf("[devpod-%d] [%s] %s", devpodWorkspaceDTO.DevpodWorkspaceId, "LOG", fmt.Sprintf("devpod exited with fatal, treating as success: %v", err))
-			return nil
+	secrets := []string{devpodWorkspaceDTO.AccessToken}
+	for _, envVar := range devpodWorkspaceDTO.Env {
+		if envVar.IsSecret {
+			secrets = append(secrets, envVar.Value)
 		}
-		log.Printf("[devpod-%d] [%s] %s", devpodWorkspaceDTO.DevpodWorkspaceId, "LOG", fmt.Sprintf("devpod exited with error: %v", err))
-		return fmt.Errorf("devpod process exited with error: %w", err)
 	}
 
-	log.Printf("[devpod-%d] [%s] %s", devpodWorkspaceDTO.DevpodWorkspaceId, "LOG", "devpod exited successfully")
-	return nil
+	return s.runner.StreamRedacted(ctx, devpodWorkspaceDTO.DevpodWorkspaceId, secrets, onEvent, "up", args...)
+}
+
+// gitSource is devpod's source string of the workspace's repository: the
+// repository URL followed by the ref to check out and the monorepo subfolder
+// to open, e.g. "https://github.com/org/repo@main@subpath:/services/api".
+func gitSource(repoURL string, devpodWorkspaceDTO dto.DevpodWorkspace) string {
+	source := repoURL
+
+	switch enums.GitRefType(devpodWorkspaceDTO.GitRefType) {
+	case enums.GitRefTypeBranch, enums.GitRefTypeTag:
+		source += "@" + devpodWorkspaceDTO.GitRef
+	case enums.GitRefTypeCommit:
+		source += "@sha256:" + devpodWorkspaceDTO.GitRef
+	case enums.GitRefTypePullRequest:
+		source += fmt.Sprintf("@pull/%s/head", devpodWorkspaceDTO.GitRef)
+	}
+
+	if devpodWorkspaceDTO.Subfolder != "" {
+		source += "@subpath:/" + strings.TrimPrefix(path.Clean(devpodWorkspaceDTO.Subfolder), "/")
+	}
+	return source
 }
 
-func handleStartWorkspaceLogs(
-	scanner *bufio.Scanner,
-	workspaceID, userID uint64,
-	onSuccess, onFailure func(string, string) error,
-	doneChan chan struct{},
-	fatalOccurred *bool,
-) {
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		switch {
-		case isDoneLine(line):
-			processLogWithLevel(DoneLog, workspaceID, userID, line)
-			handleCallback(onSuccess, workspaceID, userID, line, "DONE")
-
-		case isFatalLine(line):
-			*fatalOccurred = true
-			processLogWithLevel(FatalLog, workspaceID, userID, line)
-			handleCallback(onFailure, workspaceID, userID, line, "FATAL")
-			close(doneChan)
-			return
-
-		case isInfoLine(line):
-			processLogWithLevel(InfoLog, workspaceID, userID, line)
-			handleCallback(onSuccess, workspaceID, userID, line, "INFO")
-
-		default:
-			processLog(workspaceID, userID, "LOG", true, line)
-			handleCallback(onSuccess, workspaceID, userID, line, "INFO")
+// writeEnvFile writes the workspace environment to a file only the worker
+// can read. Passing it as a file keeps secrets off the devpod command line,
+// where any process on the host could read them. The file holds one
+// KEY=VALUE line per variable, so a value spanning lines is refused rather
+// than let it add variables of its own.
+func writeEnvFile(env []dto.WorkspaceEnvVar) (string, error) {
+	for _, envVar := range env {
+		if strings.ContainsAny(envVar.Key, "=\r\n") || strings.ContainsAny(envVar.Value, "\r\n") {
+			return "", fmt.Errorf("environment variable %q must be a single line", envVar.Key)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		processLog(workspaceID, userID, "LOG", true, "scanner error: %v", err)
+	file, err := os.CreateTemp("", "devpod-env-*")
+	if err != nil {
+		return "", err
 	}
+	defer file.Close()
 
-	close(doneChan)
+	for _, envVar := range env {
+		if _, err := fmt.Fprintf(file, "%s=%s\n", envVar.Key, envVar.Value); err != nil {
+			os.Remove(file.Name())
+			return "", err
+		}
+	}
+	return file.Name(), nil
 }
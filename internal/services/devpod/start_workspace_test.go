@@ -0,0 +1,74 @@
+package devpod
+
+import (
+	"os"
+	"testing"
+
+	"clusterix-code/internal/data/dto"
+)
+
+func TestWriteEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     []dto.WorkspaceEnvVar
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "one line per variable",
+			env: []dto.WorkspaceEnvVar{
+				{Key: "APP_ENV", Value: "dev"},
+				{Key: "API_TOKEN", Value: "s3cr=t with spaces", IsSecret: true},
+				{Key: "EMPTY", Value: ""},
+			},
+			want: "APP_ENV=dev\nAPI_TOKEN=s3cr=t with spaces\nEMPTY=\n",
+		},
+		{
+			name:    "value with a newline",
+			env:     []dto.WorkspaceEnvVar{{Key: "A", Value: "x\nOTHER=y"}},
+			wantErr: true,
+		},
+		{
+			name:    "value with a carriage return",
+			env:     []dto.WorkspaceEnvVar{{Key: "A", Value: "x\rOTHER=y"}},
+			wantErr: true,
+		},
+		{
+			name:    "key with an equals sign",
+			env:     []dto.WorkspaceEnvVar{{Key: "A=B", Value: "x"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := writeEnvFile(tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeEnvFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if path != "" {
+					t.Errorf("path = %q, want none", path)
+				}
+				return
+			}
+			defer os.Remove(path)
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("env file = %q, want %q", got, tt.want)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode&0o077 != 0 {
+				t.Errorf("env file mode = %v, want it private to the worker", mode)
+			}
+		})
+	}
+}
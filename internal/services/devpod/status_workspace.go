@@ -0,0 +1,67 @@
+package devpod
+
+import (
+	"clusterix-code/internal/data/dto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+type devpodStatus struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+}
+
+type devpodListEntry struct {
+	ID string `json:"id"`
+}
+
+func (s *DevpodService) WorkspaceStatus(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) (WorkspaceState, error) {
+	return s.statusByID(ctx, fmt.Sprintf("%d", devpodWorkspaceDTO.DevpodWorkspaceId))
+}
+
+// ListWorkspaces reads devpod's JSON listing and resolves the state of every
+// workspace in it. A workspace whose status can't be read is listed with
+// WorkspaceStateUnknown, so one failing workspace doesn't hide the others.
+func (s *DevpodService) ListWorkspaces(ctx context.Context) ([]WorkspaceInfo, error) {
+	output, err := s.runner.Output(ctx, "list", "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("devpod list failed: %w", err)
+	}
+
+	var entries []devpodListEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse devpod list: %w", err)
+	}
+
+	workspaces := make([]WorkspaceInfo, 0, len(entries))
+	for _, entry := range entries {
+		state, err := s.statusByID(ctx, entry.ID)
+		if err != nil {
+			log.Printf("[devpod] failed to read the status of workspace %s: %v", entry.ID, err)
+			state = WorkspaceStateUnknown
+		}
+		workspaces = append(workspaces, WorkspaceInfo{ID: entry.ID, State: state})
+	}
+	return workspaces, nil
+}
+
+func (s *DevpodService) statusByID(ctx context.Context, id string) (WorkspaceState, error) {
+	output, err := s.runner.Output(ctx, "status", id, "--output", "json")
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && strings.Contains(string(exitErr.Stderr), "doesn't exist") {
+			return WorkspaceStateNotFound, nil
+		}
+		return "", fmt.Errorf("devpod status failed for %s: %w", id, err)
+	}
+
+	var status devpodStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return "", fmt.Errorf("failed to parse devpod status: %w", err)
+	}
+
+	return WorkspaceState(status.State), nil
+}
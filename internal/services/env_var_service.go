@@ -0,0 +1,230 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/utils/crypto"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var envVarKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type EnvVarServiceConfig struct {
+	Repositories *repositories.Repositories
+	// EncryptionKey is the base64 encoded 32 byte key secrets are encrypted
+	// with. Without it secrets can't be stored.
+	EncryptionKey string
+}
+
+type EnvVarService struct {
+	envVarRepository *repositories.EnvVarRepository
+	cipher           *crypto.Cipher
+}
+
+func NewEnvVarService(config *EnvVarServiceConfig) *EnvVarService {
+	var cipher *crypto.Cipher
+	if config.EncryptionKey != "" {
+		var err error
+		if cipher, err = crypto.NewCipher(config.EncryptionKey); err != nil {
+			log.Printf("⚠️ Secret environment variables are disabled: %v", err)
+		}
+	}
+
+	return &EnvVarService{
+		envVarRepository: config.Repositories.EnvVar,
+		cipher:           cipher,
+	}
+}
+
+func (s *EnvVarService) GetEnvVars(ctx context.Context, scope enums.EnvVarScope, scopeID uint64) ([]dto.EnvVarDTO, error) {
+	envVars, err := s.envVarRepository.GetByScope(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToEnvVarDTOs(envVars), nil
+}
+
+func (s *EnvVarService) CreateEnvVar(
+	ctx context.Context,
+	scope enums.EnvVarScope,
+	scopeID uint64,
+	organizationID uint32,
+	userID uint64,
+	req requests.CreateEnvVarRequest,
+) (dto.EnvVarDTO, error) {
+	if err := validateEnvVar(req.Key, req.Value); err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+
+	if _, err := s.envVarRepository.GetByKey(ctx, scope, scopeID, req.Key); err == nil {
+		return dto.EnvVarDTO{}, internalErrors.NewConflictError("ENV_VAR_EXISTS",
+			fmt.Sprintf("Environment variable %s already exists", req.Key))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.EnvVarDTO{}, err
+	}
+
+	value, err := s.storedValue(req.Value, req.IsSecret)
+	if err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+
+	envVar := models.EnvVar{
+		OrganizationID: organizationID,
+		Scope:          scope,
+		ScopeID:        scopeID,
+		Key:            req.Key,
+		Value:          value,
+		IsSecret:       req.IsSecret,
+		CreatedByID:    userID,
+	}
+	if err := s.envVarRepository.Create(ctx, &envVar); err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+	return dto.ToEnvVarDTO(envVar), nil
+}
+
+func (s *EnvVarService) UpdateEnvVar(
+	ctx context.Context,
+	scope enums.EnvVarScope,
+	scopeID uint64,
+	envVarID uint64,
+	req requests.UpdateEnvVarRequest,
+) (dto.EnvVarDTO, error) {
+	envVar, err := s.getEnvVar(ctx, scope, scopeID, envVarID)
+	if err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+
+	isSecret := envVar.IsSecret
+	if req.IsSecret != nil {
+		isSecret = *req.IsSecret
+	}
+
+	var value string
+	switch {
+	case req.Value != nil:
+		value = *req.Value
+	case envVar.IsSecret && !isSecret:
+		// A secret is never revealed, not even by turning it into a plain
+		// variable.
+		return dto.EnvVarDTO{}, internalErrors.NewValidationError("A value is required to turn a secret into a plain variable",
+			map[string][]string{"value": {"required when is_secret is turned off"}})
+	default:
+		if value, err = s.plainValue(*envVar); err != nil {
+			return dto.EnvVarDTO{}, err
+		}
+	}
+
+	if err := validateEnvVar(envVar.Key, value); err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+	if envVar.Value, err = s.storedValue(value, isSecret); err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+	envVar.IsSecret = isSecret
+
+	if err := s.envVarRepository.Update(ctx, envVar); err != nil {
+		return dto.EnvVarDTO{}, err
+	}
+	return dto.ToEnvVarDTO(*envVar), nil
+}
+
+func (s *EnvVarService) DeleteEnvVar(ctx context.Context, scope enums.EnvVarScope, scopeID uint64, envVarID uint64) error {
+	if _, err := s.getEnvVar(ctx, scope, scopeID, envVarID); err != nil {
+		return err
+	}
+	return s.envVarRepository.Delete(ctx, envVarID)
+}
+
+// ResolveWorkspaceEnv merges the variables a workspace inherits from its
+// organization, repository and template with its own, the narrower scope
+// winning on a key clash, and decrypts the secrets among them.
+func (s *EnvVarService) ResolveWorkspaceEnv(ctx context.Context, workspace *models.Workspace) ([]dto.WorkspaceEnvVar, error) {
+	var templateID uint64
+	if workspace.TemplateID != nil {
+		templateID = *workspace.TemplateID
+	}
+
+	envVars, err := s.envVarRepository.GetForWorkspace(ctx, workspace.OrganizationID, workspace.RepositoryID, templateID, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]dto.WorkspaceEnvVar)
+	var keys []string
+	for _, scope := range enums.EnvVarScopePrecedence {
+		for _, envVar := range envVars {
+			if envVar.Scope != scope {
+				continue
+			}
+			value, err := s.plainValue(envVar)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve environment variable %s: %w", envVar.Key, err)
+			}
+			if _, ok := resolved[envVar.Key]; !ok {
+				keys = append(keys, envVar.Key)
+			}
+			resolved[envVar.Key] = dto.WorkspaceEnvVar{Key: envVar.Key, Value: value, IsSecret: envVar.IsSecret}
+		}
+	}
+
+	env := make([]dto.WorkspaceEnvVar, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, resolved[key])
+	}
+	return env, nil
+}
+
+func (s *EnvVarService) getEnvVar(ctx context.Context, scope enums.EnvVarScope, scopeID uint64, envVarID uint64) (*models.EnvVar, error) {
+	envVar, err := s.envVarRepository.GetByID(ctx, scope, scopeID, envVarID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, internalErrors.NewNotFoundError("environment variable")
+	}
+	return envVar, err
+}
+
+func (s *EnvVarService) storedValue(value string, isSecret bool) (string, error) {
+	if !isSecret {
+		return value, nil
+	}
+	if s.cipher == nil {
+		return "", internalErrors.NewError(internalErrors.ErrorTypeBadRequest, "SECRETS_NOT_CONFIGURED",
+			"Secrets can't be stored because no encryption key is configured", nil)
+	}
+	return s.cipher.Encrypt(value)
+}
+
+func (s *EnvVarService) plainValue(envVar models.EnvVar) (string, error) {
+	if !envVar.IsSecret {
+		return envVar.Value, nil
+	}
+	if s.cipher == nil {
+		return "", errors.New("no encryption key is configured")
+	}
+	return s.cipher.Decrypt(envVar.Value)
+}
+
+// validateEnvVar rejects keys that aren't valid shell variable names and
+// values devpod's env file can't carry.
+func validateEnvVar(key, value string) error {
+	if !envVarKeyPattern.MatchString(key) {
+		return internalErrors.NewValidationError("Invalid environment variable",
+			map[string][]string{"key": {"must start with a letter or underscore and contain only letters, digits and underscores"}})
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return internalErrors.NewValidationError("Invalid environment variable",
+			map[string][]string{"value": {"must be a single line"}})
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestValidateEnvVar(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{key: "APP_ENV", value: "dev"},
+		{key: "_PRIVATE", value: "a=b c"},
+		{key: "EMPTY", value: ""},
+		{key: "1ST", value: "x", wantErr: true},
+		{key: "WITH-DASH", value: "x", wantErr: true},
+		{key: "A=B", value: "x", wantErr: true},
+		{key: "MULTI", value: "x\nOTHER=y", wantErr: true},
+		{key: "CR", value: "x\rOTHER=y", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateEnvVar(tt.key, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("validateEnvVar(%q, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
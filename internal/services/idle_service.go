@@ -0,0 +1,171 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/config"
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// activityWriteInterval bounds how often the activity of one workspace is
+// written; traffic in between is already covered.
+const activityWriteInterval = 30 * time.Second
+
+type IdleServiceConfig struct {
+	Repositories *repositories.Repositories
+	Workspace    *WorkspaceService
+	Publisher    *PublisherService
+	Idle         config.IdleConfig
+}
+
+// IdleService tracks the activity the reverse proxy sees and stops browser
+// IDE workspaces nobody used for their idle timeout, warning the user
+// first. SSH-only workspaces are reached past the proxy and are left to
+// the provider's inactivity timeout.
+type IdleService struct {
+	workspaceRepository           *repositories.WorkspaceRepository
+	organizationSettingRepository *repositories.OrganizationSettingRepository
+	workspaceService              *WorkspaceService
+	publisherService              *PublisherService
+	timeout                       time.Duration
+	warningBefore                 time.Duration
+
+	mu           sync.Mutex
+	lastRecorded map[string]time.Time
+}
+
+func NewIdleService(config *IdleServiceConfig) *IdleService {
+	return &IdleService{
+		workspaceRepository:           config.Repositories.Workspace,
+		organizationSettingRepository: config.Repositories.OrganizationSetting,
+		workspaceService:              config.Workspace,
+		publisherService:              config.Publisher,
+		timeout:                       config.Idle.Timeout,
+		warningBefore:                 config.Idle.WarningBefore,
+		lastRecorded:                  make(map[string]time.Time),
+	}
+}
+
+// RecordActivity notes traffic to the workspace with the fingerprint.
+func (s *IdleService) RecordActivity(ctx context.Context, fingerprint string) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	if now.Sub(s.lastRecorded[fingerprint]) < activityWriteInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastRecorded[fingerprint] = now
+	s.mu.Unlock()
+
+	return s.workspaceRepository.RecordActivity(ctx, fingerprint, now)
+}
+
+// SetIdleTimeout overrides the organization's idle timeout for the
+// workspace; nil restores it.
+func (s *IdleService) SetIdleTimeout(ctx context.Context, workspaceID uint64, minutes *int) error {
+	return s.workspaceRepository.UpdateIdleTimeout(ctx, workspaceID, minutes)
+}
+
+// StopIdleWorkspaces warns the users of workspaces about to reach their
+// idle timeout and stops those that reached it after being warned.
+func (s *IdleService) StopIdleWorkspaces(ctx context.Context) error {
+	workspaces, err := s.workspaceRepository.GetIdleCandidates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load running workspaces: %w", err)
+	}
+	if len(workspaces) == 0 {
+		return nil
+	}
+
+	var organizationIDs []uint32
+	for _, workspace := range workspaces {
+		organizationIDs = append(organizationIDs, workspace.OrganizationID)
+	}
+	settings, err := s.organizationSettingRepository.GetByOrganizationIDs(ctx, organizationIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load organization settings: %w", err)
+	}
+
+	now := time.Now()
+	for _, workspace := range workspaces {
+		if enums.IDE(workspace.Ide).OrDefault().Kind() != enums.IDEKindBrowser {
+			continue
+		}
+		timeout := s.idleTimeout(workspace, settings)
+		if timeout <= 0 {
+			continue
+		}
+
+		// A warning from before the workspace last started or was used
+		// doesn't count.
+		warned := workspace.IdleWarnedAt != nil && !workspace.IdleWarnedAt.Before(workspace.IdleSince)
+		idle := now.Sub(workspace.IdleSince)
+		switch {
+		case !warned && idle >= timeout-s.warningBefore:
+			s.warn(ctx, workspace, workspace.IdleSince.Add(timeout), now)
+		case warned && idle >= timeout:
+			s.stop(ctx, workspace, timeout)
+		}
+	}
+	return nil
+}
+
+// idleTimeout is the workspace's own timeout, else its organization's,
+// else the platform default.
+func (s *IdleService) idleTimeout(workspace repositories.IdleWorkspace, settings map[uint32]models.OrganizationSetting) time.Duration {
+	if workspace.IdleTimeoutMinutes != nil {
+		return time.Duration(*workspace.IdleTimeoutMinutes) * time.Minute
+	}
+	if setting, ok := settings[workspace.OrganizationID]; ok && setting.IdleTimeoutMinutes != nil {
+		return time.Duration(*setting.IdleTimeoutMinutes) * time.Minute
+	}
+	return s.timeout
+}
+
+func (s *IdleService) warn(ctx context.Context, workspace repositories.IdleWorkspace, stopsAt, now time.Time) {
+	if err := s.workspaceRepository.MarkIdleWarned(ctx, workspace.ID, now); err != nil {
+		log.Printf("[idle] failed to mark workspace %d as warned: %v", workspace.ID, err)
+		return
+	}
+	if stopsAt.Before(now) {
+		stopsAt = now
+	}
+
+	body := dto.Message{
+		EventType: constants.WorkspaceIdleWarning,
+		Channel:   fmt.Sprintf("workspace_%d_status", workspace.ID),
+		Data: map[string]interface{}{
+			"idle_since": workspace.IdleSince,
+			"stops_at":   stopsAt,
+		},
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to marshal message: %v", err)
+		return
+	}
+	s.publisherService.Publish(constants.CLUSTERIX_CODE_V1_EXCHANGE, constants.WORKSPACE_LOG_HANDLER_QUEUE, payload)
+}
+
+func (s *IdleService) stop(ctx context.Context, workspace repositories.IdleWorkspace, timeout time.Duration) {
+	if _, err := s.workspaceService.StopWorkspace(ctx, requests.WorkspaceActionRequest{
+		ID:     workspace.ID,
+		UserID: workspace.UserID,
+		Actor:  enums.WorkspaceActorIdle,
+		Reason: fmt.Sprintf("Stopping workspace after %s without activity", timeout),
+	}); err != nil {
+		log.Printf("[idle] failed to stop idle workspace %d: %v", workspace.ID, err)
+		return
+	}
+	log.Printf("[idle] stopping workspace %d after %s without activity", workspace.ID, timeout)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"clusterix-code/internal/api/requests"
 	"clusterix-code/internal/data/dto"
 	"clusterix-code/internal/data/models"
 	"clusterix-code/internal/data/repositories"
@@ -52,6 +53,10 @@ func (s *MachineConfigService) Import(ctx context.Context) error {
 			}
 		} else {
 			config.ID = existing.ID
+			// Prices are set through the API; keep them unless the file has one.
+			if config.HourlyPrice == 0 {
+				config.HourlyPrice = existing.HourlyPrice
+			}
 			if err := s.machineConfigRepository.UpdateConfig(ctx, &config); err != nil {
 				return fmt.Errorf("failed to update config: %w", err)
 			}
@@ -72,7 +77,7 @@ func (s *MachineConfigService) GetMachineConfigs(ctx context.Context, search str
 		pagination, err = s.machineConfigRepository.Search(ctx, search, page, limit)
 	}
 	if err != nil {
-		pagination = pagination
+		return pagination, err
 	}
 
 	machines := pagination.Data.([]models.MachineConfig)
@@ -88,3 +93,17 @@ func (s *MachineConfigService) GetMachineConfig(ctx context.Context, userId uint
 	}
 	return dto.ToMachineConfigDTO(*machineConfig), nil
 }
+
+// SetHourlyPrice sets what an hour of running the machine costs.
+func (s *MachineConfigService) SetHourlyPrice(ctx context.Context, machineConfigId uint64, req requests.SetMachineConfigPriceRequest) (dto.MachineConfigDTO, error) {
+	machineConfig, err := s.machineConfigRepository.GetByID(ctx, machineConfigId)
+	if err != nil {
+		return dto.MachineConfigDTO{}, err
+	}
+
+	machineConfig.HourlyPrice = *req.HourlyPrice
+	if err := s.machineConfigRepository.UpdateConfig(ctx, machineConfig); err != nil {
+		return dto.MachineConfigDTO{}, err
+	}
+	return dto.ToMachineConfigDTO(*machineConfig), nil
+}
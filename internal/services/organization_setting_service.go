@@ -0,0 +1,44 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"context"
+)
+
+type OrganizationSettingServiceConfig struct {
+	Repositories *repositories.Repositories
+}
+
+type OrganizationSettingService struct {
+	organizationSettingRepository *repositories.OrganizationSettingRepository
+}
+
+func NewOrganizationSettingService(config *OrganizationSettingServiceConfig) *OrganizationSettingService {
+	return &OrganizationSettingService{
+		organizationSettingRepository: config.Repositories.OrganizationSetting,
+	}
+}
+
+func (s *OrganizationSettingService) GetSettings(ctx context.Context, organizationID uint32) (dto.OrganizationSettingsDTO, error) {
+	setting, err := s.organizationSettingRepository.Get(ctx, organizationID)
+	if err != nil {
+		return dto.OrganizationSettingsDTO{}, err
+	}
+	return dto.ToOrganizationSettingsDTO(organizationID, setting), nil
+}
+
+func (s *OrganizationSettingService) UpdateSettings(ctx context.Context, organizationID uint32, req requests.UpdateOrganizationSettingsRequest) (dto.OrganizationSettingsDTO, error) {
+	setting := models.OrganizationSetting{
+		OrganizationID:       organizationID,
+		IdleTimeoutMinutes:   req.IdleTimeoutMinutes,
+		StoppedRetentionDays: req.StoppedRetentionDays,
+		FailedRetentionDays:  req.FailedRetentionDays,
+	}
+	if err := s.organizationSettingRepository.Save(ctx, &setting); err != nil {
+		return dto.OrganizationSettingsDTO{}, err
+	}
+	return dto.ToOrganizationSettingsDTO(organizationID, &setting), nil
+}
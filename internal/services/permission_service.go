@@ -9,29 +9,43 @@ import (
 )
 
 type PermissionService struct {
-	superRoles map[string]struct{}
+	superRoles         map[string]struct{}
+	platformAdminRoles map[string]struct{}
 }
 
 func NewPermissionService() *PermissionService {
-	rolesEnv := os.Getenv("SUPER_ROLES")
+	return &PermissionService{
+		superRoles:         parseRoles(os.Getenv("SUPER_ROLES")),
+		platformAdminRoles: parseRoles(os.Getenv("PLATFORM_ADMIN_ROLES")),
+	}
+}
+
+func parseRoles(rolesEnv string) map[string]struct{} {
 	rolesEnv = strings.Trim(rolesEnv, "[]")
 	roles := strings.Split(rolesEnv, ",")
-	superRoles := make(map[string]struct{})
+	parsed := make(map[string]struct{})
 	for _, r := range roles {
 		if r != "" {
-			superRoles[r] = struct{}{}
+			parsed[r] = struct{}{}
 		}
 	}
-
-	return &PermissionService{
-		superRoles: superRoles,
-	}
+	return parsed
 }
 
 func (p *PermissionService) IsAdmin(userRoles []string) bool {
+	return hasRole(userRoles, p.superRoles)
+}
+
+// IsPlatformAdmin reports whether the user may change what every
+// organization shares, unlike an admin who manages their own organization.
+func (p *PermissionService) IsPlatformAdmin(userRoles []string) bool {
+	return hasRole(userRoles, p.platformAdminRoles)
+}
+
+func hasRole(userRoles []string, roles map[string]struct{}) bool {
 	for _, role := range userRoles {
 		role = strings.TrimSpace(role)
-		if _, ok := p.superRoles[role]; ok {
+		if _, ok := roles[role]; ok {
 			return true
 		}
 	}
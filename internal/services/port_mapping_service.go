@@ -24,7 +24,7 @@ type Mapping struct {
 
 var (
 	mu        sync.Mutex
-	mappings  = make(map[string]*Mapping) // workspaceID -> Mapping
+	mappings  = make(map[string]*Mapping) // workspaceID, or workspaceID:port:generation for forwarded ports -> Mapping
 	usedPorts = make(map[int]bool)
 )
 
@@ -85,3 +85,23 @@ func MapWorkspace(workspaceID string, internalPort int) (*Mapping, error) {
 
 	return mapping, nil
 }
+
+// UnmapWorkspace stops the mapping made under the key, freeing its external
+// port. Unknown keys are ignored.
+func UnmapWorkspace(workspaceID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	mapping, ok := mappings[workspaceID]
+	if !ok {
+		return
+	}
+	if mapping.Cmd.Process != nil {
+		_ = mapping.Cmd.Process.Kill()
+		go func() { _ = mapping.Cmd.Wait() }()
+	}
+	delete(mappings, workspaceID)
+	delete(usedPorts, mapping.ExternalPort)
+
+	log.Printf("Unmapped workspace %s from port %d\n", workspaceID, mapping.ExternalPort)
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type QuotaServiceConfig struct {
+	Repositories *repositories.Repositories
+}
+
+type QuotaService struct {
+	quotaRepository     *repositories.QuotaRepository
+	workspaceRepository *repositories.WorkspaceRepository
+	userRepository      *repositories.UserRepository
+}
+
+func NewQuotaService(config *QuotaServiceConfig) *QuotaService {
+	return &QuotaService{
+		quotaRepository:     config.Repositories.Quota,
+		workspaceRepository: config.Repositories.Workspace,
+		userRepository:      config.Repositories.User,
+	}
+}
+
+// GetQuotas returns the organization's quota, if any, followed by its
+// users' quotas, each with its current usage.
+func (s *QuotaService) GetQuotas(ctx context.Context, organizationID uint32) ([]dto.QuotaDTO, error) {
+	quotas, err := s.quotaRepository.GetByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]dto.QuotaDTO, len(quotas))
+	for i, quota := range quotas {
+		if dtos[i], err = s.toQuotaDTO(ctx, quota); err != nil {
+			return nil, err
+		}
+	}
+	return dtos, nil
+}
+
+// GetQuota returns the quota of the organization, or of one of its users
+// when userID is set.
+func (s *QuotaService) GetQuota(ctx context.Context, organizationID uint32, userID *uint64) (dto.QuotaDTO, error) {
+	quota, err := s.quotaRepository.Get(ctx, organizationID, userID)
+	if err != nil {
+		return dto.QuotaDTO{}, err
+	}
+	if quota == nil {
+		return dto.QuotaDTO{}, internalErrors.NewNotFoundError("quota")
+	}
+	return s.toQuotaDTO(ctx, *quota)
+}
+
+// SetQuota creates or replaces the quota of the organization, or of one of
+// its users when userID is set.
+func (s *QuotaService) SetQuota(ctx context.Context, organizationID uint32, userID *uint64, req requests.SetQuotaRequest) (dto.QuotaDTO, error) {
+	if userID != nil {
+		user, err := s.userRepository.GetByID(ctx, *userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.OrganizationID != uint64(organizationID)) {
+			return dto.QuotaDTO{}, internalErrors.NewNotFoundError("user")
+		}
+		if err != nil {
+			return dto.QuotaDTO{}, err
+		}
+	}
+
+	quota, err := s.quotaRepository.Get(ctx, organizationID, userID)
+	if err != nil {
+		return dto.QuotaDTO{}, err
+	}
+	if quota == nil {
+		quota = &models.Quota{OrganizationID: organizationID, UserID: userID}
+	}
+	quota.MaxWorkspaces = req.MaxWorkspaces
+	quota.MaxRunningWorkspaces = req.MaxRunningWorkspaces
+	quota.MaxCPUCores = req.MaxCPUCores
+	quota.MaxMemoryGB = req.MaxMemoryGB
+	quota.AllowedCategories = req.AllowedCategories
+
+	if quota.ID == 0 {
+		err = s.quotaRepository.Create(ctx, quota)
+	} else {
+		err = s.quotaRepository.Update(ctx, quota)
+	}
+	if err != nil {
+		return dto.QuotaDTO{}, err
+	}
+	return s.GetQuota(ctx, organizationID, userID)
+}
+
+// DeleteQuota lifts the quota of the organization, or of one of its users
+// when userID is set.
+func (s *QuotaService) DeleteQuota(ctx context.Context, organizationID uint32, userID *uint64) error {
+	quota, err := s.quotaRepository.Get(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return internalErrors.NewNotFoundError("quota")
+	}
+	return s.quotaRepository.Delete(ctx, quota.ID)
+}
+
+// CheckWorkspaceStart makes sure running a workspace on the machine stays
+// within the quotas of the user and of the organization. The workspace
+// counts as one more active workspace; pass 0 for one being created. It
+// only reads, so it tells early whether a start can pass; the start itself
+// is checked by ReserveWorkspaceStart.
+func (s *QuotaService) CheckWorkspaceStart(ctx context.Context, organizationID uint32, userID, workspaceID uint64, machine models.MachineConfig) error {
+	quotas, err := s.getStartQuotas(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	return checkQuotas(ctx, s.workspaceRepository, quotas, workspaceID, machine)
+}
+
+// ReserveWorkspaceStart is CheckWorkspaceStart for a caller about to make
+// the workspace count towards the quotas, by inserting it or moving it to an
+// active status, in the transaction workspaceRepository is bound to. The
+// transaction holds the organization's quota lock until it ends, so
+// concurrent starts are checked one after the other and each sees the ones
+// before it.
+func (s *QuotaService) ReserveWorkspaceStart(ctx context.Context, workspaceRepository *repositories.WorkspaceRepository, organizationID uint32, userID, workspaceID uint64, machine models.MachineConfig) error {
+	quotas, err := s.getStartQuotas(ctx, organizationID, userID)
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+	if err := workspaceRepository.LockQuota(ctx, organizationID); err != nil {
+		return fmt.Errorf("failed to lock the quotas of organization %d: %w", organizationID, err)
+	}
+	return checkQuotas(ctx, workspaceRepository, quotas, workspaceID, machine)
+}
+
+// getStartQuotas returns the user's and the organization's quotas, those
+// that are set.
+func (s *QuotaService) getStartQuotas(ctx context.Context, organizationID uint32, userID uint64) ([]*models.Quota, error) {
+	var quotas []*models.Quota
+	for _, quotaUserID := range []*uint64{&userID, nil} {
+		quota, err := s.quotaRepository.Get(ctx, organizationID, quotaUserID)
+		if err != nil {
+			return nil, err
+		}
+		if quota != nil {
+			quotas = append(quotas, quota)
+		}
+	}
+	return quotas, nil
+}
+
+// checkQuotas checks the usage of each quota's scope, leaving out the
+// workspace, plus one active workspace on the machine against the quota.
+func checkQuotas(ctx context.Context, workspaceRepository *repositories.WorkspaceRepository, quotas []*models.Quota, workspaceID uint64, machine models.MachineConfig) error {
+	for _, quota := range quotas {
+		usage, err := workspaceRepository.GetUsage(ctx, quota.OrganizationID, quota.UserID, workspaceID)
+		if err != nil {
+			return err
+		}
+		if err := checkQuota(quota, usage, machine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkQuota checks usage plus one active workspace on the machine against
+// the quota.
+func checkQuota(quota *models.Quota, usage repositories.WorkspaceUsage, machine models.MachineConfig) error {
+	scope := "organization"
+	if quota.UserID != nil {
+		scope = "user"
+	}
+
+	if len(quota.AllowedCategories) > 0 && !containsFold(quota.AllowedCategories, machine.Category) {
+		return internalErrors.NewQuotaExceededError(
+			fmt.Sprintf("Instance category %q is not allowed by the %s quota", machine.Category, scope),
+			map[string]any{"quota": "allowed_categories", "scope": scope, "allowed": quota.AllowedCategories, "requested": machine.Category})
+	}
+
+	exceeded := func(name, what string, limit, used, requested any) error {
+		return internalErrors.NewQuotaExceededError(
+			fmt.Sprintf("The %s quota allows %v %s and %v are in use", scope, limit, what, used),
+			map[string]any{"quota": name, "scope": scope, "limit": limit, "used": used, "requested": requested})
+	}
+	if quota.MaxWorkspaces != nil && usage.Workspaces+1 > int64(*quota.MaxWorkspaces) {
+		return exceeded("max_workspaces", "workspaces", *quota.MaxWorkspaces, usage.Workspaces, 1)
+	}
+	if quota.MaxRunningWorkspaces != nil && usage.RunningWorkspaces+1 > int64(*quota.MaxRunningWorkspaces) {
+		return exceeded("max_running_workspaces", "running workspaces", *quota.MaxRunningWorkspaces, usage.RunningWorkspaces, 1)
+	}
+	if quota.MaxCPUCores != nil && usage.CPUCores+int64(machine.CPUCores) > int64(*quota.MaxCPUCores) {
+		return exceeded("max_cpu_cores", "vCPUs", *quota.MaxCPUCores, usage.CPUCores, machine.CPUCores)
+	}
+	if quota.MaxMemoryGB != nil && usage.MemoryGB+machine.MemoryGB > *quota.MaxMemoryGB {
+		return exceeded("max_memory_gb", "GB of memory", *quota.MaxMemoryGB, usage.MemoryGB, machine.MemoryGB)
+	}
+	return nil
+}
+
+func (s *QuotaService) toQuotaDTO(ctx context.Context, quota models.Quota) (dto.QuotaDTO, error) {
+	usage, err := s.workspaceRepository.GetUsage(ctx, quota.OrganizationID, quota.UserID, 0)
+	if err != nil {
+		return dto.QuotaDTO{}, err
+	}
+
+	quotaDTO := dto.ToQuotaDTO(quota)
+	quotaDTO.Usage = &dto.QuotaUsageDTO{
+		Workspaces:        usage.Workspaces,
+		RunningWorkspaces: usage.RunningWorkspaces,
+		CPUCores:          usage.CPUCores,
+		MemoryGB:          usage.MemoryGB,
+	}
+	return quotaDTO, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
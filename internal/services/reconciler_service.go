@@ -0,0 +1,152 @@
+package services
+
+import (
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/services/devpod"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+type ReconcilerServiceConfig struct {
+	Repositories          *repositories.Repositories
+	Workspace             *WorkspaceService
+	Backend               devpod.WorkspaceBackend
+	GarbageCollectOrphans bool
+}
+
+// ReconcilerService compares the workspaces stored in the database with the
+// ones the backend actually knows about and corrects the drift. It also
+// fails the workspace actions whose task was lost.
+type ReconcilerService struct {
+	workspaceRepository   *repositories.WorkspaceRepository
+	workspaceService      *WorkspaceService
+	backend               devpod.WorkspaceBackend
+	garbageCollectOrphans bool
+}
+
+func NewReconcilerService(config *ReconcilerServiceConfig) *ReconcilerService {
+	return &ReconcilerService{
+		workspaceRepository:   config.Repositories.Workspace,
+		workspaceService:      config.Workspace,
+		backend:               config.Backend,
+		garbageCollectOrphans: config.GarbageCollectOrphans,
+	}
+}
+
+func (s *ReconcilerService) Reconcile(ctx context.Context) error {
+	if err := s.workspaceService.RecoverStaleWorkspaceActions(ctx); err != nil {
+		log.Printf("[reconciler] failed to recover stale workspace actions: %v", err)
+	}
+
+	// The database is read first, so a backend state newer than the loaded
+	// workspace can only come with a newer version, which the correction
+	// then leaves alone.
+	workspaces, err := s.workspaceRepository.GetNotTerminated(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+
+	backendWorkspaces, err := s.backend.ListWorkspaces(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list backend workspaces: %w", err)
+	}
+
+	states := make(map[uint64]devpod.WorkspaceState, len(backendWorkspaces))
+	for _, info := range backendWorkspaces {
+		workspaceID, err := strconv.ParseUint(info.ID, 10, 64)
+		if err != nil {
+			log.Printf("[reconciler] ignoring backend workspace %q that is not managed by this service", info.ID)
+			continue
+		}
+		states[workspaceID] = info.State
+	}
+
+	known := make(map[uint64]bool, len(workspaces))
+	for _, workspace := range workspaces {
+		known[workspace.ID] = true
+		if workspace.DeletedAt.Valid {
+			continue
+		}
+		state, found := states[workspace.ID]
+		s.reconcileWorkspace(ctx, workspace, state, found)
+	}
+
+	for workspaceID := range states {
+		if !known[workspaceID] {
+			s.handleOrphan(ctx, workspaceID)
+		}
+	}
+
+	return nil
+}
+
+// reconcileWorkspace only touches settled statuses; a workspace in a
+// transitional status has an action in flight that will settle it. The
+// correction is dropped if the workspace changed since it was loaded.
+func (s *ReconcilerService) reconcileWorkspace(ctx context.Context, workspace models.Workspace, state devpod.WorkspaceState, found bool) {
+	var newStatus enums.WorkspaceStatus
+	var message string
+
+	switch workspace.Status {
+	case enums.WorkspaceStatusRunning:
+		if !found || state == devpod.WorkspaceStateNotFound {
+			newStatus = enums.WorkspaceStatusFailed
+			message = "Reconciler: workspace is running in the database but its machine no longer exists"
+		} else if state == devpod.WorkspaceStateStopped {
+			newStatus = enums.WorkspaceStatusStopped
+			message = "Reconciler: backend reports the workspace as stopped"
+		}
+	case enums.WorkspaceStatusStopped, enums.WorkspaceStatusFailed, enums.WorkspaceStatusCancelled:
+		if found && state == devpod.WorkspaceStateRunning {
+			newStatus = enums.WorkspaceStatusRunning
+			message = "Reconciler: backend reports the workspace as running"
+		}
+	}
+
+	if newStatus == "" {
+		return
+	}
+
+	updated, err := s.workspaceService.CorrectWorkspaceStatus(ctx, workspace, newStatus, message, enums.WorkspaceActorReconciler)
+	if err != nil {
+		log.Printf("[reconciler] failed to correct workspace %d status: %v", workspace.ID, err)
+		return
+	}
+	if !updated {
+		log.Printf("[reconciler] workspace %d changed since it was loaded, leaving it", workspace.ID)
+		return
+	}
+	log.Printf("[reconciler] workspace %d: %s -> %s", workspace.ID, workspace.Status, newStatus)
+}
+
+// handleOrphan deals with a backend workspace that has no live database row.
+func (s *ReconcilerService) handleOrphan(ctx context.Context, workspaceID uint64) {
+	if !s.garbageCollectOrphans {
+		log.Printf("[reconciler] workspace %d exists in the backend but not in the database", workspaceID)
+		return
+	}
+
+	// The workspace may have been created after the database was read.
+	workspace, err := s.workspaceRepository.GetStatusByID(ctx, workspaceID)
+	if err == nil && workspace.Status != enums.WorkspaceStatusTerminated {
+		return
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[reconciler] failed to check orphaned workspace %d: %v", workspaceID, err)
+		return
+	}
+
+	log.Printf("[reconciler] deleting orphaned backend workspace %d", workspaceID)
+	err = s.backend.TerminateWorkspace(ctx, dto.DevpodWorkspace{DevpodWorkspaceId: workspaceID}, nil)
+	if err != nil {
+		log.Printf("[reconciler] failed to delete orphaned workspace %d: %v", workspaceID, err)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"clusterix-code/internal/config"
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/services/devpod"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+type RetentionServiceConfig struct {
+	Repositories *repositories.Repositories
+	Workspace    *WorkspaceService
+	Publisher    *PublisherService
+	Backend      devpod.WorkspaceBackend
+	Retention    config.RetentionConfig
+}
+
+// RetentionService terminates the workspaces that stayed stopped or failed
+// longer than their organization keeps them, warning the user first, and
+// makes sure deleted workspaces leave no machine or DNS record behind.
+type RetentionService struct {
+	workspaceRepository           *repositories.WorkspaceRepository
+	organizationSettingRepository *repositories.OrganizationSettingRepository
+	workspacePortRepository       *repositories.WorkspacePortRepository
+	workspaceService              *WorkspaceService
+	publisherService              *PublisherService
+	backend                       devpod.WorkspaceBackend
+	stoppedDays                   int
+	failedDays                    int
+	warningBefore                 time.Duration
+}
+
+func NewRetentionService(config *RetentionServiceConfig) *RetentionService {
+	return &RetentionService{
+		workspaceRepository:           config.Repositories.Workspace,
+		organizationSettingRepository: config.Repositories.OrganizationSetting,
+		workspacePortRepository:       config.Repositories.WorkspacePort,
+		workspaceService:              config.Workspace,
+		publisherService:              config.Publisher,
+		backend:                       config.Backend,
+		stoppedDays:                   config.Retention.StoppedDays,
+		failedDays:                    config.Retention.FailedDays,
+		warningBefore:                 config.Retention.WarningBefore,
+	}
+}
+
+// SetKeep exempts the workspace from the retention policy, or makes it
+// subject to it again.
+func (s *RetentionService) SetKeep(ctx context.Context, workspaceID uint64, keep bool) error {
+	return s.workspaceRepository.UpdateKeep(ctx, workspaceID, keep)
+}
+
+// Run applies the retention policy and purges deleted workspaces.
+func (s *RetentionService) Run(ctx context.Context) error {
+	return errors.Join(s.expireWorkspaces(ctx), s.purgeDeletedWorkspaces(ctx))
+}
+
+// expireWorkspaces warns the users of workspaces about to reach their
+// retention and terminates those that reached it after being warned.
+func (s *RetentionService) expireWorkspaces(ctx context.Context) error {
+	workspaces, err := s.workspaceRepository.GetExpiryCandidates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load stopped and failed workspaces: %w", err)
+	}
+	if len(workspaces) == 0 {
+		return nil
+	}
+
+	var organizationIDs []uint32
+	for _, workspace := range workspaces {
+		organizationIDs = append(organizationIDs, workspace.OrganizationID)
+	}
+	settings, err := s.organizationSettingRepository.GetByOrganizationIDs(ctx, organizationIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load organization settings: %w", err)
+	}
+
+	now := time.Now()
+	for _, workspace := range workspaces {
+		retention := s.retention(workspace, settings)
+		if retention <= 0 {
+			continue
+		}
+		expiresAt := workspace.StatusSince.Add(retention)
+
+		// A warning from before the workspace last changed status doesn't
+		// count, and a warned user always gets the full notice.
+		warned := workspace.ExpiryWarnedAt != nil && !workspace.ExpiryWarnedAt.Before(workspace.StatusSince)
+		switch {
+		case !warned && !now.Before(expiresAt.Add(-s.warningBefore)):
+			s.warn(ctx, workspace, expiresAt, now)
+		case warned && !now.Before(expiresAt) && !now.Before(workspace.ExpiryWarnedAt.Add(s.warningBefore)):
+			s.expire(ctx, workspace, retention)
+		}
+	}
+	return nil
+}
+
+// retention is how long the workspace's organization keeps workspaces in
+// its status, else the platform default.
+func (s *RetentionService) retention(workspace repositories.ExpiringWorkspace, settings map[uint32]models.OrganizationSetting) time.Duration {
+	setting, hasSetting := settings[workspace.OrganizationID]
+
+	days := s.stoppedDays
+	if workspace.Status == enums.WorkspaceStatusFailed {
+		days = s.failedDays
+		if hasSetting && setting.FailedRetentionDays != nil {
+			days = *setting.FailedRetentionDays
+		}
+	} else if hasSetting && setting.StoppedRetentionDays != nil {
+		days = *setting.StoppedRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (s *RetentionService) warn(ctx context.Context, workspace repositories.ExpiringWorkspace, expiresAt, now time.Time) {
+	if err := s.workspaceRepository.MarkExpiryWarned(ctx, workspace.ID, now); err != nil {
+		log.Printf("[retention] failed to mark workspace %d as warned: %v", workspace.ID, err)
+		return
+	}
+	if terminatesAt := now.Add(s.warningBefore); expiresAt.Before(terminatesAt) {
+		expiresAt = terminatesAt
+	}
+
+	body := dto.Message{
+		EventType: constants.WorkspaceExpiryWarning,
+		Channel:   fmt.Sprintf("workspace_%d_status", workspace.ID),
+		Data: map[string]interface{}{
+			"status":        workspace.Status,
+			"status_since":  workspace.StatusSince,
+			"terminates_at": expiresAt,
+		},
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to marshal message: %v", err)
+		return
+	}
+	s.publisherService.Publish(constants.CLUSTERIX_CODE_V1_EXCHANGE, constants.WORKSPACE_LOG_HANDLER_QUEUE, payload)
+}
+
+// expire terminates and deletes the workspace.
+func (s *RetentionService) expire(ctx context.Context, workspace repositories.ExpiringWorkspace, retention time.Duration) {
+	days := int(retention / (24 * time.Hour))
+	reason := fmt.Sprintf("Terminating workspace after %d days %s", days, workspace.Status)
+	if err := s.workspaceService.DeleteWorkspace(ctx, strconv.FormatUint(workspace.ID, 10), enums.WorkspaceActorRetention, reason); err != nil {
+		log.Printf("[retention] failed to terminate expired workspace %d: %v", workspace.ID, err)
+		return
+	}
+	log.Printf("[retention] terminating workspace %d after %d days %s", workspace.ID, days, workspace.Status)
+}
+
+// purgeDeletedWorkspaces retries the termination of deleted workspaces that
+// still have a machine and removes the DNS records of terminated ones.
+func (s *RetentionService) purgeDeletedWorkspaces(ctx context.Context) error {
+	workspaces, err := s.workspaceRepository.GetUnpurged(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load deleted workspaces: %w", err)
+	}
+
+	now := time.Now()
+	for _, workspace := range workspaces {
+		if workspace.Status != enums.WorkspaceStatusTerminated {
+			s.retryTermination(ctx, workspace)
+			continue
+		}
+
+		ports, err := s.workspacePortRepository.GetPortNumbers(ctx, workspace.ID)
+		if err != nil {
+			log.Printf("[retention] failed to load the ports of workspace %d: %v", workspace.ID, err)
+			continue
+		}
+		if err := s.backend.DeleteDNSRecord(ctx, dto.DevpodWorkspace{
+			DevpodWorkspaceId: workspace.ID,
+			Fingerprint:       workspace.Fingerprint,
+			Ports:             ports,
+		}); err != nil {
+			log.Printf("[retention] failed to delete the DNS record of workspace %d: %v", workspace.ID, err)
+			continue
+		}
+		if err := s.workspaceRepository.MarkPurged(ctx, workspace.ID, now); err != nil {
+			log.Printf("[retention] failed to mark workspace %d as purged: %v", workspace.ID, err)
+		}
+	}
+	return nil
+}
+
+// retryTermination terminates a deleted workspace whose termination failed
+// or was cancelled. One still queued or running is left to finish.
+func (s *RetentionService) retryTermination(ctx context.Context, workspace models.Workspace) {
+	pending, err := s.workspaceService.GetPendingWorkspaceActions(ctx, workspace.ID)
+	if err != nil {
+		log.Printf("[retention] failed to load the actions of workspace %d: %v", workspace.ID, err)
+		return
+	}
+	if len(pending) > 0 {
+		return
+	}
+
+	if _, err := s.workspaceService.SubmitWorkspaceAction(ctx, workspace.ID, workspace.UserID, constants.ActionTerminate, enums.WorkspaceActorRetention); err != nil {
+		log.Printf("[retention] failed to terminate deleted workspace %d: %v", workspace.ID, err)
+		return
+	}
+	log.Printf("[retention] retrying the termination of deleted workspace %d", workspace.ID)
+}
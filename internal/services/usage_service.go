@@ -0,0 +1,239 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+type UsageServiceConfig struct {
+	Repositories *repositories.Repositories
+}
+
+// UsageService derives how long workspaces ran, and what that cost, from
+// their status events.
+type UsageService struct {
+	workspaceRepository            *repositories.WorkspaceRepository
+	workspaceStatusEventRepository *repositories.WorkspaceStatusEventRepository
+}
+
+func NewUsageService(config *UsageServiceConfig) *UsageService {
+	return &UsageService{
+		workspaceRepository:            config.Repositories.Workspace,
+		workspaceStatusEventRepository: config.Repositories.WorkspaceStatusEvent,
+	}
+}
+
+// GetUsage reports the running time and cost of the organization's
+// workspaces over the requested period, which defaults to the current month.
+// Costs use the current hourly price of each workspace's machine.
+func (s *UsageService) GetUsage(ctx context.Context, organizationID uint32, req requests.UsageRequest) (dto.UsageReportDTO, error) {
+	from, to, err := usagePeriod(req, time.Now())
+	if err != nil {
+		return dto.UsageReportDTO{}, err
+	}
+
+	events, err := s.workspaceStatusEventRepository.GetForPeriod(ctx, organizationID, from, to)
+	if err != nil {
+		return dto.UsageReportDTO{}, err
+	}
+
+	running := make(map[uint64]time.Duration)
+	var ids []uint64
+	for start := 0; start < len(events); {
+		end := start
+		for end < len(events) && events[end].WorkspaceID == events[start].WorkspaceID {
+			end++
+		}
+		if duration := runningDuration(events[start:end], from, to); duration > 0 {
+			running[events[start].WorkspaceID] = duration
+			ids = append(ids, events[start].WorkspaceID)
+		}
+		start = end
+	}
+
+	report := dto.UsageReportDTO{
+		OrganizationID: organizationID,
+		From:           from,
+		To:             to,
+		Users:          []dto.UsageGroupDTO{},
+		Repositories:   []dto.UsageGroupDTO{},
+		Workspaces:     []dto.WorkspaceUsageDTO{},
+	}
+	if len(ids) == 0 {
+		return report, nil
+	}
+
+	workspaces, err := s.workspaceRepository.GetByIDsIncludingDeleted(ctx, ids)
+	if err != nil {
+		return dto.UsageReportDTO{}, err
+	}
+
+	users := newUsageGroups()
+	repositories := newUsageGroups()
+	var hours, cost float64
+	for _, workspace := range workspaces {
+		if req.UserID != 0 && workspace.UserID != req.UserID {
+			continue
+		}
+		if req.RepositoryID != 0 && workspace.RepositoryID != req.RepositoryID {
+			continue
+		}
+
+		machine := workspaceMachineConfig(&workspace)
+		workspaceHours := running[workspace.ID].Hours()
+		workspaceCost := workspaceHours * machine.HourlyPrice
+		hours += workspaceHours
+		cost += workspaceCost
+
+		userName := userDisplayName(workspace.User)
+		users.add(workspace.UserID, userName, workspaceHours, workspaceCost)
+		repositories.add(workspace.RepositoryID, workspace.Repository.Title, workspaceHours, workspaceCost)
+
+		report.Workspaces = append(report.Workspaces, dto.WorkspaceUsageDTO{
+			WorkspaceID:     workspace.ID,
+			Title:           workspace.Title,
+			UserID:          workspace.UserID,
+			UserName:        userName,
+			UserEmail:       workspace.User.Email,
+			RepositoryID:    workspace.RepositoryID,
+			RepositoryTitle: workspace.Repository.Title,
+			InstanceType:    machine.InstanceType,
+			HourlyPrice:     machine.HourlyPrice,
+			Hours:           dto.RoundUsage(workspaceHours),
+			Cost:            dto.RoundUsage(workspaceCost),
+		})
+	}
+
+	sort.Slice(report.Workspaces, func(i, j int) bool {
+		return report.Workspaces[i].WorkspaceID < report.Workspaces[j].WorkspaceID
+	})
+	report.Hours = dto.RoundUsage(hours)
+	report.Cost = dto.RoundUsage(cost)
+	report.Users = users.list()
+	report.Repositories = repositories.list()
+	return report, nil
+}
+
+// runningDuration sums the time within [from, to) that the events of one
+// workspace, oldest first, show it running.
+func runningDuration(events []models.WorkspaceStatusEvent, from, to time.Time) time.Duration {
+	var total time.Duration
+	var since *time.Time
+	for _, event := range events {
+		at := event.CreatedAt
+		if at.Before(from) {
+			at = from
+		}
+		if event.Status == enums.WorkspaceStatusRunning {
+			if since == nil {
+				since = &at
+			}
+			continue
+		}
+		if since != nil {
+			total += at.Sub(*since)
+			since = nil
+		}
+	}
+	if since != nil {
+		total += to.Sub(*since)
+	}
+	return total
+}
+
+// usagePeriod resolves the requested period. It never extends past now, so
+// workspaces still running are counted until now.
+func usagePeriod(req requests.UsageRequest, now time.Time) (time.Time, time.Time, error) {
+	errs := map[string][]string{}
+
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if req.From != "" {
+		parsed, _, err := parseUsageTime(req.From)
+		if err != nil {
+			errs["from"] = []string{"must be a date (YYYY-MM-DD) or an RFC 3339 time"}
+		}
+		from = parsed
+	}
+
+	to := now
+	if req.To != "" {
+		parsed, isDate, err := parseUsageTime(req.To)
+		if err != nil {
+			errs["to"] = []string{"must be a date (YYYY-MM-DD) or an RFC 3339 time"}
+		}
+		if isDate {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		if parsed.Before(now) {
+			to = parsed
+		}
+	}
+
+	if len(errs) == 0 && !from.Before(to) {
+		errs["from"] = []string{"must be before to"}
+	}
+	if len(errs) > 0 {
+		return time.Time{}, time.Time{}, internalErrors.NewValidationError("Invalid usage period", errs)
+	}
+	return from, to, nil
+}
+
+func parseUsageTime(value string) (time.Time, bool, error) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, true, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	return parsed, false, err
+}
+
+func userDisplayName(user models.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// usageGroups sums up hours and cost per user or repository.
+type usageGroups map[uint64]*dto.UsageGroupDTO
+
+func newUsageGroups() usageGroups {
+	return make(usageGroups)
+}
+
+func (g usageGroups) add(id uint64, name string, hours, cost float64) {
+	group, ok := g[id]
+	if !ok {
+		group = &dto.UsageGroupDTO{ID: id, Name: name}
+		g[id] = group
+	}
+	group.Hours += hours
+	group.Cost += cost
+}
+
+// list returns the groups, most expensive first.
+func (g usageGroups) list() []dto.UsageGroupDTO {
+	groups := make([]dto.UsageGroupDTO, 0, len(g))
+	for _, group := range g {
+		groups = append(groups, dto.UsageGroupDTO{
+			ID:    group.ID,
+			Name:  group.Name,
+			Hours: dto.RoundUsage(group.Hours),
+			Cost:  dto.RoundUsage(group.Cost),
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Cost != groups[j].Cost {
+			return groups[i].Cost > groups[j].Cost
+		}
+		return groups[i].ID < groups[j].ID
+	})
+	return groups
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"strings"
+)
+
+type UserPreferenceServiceConfig struct {
+	Repositories *repositories.Repositories
+}
+
+type UserPreferenceService struct {
+	userPreferenceRepository         *repositories.UserPreferenceRepository
+	machineConfigRepository          *repositories.MachineConfigRepository
+	gitPersonalAccessTokenRepository *repositories.GitPersonalAccessTokenRepository
+}
+
+func NewUserPreferenceService(config *UserPreferenceServiceConfig) *UserPreferenceService {
+	return &UserPreferenceService{
+		userPreferenceRepository:         config.Repositories.UserPreference,
+		machineConfigRepository:          config.Repositories.MachineConfig,
+		gitPersonalAccessTokenRepository: config.Repositories.GitPersonalAccessToken,
+	}
+}
+
+func (s *UserPreferenceService) GetPreferences(ctx context.Context, userID uint64) (dto.UserPreferencesDTO, error) {
+	preference, err := s.userPreferenceRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return dto.UserPreferencesDTO{}, err
+	}
+	return dto.ToUserPreferencesDTO(preference), nil
+}
+
+// UpdatePreferences replaces the user's preferences.
+func (s *UserPreferenceService) UpdatePreferences(ctx context.Context, userID uint64, req requests.UpdateUserPreferencesRequest) (dto.UserPreferencesDTO, error) {
+	if err := s.validatePreferences(ctx, userID, req); err != nil {
+		return dto.UserPreferencesDTO{}, err
+	}
+
+	preference := models.UserPreference{
+		UserID:                  userID,
+		DotfilesRepository:      req.DotfilesRepository,
+		DotfilesInstallScript:   req.DotfilesInstallScript,
+		DefaultIDE:              req.DefaultIDE,
+		DefaultColor:            req.DefaultColor,
+		DefaultMachineConfigID:  req.DefaultMachineConfigID,
+		DefaultGitAccessTokenID: req.DefaultGitAccessTokenID,
+	}
+	if err := s.userPreferenceRepository.Save(ctx, &preference); err != nil {
+		return dto.UserPreferencesDTO{}, err
+	}
+	return dto.ToUserPreferencesDTO(&preference), nil
+}
+
+// ApplyWorkspaceDefaults fills in what a create request left out from the
+// user's preferences. Workspaces get openvscode when neither names an IDE;
+// color and git access token have no fallback and are then required.
+func (s *UserPreferenceService) ApplyWorkspaceDefaults(ctx context.Context, req *requests.CreateWorkspaceRequest) error {
+	preference, err := s.userPreferenceRepository.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if preference == nil {
+		preference = &models.UserPreference{}
+	}
+
+	if req.IDE == "" {
+		req.IDE = preference.DefaultIDE
+	}
+	if req.IDE == "" {
+		req.IDE = string(enums.IDEOpenVSCode)
+	}
+	if req.Color == "" {
+		req.Color = preference.DefaultColor
+	}
+	if req.GitAccessTokenID == 0 && preference.DefaultGitAccessTokenID != nil {
+		req.GitAccessTokenID = *preference.DefaultGitAccessTokenID
+	}
+	if req.MachineConfigID == 0 && preference.DefaultMachineConfigID != nil {
+		req.MachineConfigID = *preference.DefaultMachineConfigID
+	}
+
+	errs := map[string][]string{}
+	if req.Color == "" {
+		errs["color"] = []string{"is required when no default color is set"}
+	}
+	if req.GitAccessTokenID == 0 {
+		errs["git_access_token_id"] = []string{"is required when no default git access token is set"}
+	}
+	if req.MachineConfigID != 0 {
+		if _, err := s.machineConfigRepository.GetByID(ctx, req.MachineConfigID); err != nil {
+			errs["machine_config_id"] = []string{"must be an existing machine config"}
+		}
+	}
+	if len(errs) > 0 {
+		return internalErrors.NewValidationError("Missing workspace settings", errs)
+	}
+	return nil
+}
+
+// GetDotfiles returns the dotfiles repository and install script of the
+// user, both empty when the user has no dotfiles.
+func (s *UserPreferenceService) GetDotfiles(ctx context.Context, userID uint64) (string, string, error) {
+	preference, err := s.userPreferenceRepository.GetByUserID(ctx, userID)
+	if err != nil || preference == nil {
+		return "", "", err
+	}
+	return preference.DotfilesRepository, preference.DotfilesInstallScript, nil
+}
+
+func (s *UserPreferenceService) validatePreferences(ctx context.Context, userID uint64, req requests.UpdateUserPreferencesRequest) error {
+	errs := map[string][]string{}
+
+	if req.DotfilesRepository != "" &&
+		(strings.HasPrefix(req.DotfilesRepository, "-") || strings.ContainsAny(req.DotfilesRepository, " \t\r\n")) {
+		errs["dotfiles_repository"] = []string{"must be a git repository URL"}
+	}
+	if req.DotfilesInstallScript != "" {
+		if req.DotfilesRepository == "" {
+			errs["dotfiles_install_script"] = []string{"requires a dotfiles repository"}
+		} else if !isRepositoryPath(req.DotfilesInstallScript) {
+			errs["dotfiles_install_script"] = []string{"must be a path inside the dotfiles repository"}
+		}
+	}
+	if req.DefaultIDE != "" {
+		if !enums.IDE(req.DefaultIDE).IsSupported() {
+			errs["default_ide"] = []string{"must be a supported IDE"}
+		}
+	}
+	if req.DefaultMachineConfigID != nil {
+		if _, err := s.machineConfigRepository.GetByID(ctx, *req.DefaultMachineConfigID); err != nil {
+			errs["default_machine_config_id"] = []string{"must be an existing machine config"}
+		}
+	}
+	if req.DefaultGitAccessTokenID != nil {
+		if _, err := s.gitPersonalAccessTokenRepository.GetByID(ctx, userID, *req.DefaultGitAccessTokenID); err != nil {
+			errs["default_git_access_token_id"] = []string{"must be one of your git access tokens"}
+		}
+	}
+
+	if len(errs) > 0 {
+		return internalErrors.NewValidationError("Invalid preferences", errs)
+	}
+	return nil
+}
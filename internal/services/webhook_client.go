@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// webhookMetadataAddresses are the cloud metadata services outside the
+// link-local range, which webhooks may never reach.
+var webhookMetadataAddresses = []netip.Addr{
+	netip.MustParseAddr("fd00:ec2::254"),   // AWS over IPv6
+	netip.MustParseAddr("100.100.100.200"), // Alibaba Cloud
+}
+
+// errWebhookAddressBlocked is returned for endpoints on addresses webhooks
+// may not reach.
+var errWebhookAddressBlocked = errors.New("webhook endpoints may not be on this address")
+
+// newWebhookHTTPClient returns the client deliveries are posted with. Since
+// organization admins choose the endpoints, the client refuses addresses
+// inside the platform, checked on every connection after DNS resolution so
+// a hostname can't be pointed at them later. Redirects aren't followed, as
+// they could lead anywhere, and proxies from the environment aren't used,
+// since the check only sees the proxy's address.
+func newWebhookHTTPClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !webhookAddressAllowed(addrPort.Addr(), allowPrivateNetworks) {
+				return fmt.Errorf("%w: %s", errWebhookAddressBlocked, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookAddressAllowed reports whether a webhook may connect to the
+// address. Link-local addresses, which include most cloud metadata
+// services, and the other metadata addresses are always refused; loopback
+// and private networks unless allowPrivateNetworks is set.
+func webhookAddressAllowed(addr netip.Addr, allowPrivateNetworks bool) bool {
+	addr = addr.Unmap()
+	for _, metadata := range webhookMetadataAddresses {
+		if addr == metadata {
+			return false
+		}
+	}
+	if addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	if addr.IsLoopback() || addr.IsPrivate() {
+		return allowPrivateNetworks
+	}
+	return true
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"bytes"
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/config"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/tasks"
+	"clusterix-code/internal/utils/crypto"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// Headers sent with every webhook delivery. The signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret,
+// prefixed with "sha256=".
+const (
+	WebhookEventHeader     = "X-Clusterix-Event"
+	WebhookDeliveryHeader  = "X-Clusterix-Delivery"
+	WebhookTimestampHeader = "X-Clusterix-Timestamp"
+	WebhookSignatureHeader = "X-Clusterix-Signature"
+)
+
+// webhookResponseLimit is how much of an endpoint's response is kept in the
+// delivery log.
+const webhookResponseLimit = 2048
+
+type WebhookServiceConfig struct {
+	Repositories *repositories.Repositories
+	AsynqClient  *asynq.Client
+	// EncryptionKey is the base64 encoded 32 byte key webhook secrets are
+	// encrypted with. Without it webhooks can't be created.
+	EncryptionKey string
+	Webhooks      config.WebhooksConfig
+}
+
+// WebhookService manages the organizations' webhooks and delivers the
+// status changes of their workspaces to them from the worker.
+type WebhookService struct {
+	webhookRepository         *repositories.WebhookRepository
+	webhookDeliveryRepository *repositories.WebhookDeliveryRepository
+	asynqClient               *asynq.Client
+	cipher                    *crypto.Cipher
+	httpClient                *http.Client
+	maxRetries                int
+}
+
+func NewWebhookService(config *WebhookServiceConfig) *WebhookService {
+	var cipher *crypto.Cipher
+	if config.EncryptionKey != "" {
+		var err error
+		if cipher, err = crypto.NewCipher(config.EncryptionKey); err != nil {
+			log.Printf("⚠️ Webhooks are disabled: %v", err)
+		}
+	}
+
+	return &WebhookService{
+		webhookRepository:         config.Repositories.Webhook,
+		webhookDeliveryRepository: config.Repositories.WebhookDelivery,
+		asynqClient:               config.AsynqClient,
+		cipher:                    cipher,
+		httpClient:                newWebhookHTTPClient(config.Webhooks.Timeout, config.Webhooks.AllowPrivateNetworks),
+		maxRetries:                config.Webhooks.MaxRetries,
+	}
+}
+
+// WebhookEvent is the event a workspace moving to the status is delivered
+// as.
+func WebhookEvent(status enums.WorkspaceStatus) string {
+	return "workspace." + string(status)
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, organizationID uint32) ([]dto.WebhookDTO, error) {
+	webhooks, err := s.webhookRepository.GetByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToWebhookDTOs(webhooks), nil
+}
+
+// GetWebhook returns the organization's webhook. Webhooks of other
+// organizations are reported as missing.
+func (s *WebhookService) GetWebhook(ctx context.Context, organizationID uint32, id uint64) (dto.WebhookDTO, error) {
+	webhook, err := s.getWebhook(ctx, organizationID, id)
+	if err != nil {
+		return dto.WebhookDTO{}, err
+	}
+	return dto.ToWebhookDTO(*webhook), nil
+}
+
+// CreateWebhook subscribes a webhook. The secret is returned only here, and
+// only when it was generated.
+func (s *WebhookService) CreateWebhook(ctx context.Context, organizationID uint32, userID uint64, req requests.CreateWebhookRequest) (dto.WebhookDTO, error) {
+	secret, generated := req.Secret, false
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return dto.WebhookDTO{}, err
+		}
+		generated = true
+	}
+
+	webhook := models.Webhook{
+		OrganizationID: organizationID,
+		CreatedByID:    userID,
+	}
+	if err := s.applyWebhookRequest(&webhook, req, secret); err != nil {
+		return dto.WebhookDTO{}, err
+	}
+	if err := s.webhookRepository.Create(ctx, &webhook); err != nil {
+		return dto.WebhookDTO{}, err
+	}
+
+	webhookDTO := dto.ToWebhookDTO(webhook)
+	if generated {
+		webhookDTO.Secret = secret
+	}
+	return webhookDTO, nil
+}
+
+// UpdateWebhook replaces the webhook, keeping its secret unless a new one is
+// given. Deliveries already made are not affected.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, organizationID uint32, id uint64, req requests.UpdateWebhookRequest) (dto.WebhookDTO, error) {
+	webhook, err := s.getWebhook(ctx, organizationID, id)
+	if err != nil {
+		return dto.WebhookDTO{}, err
+	}
+	if err := s.applyWebhookRequest(webhook, req, req.Secret); err != nil {
+		return dto.WebhookDTO{}, err
+	}
+	if err := s.webhookRepository.Update(ctx, webhook); err != nil {
+		return dto.WebhookDTO{}, err
+	}
+	return dto.ToWebhookDTO(*webhook), nil
+}
+
+// DeleteWebhook removes the webhook and its delivery log. Deliveries still
+// being retried are dropped.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, organizationID uint32, id uint64) error {
+	if _, err := s.getWebhook(ctx, organizationID, id); err != nil {
+		return err
+	}
+	return s.webhookRepository.Delete(ctx, id)
+}
+
+// GetDeliveries lists the webhook's deliveries, latest first.
+func (s *WebhookService) GetDeliveries(ctx context.Context, organizationID uint32, webhookID uint64, page, limit int) (pagination.Pagination, error) {
+	if _, err := s.getWebhook(ctx, organizationID, webhookID); err != nil {
+		return pagination.Pagination{}, err
+	}
+
+	result, err := s.webhookDeliveryRepository.GetByWebhookID(ctx, webhookID, page, limit)
+	if err != nil {
+		return result, err
+	}
+	result.Data = dto.ToWebhookDeliveryDTOs(result.Data.([]models.WebhookDelivery))
+	return result, nil
+}
+
+// Redeliver sends a delivery's payload again as a new delivery, e.g. once
+// an endpoint that was down is back.
+func (s *WebhookService) Redeliver(ctx context.Context, organizationID uint32, webhookID uint64, deliveryID uint64) (dto.WebhookDeliveryDTO, error) {
+	webhook, err := s.getWebhook(ctx, organizationID, webhookID)
+	if err != nil {
+		return dto.WebhookDeliveryDTO{}, err
+	}
+	if !webhook.Enabled {
+		return dto.WebhookDeliveryDTO{}, internalErrors.NewConflictError("WEBHOOK_DISABLED",
+			"The webhook must be enabled to redeliver to it")
+	}
+
+	original, err := s.webhookDeliveryRepository.GetByID(ctx, webhookID, deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.WebhookDeliveryDTO{}, internalErrors.NewNotFoundError("delivery")
+	}
+	if err != nil {
+		return dto.WebhookDeliveryDTO{}, err
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID:      webhookID,
+		WorkspaceID:    original.WorkspaceID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         enums.WebhookDeliveryStatusPending,
+		RedeliveryOfID: &original.ID,
+	}
+	if err := s.webhookDeliveryRepository.Create(ctx, &delivery); err != nil {
+		return dto.WebhookDeliveryDTO{}, err
+	}
+	if err := s.enqueueDelivery(delivery.ID); err != nil {
+		return dto.WebhookDeliveryDTO{}, err
+	}
+	return dto.ToWebhookDeliveryDTO(delivery), nil
+}
+
+// WorkspaceStatusChanged queues a delivery of the status change to every
+// webhook of the workspace's organization subscribed to it. Failing to do
+// so is logged and never fails the status change.
+func (s *WebhookService) WorkspaceStatusChanged(ctx context.Context, workspace dto.WorkspaceDTO, event models.WorkspaceStatusEvent) {
+	name := WebhookEvent(event.Status)
+	webhooks, err := s.webhookRepository.GetSubscribed(ctx, workspace.OrganizationID, name)
+	if err != nil {
+		log.Printf("[webhooks] failed to load the webhooks of organization %d: %v", workspace.OrganizationID, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	occurredAt := event.CreatedAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	payload, err := json.Marshal(dto.WebhookPayload{
+		Event:          name,
+		OccurredAt:     occurredAt,
+		OrganizationID: workspace.OrganizationID,
+		Workspace: dto.WebhookWorkspace{
+			ID:          workspace.ID,
+			Title:       workspace.Title,
+			Fingerprint: workspace.Fingerprint,
+			UserID:      workspace.UserID,
+			Status:      string(event.Status),
+			URL:         workspace.URL,
+		},
+		StatusEvent: dto.WebhookStatusEventData{
+			ID:          event.ID,
+			Status:      string(event.Status),
+			Message:     event.Message,
+			ErrorCode:   event.ErrorCode,
+			ErrorReason: event.ErrorReason,
+			Actor:       string(event.Actor),
+			ActorUserID: event.ActorUserID,
+			RunID:       event.RunID,
+		},
+	})
+	if err != nil {
+		log.Printf("[webhooks] failed to marshal %s of workspace %d: %v", name, workspace.ID, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		delivery := models.WebhookDelivery{
+			WebhookID:   webhook.ID,
+			WorkspaceID: workspace.ID,
+			Event:       name,
+			Payload:     string(payload),
+			Status:      enums.WebhookDeliveryStatusPending,
+		}
+		if err := s.webhookDeliveryRepository.Create(ctx, &delivery); err != nil {
+			log.Printf("[webhooks] failed to create the delivery of %s to webhook %d: %v", name, webhook.ID, err)
+			continue
+		}
+		if err := s.enqueueDelivery(delivery.ID); err != nil {
+			log.Printf("[webhooks] %v", err)
+		}
+	}
+}
+
+// Deliver posts a delivery to its webhook and records the outcome. An error
+// means the attempt failed and should be retried; on the final attempt the
+// delivery is marked as failed instead. Deliveries that can't succeed, e.g.
+// because the webhook was disabled, are failed without an error.
+func (s *WebhookService) Deliver(ctx context.Context, deliveryID uint64, final bool) error {
+	delivery, err := s.webhookDeliveryRepository.GetByID(ctx, 0, deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The webhook was deleted with its deliveries.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load delivery %d: %w", deliveryID, err)
+	}
+	if delivery.Status != enums.WebhookDeliveryStatusPending {
+		return nil
+	}
+
+	webhook, err := s.webhookRepository.GetByID(ctx, 0, delivery.WebhookID)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook %d: %w", delivery.WebhookID, err)
+	}
+	if !webhook.Enabled {
+		return s.failDelivery(ctx, delivery, "the webhook is disabled")
+	}
+	if s.cipher == nil {
+		return s.failDelivery(ctx, delivery, "no encryption key is configured to sign the delivery with")
+	}
+	secret, err := s.cipher.Decrypt(webhook.Secret)
+	if err != nil {
+		return s.failDelivery(ctx, delivery, fmt.Sprintf("failed to decrypt the webhook's secret: %v", err))
+	}
+
+	delivery.Attempts++
+	attemptErr := s.post(ctx, webhook.URL, secret, delivery)
+	switch {
+	case attemptErr == nil:
+		now := time.Now()
+		delivery.Status = enums.WebhookDeliveryStatusSucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+	case final:
+		delivery.Status = enums.WebhookDeliveryStatusFailed
+		delivery.Error = attemptErr.Error()
+	default:
+		delivery.Error = attemptErr.Error()
+	}
+	if err := s.webhookDeliveryRepository.UpdateAttempt(ctx, delivery); err != nil {
+		log.Printf("[webhooks] failed to record the attempt of delivery %d: %v", delivery.ID, err)
+	}
+
+	if attemptErr != nil && !final {
+		return fmt.Errorf("delivery %d to webhook %d failed: %w", delivery.ID, webhook.ID, attemptErr)
+	}
+	return nil
+}
+
+// post sends the delivery, keeping the endpoint's response on it. Only 2xx
+// responses count as delivered; redirects aren't followed.
+func (s *WebhookService) post(ctx context.Context, url string, secret string, delivery *models.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Clusterix-Webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		delivery.ResponseStatus = nil
+		delivery.ResponseBody = ""
+		return err
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	delivery.ResponseStatus = &resp.StatusCode
+	delivery.ResponseBody = string(response)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// SignWebhookPayload is the signature header value of a payload sent at
+// timestamp, in Unix seconds. Receivers compute it the same way to verify
+// deliveries.
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookService) failDelivery(ctx context.Context, delivery *models.WebhookDelivery, reason string) error {
+	delivery.Status = enums.WebhookDeliveryStatusFailed
+	delivery.Error = reason
+	if err := s.webhookDeliveryRepository.UpdateAttempt(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to fail delivery %d: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+func (s *WebhookService) enqueueDelivery(deliveryID uint64) error {
+	task, err := tasks.NewDeliverWebhookTask(deliveryID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.asynqClient.Enqueue(task, asynq.MaxRetry(s.maxRetries)); err != nil {
+		return fmt.Errorf("failed to enqueue delivery %d: %w", deliveryID, err)
+	}
+	return nil
+}
+
+func (s *WebhookService) getWebhook(ctx context.Context, organizationID uint32, id uint64) (*models.Webhook, error) {
+	webhook, err := s.webhookRepository.GetByID(ctx, organizationID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, internalErrors.NewNotFoundError("webhook")
+	}
+	return webhook, err
+}
+
+// applyWebhookRequest sets the request's fields on the webhook, encrypting
+// secret unless it is empty.
+func (s *WebhookService) applyWebhookRequest(webhook *models.Webhook, req requests.CreateWebhookRequest, secret string) error {
+	if secret != "" {
+		if s.cipher == nil {
+			return internalErrors.NewError(internalErrors.ErrorTypeBadRequest, "SECRETS_NOT_CONFIGURED",
+				"Webhooks can't be saved because no encryption key is configured", nil)
+		}
+		encrypted, err := s.cipher.Encrypt(secret)
+		if err != nil {
+			return err
+		}
+		webhook.Secret = encrypted
+	}
+
+	webhook.URL = req.URL
+	webhook.Events = req.Events
+	webhook.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
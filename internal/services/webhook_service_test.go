@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"clusterix-code/internal/data/models"
+)
+
+func TestWebhookPost(t *testing.T) {
+	const secret = "s3cret"
+	payload := `{"event":"workspace.running"}`
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantErr    bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "delivered",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "endpoint error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("boom"))
+			},
+			wantErr:    true,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "boom",
+		},
+		{
+			name: "redirect not followed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/elsewhere" {
+					t.Error("redirect was followed")
+				}
+				http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			},
+			wantErr:    true,
+			wantStatus: http.StatusFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != payload {
+					t.Errorf("body = %q, want %q", body, payload)
+				}
+				if got := r.Header.Get(WebhookEventHeader); got != "workspace.running" {
+					t.Errorf("event header = %q", got)
+				}
+				if got := r.Header.Get(WebhookDeliveryHeader); got != "42" {
+					t.Errorf("delivery header = %q", got)
+				}
+				want := SignWebhookPayload(secret, r.Header.Get(WebhookTimestampHeader), body)
+				if got := r.Header.Get(WebhookSignatureHeader); got != want {
+					t.Errorf("signature = %q, want %q", got, want)
+				}
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			s := &WebhookService{httpClient: newWebhookHTTPClient(5*time.Second, true)}
+			delivery := &models.WebhookDelivery{ID: 42, Event: "workspace.running", Payload: payload}
+			err := s.post(context.Background(), server.URL, secret, delivery)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if delivery.ResponseStatus == nil || *delivery.ResponseStatus != tt.wantStatus {
+				t.Errorf("response status = %v, want %d", delivery.ResponseStatus, tt.wantStatus)
+			}
+			if tt.wantBody != "" && delivery.ResponseBody != tt.wantBody {
+				t.Errorf("response body = %q, want %q", delivery.ResponseBody, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWebhookPostRefusesPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback endpoint")
+	}))
+	defer server.Close()
+
+	s := &WebhookService{httpClient: newWebhookHTTPClient(5*time.Second, false)}
+	delivery := &models.WebhookDelivery{ID: 1, Event: "workspace.running", Payload: "{}"}
+	err := s.post(context.Background(), server.URL, "secret", delivery)
+
+	if !errors.Is(err, errWebhookAddressBlocked) {
+		t.Fatalf("post() error = %v, want %v", err, errWebhookAddressBlocked)
+	}
+	if delivery.ResponseStatus != nil {
+		t.Errorf("response status = %d, want none", *delivery.ResponseStatus)
+	}
+}
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	tests := []struct {
+		addr         string
+		allowPrivate bool
+		want         bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1::1", want: true},
+		{addr: "169.254.169.254", allowPrivate: true, want: false},
+		{addr: "fe80::1", allowPrivate: true, want: false},
+		{addr: "fd00:ec2::254", allowPrivate: true, want: false},
+		{addr: "100.100.100.200", allowPrivate: true, want: false},
+		{addr: "0.0.0.0", allowPrivate: true, want: false},
+		{addr: "224.0.0.1", allowPrivate: true, want: false},
+		{addr: "::ffff:169.254.169.254", allowPrivate: true, want: false},
+		{addr: "127.0.0.1", want: false},
+		{addr: "127.0.0.1", allowPrivate: true, want: true},
+		{addr: "::1", want: false},
+		{addr: "10.0.0.5", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "172.16.0.1", allowPrivate: true, want: true},
+		{addr: "fd12::1", want: false},
+		{addr: "::ffff:10.0.0.5", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := webhookAddressAllowed(netip.MustParseAddr(tt.addr), tt.allowPrivate); got != tt.want {
+			t.Errorf("webhookAddressAllowed(%s, %v) = %v, want %v", tt.addr, tt.allowPrivate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package services
+
+import (
+	"clusterix-code/internal/config"
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/tasks"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"log"
+	"time"
+)
+
+// workspaceActionStatuses is the status a workspace takes once one of its
+// queued actions is handed to a worker.
+var workspaceActionStatuses = map[constants.WorkspaceAction]enums.WorkspaceStatus{
+	constants.ActionStart:     enums.WorkspaceStatusStarting,
+	constants.ActionStop:      enums.WorkspaceStatusStopping,
+	constants.ActionRestart:   enums.WorkspaceStatusRestarting,
+	constants.ActionRebuild:   enums.WorkspaceStatusRebuilding,
+	constants.ActionTerminate: enums.WorkspaceStatusTerminating,
+}
+
+// workspaceBootActions are the actions that boot the workspace's machine
+// and so count towards its quotas and budget.
+var workspaceBootActions = map[constants.WorkspaceAction]bool{
+	constants.ActionStart:   true,
+	constants.ActionRestart: true,
+	constants.ActionRebuild: true,
+}
+
+// staleActionAge is how long a running action may go without a live task
+// before it counts as lost. It leaves room for the task to be enqueued
+// after the action is committed.
+const staleActionAge = 5 * time.Minute
+
+// actionTaskMargin is the time an action task gets on top of its devpod
+// commands for the database work and cleanup around them.
+const actionTaskMargin = 5 * time.Minute
+
+// unboundedActionTimeout stands in for no timeout, as asynq replaces a zero
+// timeout with its 30 minute default.
+const unboundedActionTimeout = 24 * time.Hour
+
+// WorkspaceActionTimeouts is how long each action's task may run: the
+// timeouts of the devpod commands it runs plus actionTaskMargin. Keeping it
+// above them lets a slow command fail as a devpod timeout, with the action's
+// cleanup, instead of asynq killing the task midway.
+func WorkspaceActionTimeouts(devpodConfig config.DevpodConfig) map[constants.WorkspaceAction]time.Duration {
+	total := func(timeouts ...time.Duration) time.Duration {
+		sum := actionTaskMargin
+		for _, timeout := range timeouts {
+			if timeout <= 0 {
+				return unboundedActionTimeout
+			}
+			sum += timeout
+		}
+		return sum
+	}
+
+	return map[constants.WorkspaceAction]time.Duration{
+		constants.ActionStart:     total(devpodConfig.UpTimeout),
+		constants.ActionStop:      total(devpodConfig.StopTimeout),
+		constants.ActionRestart:   total(devpodConfig.StopTimeout, devpodConfig.UpTimeout),
+		constants.ActionRebuild:   total(devpodConfig.DeleteTimeout, devpodConfig.UpTimeout),
+		constants.ActionTerminate: total(devpodConfig.DeleteTimeout),
+	}
+}
+
+// dispatchedAction is an action handed to a worker by a locked change to its
+// workspace's queue, with the status change that goes with it. Its task is
+// enqueued and the status announced only once the change is committed, so a
+// worker never sees an action or status that could still be rolled back.
+type dispatchedAction struct {
+	action models.WorkspaceAction
+	event  models.WorkspaceStatusEvent
+}
+
+// SubmitWorkspaceAction adds an action to the workspace's action queue. An
+// idle workspace runs it right away, and an invalid status transition is
+// returned to the caller. A busy workspace queues it behind the running
+// action, coalescing it with the last queued one where possible. Actions
+// that boot the machine are checked against the quotas and budget first.
+// The actor is recorded with the action and the status changes it causes.
+func (s *WorkspaceService) SubmitWorkspaceAction(
+	ctx context.Context,
+	workspaceID, userID uint64,
+	action constants.WorkspaceAction,
+	actor enums.WorkspaceActor,
+) (models.WorkspaceAction, error) {
+	return s.SubmitWorkspaceActionWithReason(ctx, workspaceID, userID, action, actor, "")
+}
+
+// SubmitWorkspaceActionWithReason is SubmitWorkspaceAction for an actor that
+// explains the action. The reason is the message of the status change the
+// action starts with.
+func (s *WorkspaceService) SubmitWorkspaceActionWithReason(
+	ctx context.Context,
+	workspaceID, userID uint64,
+	action constants.WorkspaceAction,
+	actor enums.WorkspaceActor,
+	reason string,
+) (models.WorkspaceAction, error) {
+	var submitted models.WorkspaceAction
+	var dispatched *dispatchedAction
+	err := s.workspaceActionRepository.WithWorkspaceLock(ctx, workspaceID, func(tx *repositories.WorkspaceActionTx) error {
+		if workspaceBootActions[action] {
+			if err := s.reserveWorkspaceBoot(ctx, tx, workspaceID); err != nil {
+				return err
+			}
+		}
+
+		pending, err := tx.Action.GetPending(ctx, workspaceID)
+		if err != nil {
+			return err
+		}
+
+		if len(pending) == 0 {
+			submitted = models.WorkspaceAction{
+				WorkspaceID: workspaceID,
+				UserID:      userID,
+				Action:      action,
+				Actor:       actor,
+				Reason:      reason,
+			}
+			dispatched, err = s.dispatchAction(ctx, tx, &submitted)
+			return err
+		}
+
+		submitted, err = s.queueAction(ctx, tx.Action, pending, workspaceID, userID, action, actor, reason)
+		if err != nil {
+			return err
+		}
+
+		if pending[0].Status != enums.WorkspaceActionStatusRunning {
+			dispatched, err = s.dispatchNextAction(ctx, tx, workspaceID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return models.WorkspaceAction{}, err
+	}
+	return submitted, s.enqueueAction(ctx, dispatched)
+}
+
+// queueAction appends action to the queue. Repeating the last action is a
+// no-op, a start queued behind a stop turns the stop into a restart and a
+// terminate supersedes everything queued before it.
+func (s *WorkspaceService) queueAction(
+	ctx context.Context,
+	repo *repositories.WorkspaceActionRepository,
+	pending []models.WorkspaceAction,
+	workspaceID, userID uint64,
+	action constants.WorkspaceAction,
+	actor enums.WorkspaceActor,
+	reason string,
+) (models.WorkspaceAction, error) {
+	last := pending[len(pending)-1]
+
+	switch {
+	case last.Action == action:
+		return last, nil
+	case action == constants.ActionTerminate:
+		if _, err := repo.FinishQueued(ctx, workspaceID, enums.WorkspaceActionStatusCoalesced, "Superseded by terminate"); err != nil {
+			return models.WorkspaceAction{}, err
+		}
+	case last.Action == constants.ActionTerminate:
+		return models.WorkspaceAction{}, internalErrors.NewConflictError("WORKSPACE_TERMINATING", "Workspace is being terminated")
+	case last.Status == enums.WorkspaceActionStatusQueued && last.Action == constants.ActionStop && action == constants.ActionStart:
+		last.Action = constants.ActionRestart
+		last.UserID = userID
+		last.Actor = actor
+		last.Reason = reason
+		if err := repo.Update(ctx, &last); err != nil {
+			return models.WorkspaceAction{}, err
+		}
+		log.Printf("🔀 Coalesced queued stop and start of workspace %d into restart", workspaceID)
+		return last, nil
+	}
+
+	queued := models.WorkspaceAction{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Action:      action,
+		Status:      enums.WorkspaceActionStatusQueued,
+		Actor:       actor,
+		Reason:      reason,
+	}
+	if err := repo.Create(ctx, &queued); err != nil {
+		return models.WorkspaceAction{}, err
+	}
+	log.Printf("⏳ Queued workspace %s of workspace %d behind %s", action, workspaceID, pending[0].Action)
+	return queued, nil
+}
+
+// dispatchNextAction hands the oldest queued action to a worker. Actions
+// the workspace can no longer take are failed and skipped.
+func (s *WorkspaceService) dispatchNextAction(ctx context.Context, tx *repositories.WorkspaceActionTx, workspaceID uint64) (*dispatchedAction, error) {
+	for {
+		next, err := tx.Action.GetNextQueued(ctx, workspaceID)
+		if err != nil || next == nil {
+			return nil, err
+		}
+
+		dispatched, err := s.dispatchAction(ctx, tx, next)
+		if err == nil {
+			return dispatched, nil
+		}
+		if !IsInvalidStatusTransition(err) {
+			return nil, err
+		}
+		if err := tx.Action.Finish(ctx, next.ID, enums.WorkspaceActionStatusFailed, err.Error()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// dispatchAction moves the workspace to the action's status and marks the
+// action running under the task ID it will be enqueued with.
+func (s *WorkspaceService) dispatchAction(ctx context.Context, tx *repositories.WorkspaceActionTx, action *models.WorkspaceAction) (*dispatchedAction, error) {
+	status, ok := workspaceActionStatuses[action.Action]
+	if !ok {
+		return nil, fmt.Errorf("unknown action: %s", action.Action)
+	}
+
+	message := action.Reason
+	if message == "" {
+		message = fmt.Sprintf("Workspace %s is waiting for processing", status)
+	}
+	event := models.WorkspaceStatusEvent{
+		WorkspaceID: action.WorkspaceID,
+		Status:      status,
+		Message:     message,
+		Actor:       action.Actor,
+		ActorUserID: actionActorUserID(action),
+	}
+	if err := s.writeStatus(ctx, tx.Workspace, tx.StatusEvent, &event); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	action.Status = enums.WorkspaceActionStatusRunning
+	action.StartedAt = &now
+	if action.ID == 0 {
+		if err := tx.Action.Create(ctx, action); err != nil {
+			return nil, err
+		}
+	}
+	action.TaskID = workspaceActionTaskID(action.ID)
+	if err := tx.Action.Update(ctx, action); err != nil {
+		return nil, err
+	}
+	return &dispatchedAction{action: *action, event: event}, nil
+}
+
+// enqueueAction enqueues the task of a committed dispatch and announces its
+// status change. An action whose task can't be enqueued is failed along
+// with its workspace, which lets the next queued action through.
+func (s *WorkspaceService) enqueueAction(ctx context.Context, dispatched *dispatchedAction) error {
+	if dispatched == nil {
+		return nil
+	}
+	action := dispatched.action
+	s.announceStatus(ctx, &dispatched.event)
+
+	err := s.enqueueActionTask(action)
+	if err == nil {
+		return nil
+	}
+
+	if failErr := s.FailWorkspace(ctx, action.WorkspaceID, "ENQUEUE_FAILED", err.Error()); failErr != nil {
+		log.Printf("Failed to fail workspace %d: %v", action.WorkspaceID, failErr)
+	}
+	if finishErr := s.FinishWorkspaceAction(ctx, action.ID, enums.WorkspaceActionStatusFailed, err.Error()); finishErr != nil {
+		log.Printf("Failed to finish workspace action %d: %v", action.ID, finishErr)
+	}
+	return err
+}
+
+func (s *WorkspaceService) enqueueActionTask(action models.WorkspaceAction) error {
+	task, err := newWorkspaceActionTask(&action)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace %s job: %w", action.Action, err)
+	}
+
+	info, err := s.asynqClient.Enqueue(task, asynq.TaskID(action.TaskID), asynq.Timeout(s.actionTimeouts[action.Action]))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue workspace %s task: %w", action.Action, err)
+	}
+	log.Printf("✅ Enqueued workspace %s task: ID=%s queue=%s", action.Action, info.ID, info.Queue)
+	return nil
+}
+
+// FinishWorkspaceAction records the outcome of a running action and hands
+// the next queued action of the workspace to a worker.
+func (s *WorkspaceService) FinishWorkspaceAction(ctx context.Context, actionID uint64, status enums.WorkspaceActionStatus, message string) error {
+	action, err := s.workspaceActionRepository.GetByID(ctx, actionID)
+	if err != nil {
+		return err
+	}
+
+	var dispatched *dispatchedAction
+	err = s.workspaceActionRepository.WithWorkspaceLock(ctx, action.WorkspaceID, func(tx *repositories.WorkspaceActionTx) error {
+		if err := tx.Action.Finish(ctx, actionID, status, message); err != nil {
+			return err
+		}
+		dispatched, err = s.dispatchNextAction(ctx, tx, action.WorkspaceID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.enqueueAction(ctx, dispatched)
+}
+
+// RecoverStaleWorkspaceActions fails the running actions whose task is gone
+// from asynq, e.g. because the process that dispatched them died before
+// enqueueing it or a worker died before recording the outcome. Left
+// running, such an action would hold its workspace's queue forever.
+func (s *WorkspaceService) RecoverStaleWorkspaceActions(ctx context.Context) error {
+	actions, err := s.workspaceActionRepository.GetRunningStartedBefore(ctx, time.Now().Add(-staleActionAge))
+	if err != nil {
+		return fmt.Errorf("failed to load running actions: %w", err)
+	}
+
+	for _, action := range actions {
+		info, err := s.findActionTask(action.TaskID)
+		if err != nil {
+			log.Printf("Failed to look up the task of workspace action %d: %v", action.ID, err)
+			continue
+		}
+		if info != nil && info.State != asynq.TaskStateArchived && info.State != asynq.TaskStateCompleted {
+			continue
+		}
+
+		message := fmt.Sprintf("Workspace %s was lost by the worker", action.Action)
+		if err := s.FailWorkspace(ctx, action.WorkspaceID, "ACTION_LOST", message); err != nil && !IsInvalidStatusTransition(err) {
+			log.Printf("Failed to fail workspace %d: %v", action.WorkspaceID, err)
+		}
+		if err := s.FinishWorkspaceAction(ctx, action.ID, enums.WorkspaceActionStatusFailed, message); err != nil {
+			log.Printf("Failed to finish workspace action %d: %v", action.ID, err)
+			continue
+		}
+		log.Printf("🧹 Failed lost workspace %s action %d of workspace %d", action.Action, action.ID, action.WorkspaceID)
+	}
+	return nil
+}
+
+// findActionTask looks the task up in every queue and returns nil when no
+// queue has it.
+func (s *WorkspaceService) findActionTask(taskID string) (*asynq.TaskInfo, error) {
+	if taskID == "" {
+		return nil, nil
+	}
+
+	queues, err := s.asynqInspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task queues: %w", err)
+	}
+
+	for _, queue := range queues {
+		info, err := s.asynqInspector.GetTaskInfo(queue, taskID)
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect task %s: %w", taskID, err)
+		}
+		return info, nil
+	}
+	return nil, nil
+}
+
+// IsWorkspaceActionCancelRequested reports whether the action was cancelled
+// through the cancel endpoint.
+func (s *WorkspaceService) IsWorkspaceActionCancelRequested(ctx context.Context, actionID uint64) (bool, error) {
+	action, err := s.workspaceActionRepository.GetByID(ctx, actionID)
+	if err != nil {
+		return false, err
+	}
+	return action.CancelRequestedAt != nil, nil
+}
+
+// GetPendingWorkspaceActions returns the running and queued actions of the
+// workspace.
+func (s *WorkspaceService) GetPendingWorkspaceActions(ctx context.Context, workspaceID uint64) ([]dto.WorkspaceActionDTO, error) {
+	actions, err := s.workspaceActionRepository.GetPending(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToWorkspaceActionDTOs(actions), nil
+}
+
+// workspaceActionTaskID is the ID of the asynq task that runs the action,
+// known before the task is enqueued.
+func workspaceActionTaskID(actionID uint64) string {
+	return fmt.Sprintf("workspace-action-%d", actionID)
+}
+
+func newWorkspaceActionTask(action *models.WorkspaceAction) (*asynq.Task, error) {
+	switch action.Action {
+	case constants.ActionStart:
+		return tasks.NewStartWorkspaceTask(action.WorkspaceID, action.UserID, action.ID)
+	case constants.ActionStop:
+		return tasks.NewStopWorkspaceTask(action.WorkspaceID, action.UserID, action.ID)
+	case constants.ActionRestart:
+		return tasks.NewRestartWorkspaceTask(action.WorkspaceID, action.UserID, action.ID)
+	case constants.ActionRebuild:
+		return tasks.NewRebuildWorkspaceTask(action.WorkspaceID, action.UserID, action.ID)
+	case constants.ActionTerminate:
+		return tasks.NewTerminateWorkspaceTask(action.WorkspaceID, action.UserID, action.ID)
+	default:
+		return nil, fmt.Errorf("unknown action: %s", action.Action)
+	}
+}
+
+// actionActorUserID is the user or admin who triggered the action, nil
+// when it was neither.
+func actionActorUserID(action *models.WorkspaceAction) *uint64 {
+	if action.Actor != enums.WorkspaceActorUser && action.Actor != enums.WorkspaceActorAdmin {
+		return nil
+	}
+	userID := action.UserID
+	return &userID
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/tasks"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+type WorkspaceAdminServiceConfig struct {
+	Repositories *repositories.Repositories
+	Workspace    *WorkspaceService
+	AsynqClient  *asynq.Client
+}
+
+// WorkspaceAdminService lets the admins of an organization manage all of its
+// workspaces: list them, act on many at once and hand them over to another
+// user.
+type WorkspaceAdminService struct {
+	workspaceRepository              *repositories.WorkspaceRepository
+	bulkOperationRepository          *repositories.BulkOperationRepository
+	userRepository                   *repositories.UserRepository
+	userPreferenceRepository         *repositories.UserPreferenceRepository
+	gitPersonalAccessTokenRepository *repositories.GitPersonalAccessTokenRepository
+	workspaceShareRepository         *repositories.WorkspaceShareRepository
+	workspaceService                 *WorkspaceService
+	asynqClient                      *asynq.Client
+}
+
+func NewWorkspaceAdminService(config *WorkspaceAdminServiceConfig) *WorkspaceAdminService {
+	return &WorkspaceAdminService{
+		workspaceRepository:              config.Repositories.Workspace,
+		bulkOperationRepository:          config.Repositories.BulkOperation,
+		userRepository:                   config.Repositories.User,
+		userPreferenceRepository:         config.Repositories.UserPreference,
+		gitPersonalAccessTokenRepository: config.Repositories.GitPersonalAccessToken,
+		workspaceShareRepository:         config.Repositories.WorkspaceShare,
+		workspaceService:                 config.Workspace,
+		asynqClient:                      config.AsynqClient,
+	}
+}
+
+// GetWorkspaces lists the organization's workspaces matching the filter,
+// whoever owns them.
+func (s *WorkspaceAdminService) GetWorkspaces(ctx context.Context, organizationID uint32, filter requests.AdminWorkspaceFilter, with []string, page, limit int) (pagination.Pagination, error) {
+	pagination, err := s.workspaceRepository.GetOrganizationWorkspaces(ctx, organizationID, toWorkspaceFilter(filter), with, page, limit)
+	if err != nil {
+		return pagination, err
+	}
+
+	workspaces := pagination.Data.([]models.Workspace)
+	pagination.Data = dto.ToWorkspaceDTOs(workspaces)
+
+	return pagination, nil
+}
+
+// CreateBulkOperation records the action for every workspace matching the
+// filter now, so workspaces created later are left alone, and has a worker
+// apply it. An operation no worker will see is marked failed.
+func (s *WorkspaceAdminService) CreateBulkOperation(ctx context.Context, organizationID uint32, userID uint64, req requests.BulkWorkspaceActionRequest) (dto.BulkOperationDTO, error) {
+	ids, err := s.workspaceRepository.GetOrganizationWorkspaceIDs(ctx, organizationID, toWorkspaceFilter(req.Filter))
+	if err != nil {
+		return dto.BulkOperationDTO{}, err
+	}
+	if len(ids) == 0 {
+		return dto.BulkOperationDTO{}, internalErrors.NewValidationError("No workspace matches the filter",
+			map[string][]string{"filter": {"must match at least one workspace"}})
+	}
+
+	filter, err := json.Marshal(req.Filter)
+	if err != nil {
+		return dto.BulkOperationDTO{}, err
+	}
+
+	operation := models.BulkOperation{
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Action:         constants.WorkspaceAction(req.Action),
+		Filter:         string(filter),
+		Status:         enums.BulkOperationStatusPending,
+		Total:          len(ids),
+	}
+	for _, id := range ids {
+		operation.Items = append(operation.Items, models.BulkOperationItem{
+			WorkspaceID: id,
+			Status:      enums.BulkOperationItemStatusPending,
+		})
+	}
+	if err := s.bulkOperationRepository.Create(ctx, &operation); err != nil {
+		return dto.BulkOperationDTO{}, err
+	}
+
+	if err := s.enqueueBulkOperation(operation.ID); err != nil {
+		if failErr := s.bulkOperationRepository.Fail(ctx, operation.ID, "Failed to hand the operation to a worker", time.Now()); failErr != nil {
+			log.Printf("[bulk] failed to fail bulk operation %d: %v", operation.ID, failErr)
+		}
+		return dto.BulkOperationDTO{}, err
+	}
+
+	return dto.ToBulkOperationDTO(operation), nil
+}
+
+func (s *WorkspaceAdminService) enqueueBulkOperation(id uint64) error {
+	task, err := tasks.NewRunBulkOperationTask(id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.asynqClient.Enqueue(task); err != nil {
+		return fmt.Errorf("failed to enqueue bulk operation %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetBulkOperations lists the organization's bulk operations without their
+// items, latest first.
+func (s *WorkspaceAdminService) GetBulkOperations(ctx context.Context, organizationID uint32, page, limit int) (pagination.Pagination, error) {
+	pagination, err := s.bulkOperationRepository.GetAll(ctx, organizationID, page, limit)
+	if err != nil {
+		return pagination, err
+	}
+
+	operations := pagination.Data.([]models.BulkOperation)
+	pagination.Data = dto.ToBulkOperationDTOs(operations)
+
+	return pagination, nil
+}
+
+func (s *WorkspaceAdminService) GetBulkOperation(ctx context.Context, organizationID uint32, id uint64) (dto.BulkOperationDTO, error) {
+	operation, err := s.bulkOperationRepository.GetByID(ctx, organizationID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.BulkOperationDTO{}, internalErrors.NewNotFoundError("bulk operation")
+	}
+	if err != nil {
+		return dto.BulkOperationDTO{}, err
+	}
+	return dto.ToBulkOperationDTO(*operation), nil
+}
+
+// RunBulkOperation applies the operation's action to the workspaces it has
+// not been applied to yet, so a retried task picks up where it stopped.
+// Workspaces already in the target state are skipped. Failing to act on
+// one workspace, e.g. because of a quota, does not hold back the others.
+func (s *WorkspaceAdminService) RunBulkOperation(ctx context.Context, id uint64) error {
+	operation, err := s.bulkOperationRepository.GetByID(ctx, 0, id)
+	if err != nil {
+		return err
+	}
+	if operation.Status == enums.BulkOperationStatusCompleted {
+		return nil
+	}
+	if err := s.bulkOperationRepository.UpdateStatus(ctx, id, enums.BulkOperationStatusRunning); err != nil {
+		return err
+	}
+
+	var ids []uint64
+	for _, item := range operation.Items {
+		if item.Status == enums.BulkOperationItemStatusPending {
+			ids = append(ids, item.WorkspaceID)
+		}
+	}
+	workspaces, err := s.workspaceRepository.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint64]models.Workspace, len(workspaces))
+	for _, workspace := range workspaces {
+		byID[workspace.ID] = workspace
+	}
+
+	for _, item := range operation.Items {
+		if item.Status != enums.BulkOperationItemStatusPending {
+			continue
+		}
+
+		status, message := s.applyBulkAction(ctx, operation, item.WorkspaceID, byID)
+		if err := s.bulkOperationRepository.FinishItem(ctx, item.ID, status, message); err != nil {
+			return err
+		}
+	}
+
+	return s.bulkOperationRepository.Complete(ctx, id, time.Now())
+}
+
+func (s *WorkspaceAdminService) applyBulkAction(
+	ctx context.Context,
+	operation *models.BulkOperation,
+	workspaceID uint64,
+	workspaces map[uint64]models.Workspace,
+) (enums.BulkOperationItemStatus, string) {
+	workspace, ok := workspaces[workspaceID]
+	if !ok {
+		return enums.BulkOperationItemStatusSkipped, "Workspace no longer exists"
+	}
+
+	active := slices.Contains(enums.ActiveWorkspaceStatuses, workspace.Status)
+	req := requests.WorkspaceActionRequest{ID: workspace.ID, UserID: operation.UserID, Actor: enums.WorkspaceActorAdmin}
+	var err error
+	switch operation.Action {
+	case constants.ActionStart:
+		if active {
+			return enums.BulkOperationItemStatusSkipped, fmt.Sprintf("Workspace is already %s", workspace.Status)
+		}
+		_, err = s.workspaceService.StartWorkspace(ctx, req)
+	case constants.ActionStop:
+		if !active {
+			return enums.BulkOperationItemStatusSkipped, fmt.Sprintf("Workspace is already %s", workspace.Status)
+		}
+		// A pending workspace was never started, so there is nothing to stop.
+		if workspace.Status == enums.WorkspaceStatusPending {
+			return enums.BulkOperationItemStatusSkipped, "Workspace is still pending"
+		}
+		_, err = s.workspaceService.StopWorkspace(ctx, req)
+	case constants.ActionTerminate:
+		if workspace.Status == enums.WorkspaceStatusTerminating || workspace.Status == enums.WorkspaceStatusTerminated {
+			return enums.BulkOperationItemStatusSkipped, fmt.Sprintf("Workspace is already %s", workspace.Status)
+		}
+		_, err = s.workspaceService.TerminateWorkspace(ctx, req)
+	default:
+		err = fmt.Errorf("unknown action: %s", operation.Action)
+	}
+	if err != nil {
+		log.Printf("[bulk] failed to %s workspace %d in bulk operation %d: %v", operation.Action, workspace.ID, operation.ID, err)
+		return enums.BulkOperationItemStatusFailed, err.Error()
+	}
+	return enums.BulkOperationItemStatusSubmitted, ""
+}
+
+// ReassignWorkspace hands the workspace over to another user of the
+// organization, e.g. when its owner leaves. The workspace then clones with
+// the new owner's git access token; a share with the new owner is dropped as
+// they now own it.
+func (s *WorkspaceAdminService) ReassignWorkspace(ctx context.Context, organizationID uint32, workspaceID uint64, req requests.ReassignWorkspaceRequest) (dto.WorkspaceDTO, error) {
+	workspace, err := s.workspaceRepository.GetByID(ctx, workspaceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && workspace.OrganizationID != organizationID) {
+		return dto.WorkspaceDTO{}, internalErrors.NewNotFoundError("workspace")
+	}
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	user, err := s.userRepository.GetByID(ctx, req.UserID)
+	if err != nil || user.OrganizationID != uint64(organizationID) {
+		return dto.WorkspaceDTO{}, internalErrors.NewValidationError("Invalid owner",
+			map[string][]string{"user_id": {"must be a user of your organization"}})
+	}
+
+	tokenID := req.GitAccessTokenID
+	if tokenID == 0 {
+		preference, err := s.userPreferenceRepository.GetByUserID(ctx, user.ID)
+		if err != nil {
+			return dto.WorkspaceDTO{}, err
+		}
+		if preference == nil || preference.DefaultGitAccessTokenID == nil {
+			return dto.WorkspaceDTO{}, internalErrors.NewValidationError("Missing git access token",
+				map[string][]string{"git_access_token_id": {"is required when the user has no default git access token"}})
+		}
+		tokenID = *preference.DefaultGitAccessTokenID
+	}
+	if _, err := s.gitPersonalAccessTokenRepository.GetByID(ctx, user.ID, tokenID); err != nil {
+		return dto.WorkspaceDTO{}, internalErrors.NewValidationError("Invalid git access token",
+			map[string][]string{"git_access_token_id": {"must be one of the user's git access tokens"}})
+	}
+
+	if err := s.workspaceRepository.UpdateOwner(ctx, workspace.ID, user.ID, tokenID); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+	if _, err := s.workspaceShareRepository.Delete(ctx, workspace.ID, user.ID); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	return s.workspaceService.GetWorkspace(ctx, workspace.ID)
+}
+
+func toWorkspaceFilter(filter requests.AdminWorkspaceFilter) repositories.WorkspaceFilter {
+	return repositories.WorkspaceFilter{
+		UserID:          filter.UserID,
+		Status:          enums.WorkspaceStatus(filter.Status),
+		MachineConfigID: filter.MachineConfigID,
+		RepositoryID:    filter.RepositoryID,
+		TemplateID:      filter.TemplateID,
+		Search:          filter.Search,
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"clusterix-code/internal/data/enums"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	gitRefNamePattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+	gitCommitPattern      = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+	gitPullRequestPattern = regexp.MustCompile(`^[1-9][0-9]*$`)
+)
+
+// validateGitSource checks the git ref, subfolder and devcontainer path of a
+// workspace before they end up in a devpod source string, where "@" and a
+// leading "-" would change its meaning.
+func validateGitSource(refType enums.GitRefType, ref, subfolder, devcontainerPath string) error {
+	errs := map[string][]string{}
+
+	switch refType {
+	case "":
+	case enums.GitRefTypeBranch, enums.GitRefTypeTag:
+		if !gitRefNamePattern.MatchString(ref) || strings.Contains(ref, "..") {
+			errs["git_ref"] = append(errs["git_ref"], "must be a valid branch or tag name")
+		}
+	case enums.GitRefTypeCommit:
+		if !gitCommitPattern.MatchString(ref) {
+			errs["git_ref"] = append(errs["git_ref"], "must be a commit SHA of 7 to 40 hex digits")
+		}
+	case enums.GitRefTypePullRequest:
+		if !gitPullRequestPattern.MatchString(ref) {
+			errs["git_ref"] = append(errs["git_ref"], "must be a pull request number")
+		}
+	default:
+		errs["git_ref_type"] = append(errs["git_ref_type"], "must be one of branch, tag, commit, pull_request")
+	}
+
+	if subfolder != "" && !isRepositoryPath(subfolder) {
+		errs["subfolder"] = append(errs["subfolder"], "must be a path inside the repository")
+	}
+	if devcontainerPath != "" && !isRepositoryPath(devcontainerPath) {
+		errs["devcontainer_path"] = append(errs["devcontainer_path"], "must be a path inside the repository")
+	}
+
+	if len(errs) > 0 {
+		return internalErrors.NewValidationError("Invalid git source", errs)
+	}
+	return nil
+}
+
+// isRepositoryPath reports whether p is a relative path that stays inside
+// the repository.
+func isRepositoryPath(p string) bool {
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, "-") || strings.ContainsAny(p, "@\\\r\n") {
+		return false
+	}
+	cleaned := path.Clean(p)
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
@@ -7,8 +7,12 @@ import (
 	"clusterix-code/internal/data/dto"
 	"clusterix-code/internal/data/models"
 	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
 	"context"
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type WorkspaceLogServiceConfig struct {
@@ -17,11 +21,13 @@ type WorkspaceLogServiceConfig struct {
 
 type WorkspaceLogService struct {
 	workspaceLogRepository *repositories.WorkspaceLogRepository
+	workspaceRunRepository *repositories.WorkspaceRunRepository
 }
 
 func NewWorkspaceLogService(config *WorkspaceLogServiceConfig) *WorkspaceLogService {
 	return &WorkspaceLogService{
 		workspaceLogRepository: config.Repositories.WorkspaceLog,
+		workspaceRunRepository: config.Repositories.WorkspaceRun,
 	}
 }
 
@@ -34,6 +40,22 @@ func (s *WorkspaceLogService) GetWorkspaceLogs(ctx context.Context, workspaceId
 	return dto.ToWorkspaceLogDTOs(logs), nil
 }
 
+// GetRunLogs returns the log lines of one of the workspace's runs.
+func (s *WorkspaceLogService) GetRunLogs(ctx context.Context, workspaceID, runID uint64) ([]*dto.WorkspaceLogDTO, error) {
+	if _, err := s.workspaceRunRepository.GetByID(ctx, workspaceID, runID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, internalErrors.NewNotFoundError("run")
+		}
+		return nil, err
+	}
+
+	logs, err := s.workspaceLogRepository.GetRunLogs(ctx, workspaceID, runID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToWorkspaceLogDTOs(logs), nil
+}
+
 func (s *WorkspaceLogService) Create(ctx context.Context, log requests.CreateWorkspaceLogRequest) error {
 	workspaceLog := models.WorkspaceLog{
 		WorkspaceID: log.WorkspaceID,
@@ -41,6 +63,7 @@ func (s *WorkspaceLogService) Create(ctx context.Context, log requests.CreateWor
 		Type:        log.Type,
 		Time:        log.Time,
 		CreatedAt:   time.Now(),
+		RunID:       log.RunID,
 	}
 	return s.workspaceLogRepository.Create(ctx, &workspaceLog)
 }
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	"clusterix-code/internal/services/devpod"
+	"clusterix-code/internal/tasks"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxWorkspacePorts bounds how many ports a workspace can expose.
+	maxWorkspacePorts = 20
+	// reforwardDelay is how long the worker waits before forwarding a port
+	// again whose tunnel dropped.
+	reforwardDelay = 30 * time.Second
+)
+
+type WorkspacePortServiceConfig struct {
+	Repositories *repositories.Repositories
+	Backend      devpod.WorkspaceBackend
+	AsynqClient  *asynq.Client
+}
+
+// WorkspacePortService manages the application ports workspaces expose
+// through the reverse proxy, next to their IDE. The worker forwards the
+// ports of running workspaces; the forwards live in its memory.
+type WorkspacePortService struct {
+	workspacePortRepository *repositories.WorkspacePortRepository
+	workspaceRepository     *repositories.WorkspaceRepository
+	backend                 devpod.WorkspaceBackend
+	asynqClient             *asynq.Client
+
+	mu         sync.Mutex
+	forwards   map[uint64]map[int]*portForward // workspace ID -> port -> forward
+	syncing    map[uint64]*sync.Mutex          // workspace ID -> lock of its syncs
+	generation uint64
+}
+
+// portForward is a forward of a port, told apart from the forwards that
+// came before or after it by its generation.
+type portForward struct {
+	generation uint64
+	cancel     context.CancelFunc
+}
+
+func NewWorkspacePortService(config *WorkspacePortServiceConfig) *WorkspacePortService {
+	return &WorkspacePortService{
+		workspacePortRepository: config.Repositories.WorkspacePort,
+		workspaceRepository:     config.Repositories.Workspace,
+		backend:                 config.Backend,
+		asynqClient:             config.AsynqClient,
+		forwards:                make(map[uint64]map[int]*portForward),
+		syncing:                 make(map[uint64]*sync.Mutex),
+	}
+}
+
+func (s *WorkspacePortService) GetPorts(ctx context.Context, workspace dto.WorkspaceDTO) ([]dto.WorkspacePortDTO, error) {
+	ports, err := s.workspacePortRepository.GetByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToWorkspacePortDTOs(ports, workspace.URL), nil
+}
+
+// GetPort returns a declared port of the workspace, for the reverse proxy.
+func (s *WorkspacePortService) GetPort(ctx context.Context, workspaceID uint64, port int) (*models.WorkspacePort, error) {
+	return s.getPort(ctx, workspaceID, port)
+}
+
+// CreatePort declares a port of the workspace. A running workspace has it
+// forwarded right away.
+func (s *WorkspacePortService) CreatePort(ctx context.Context, workspace dto.WorkspaceDTO, req requests.CreateWorkspacePortRequest) (dto.WorkspacePortDTO, error) {
+	if _, err := s.workspacePortRepository.GetByPort(ctx, workspace.ID, req.Port); err == nil {
+		return dto.WorkspacePortDTO{}, internalErrors.NewConflictError("PORT_ALREADY_DECLARED",
+			fmt.Sprintf("Port %d is already exposed", req.Port))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.WorkspacePortDTO{}, err
+	}
+
+	count, err := s.workspacePortRepository.CountByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return dto.WorkspacePortDTO{}, err
+	}
+	if count >= maxWorkspacePorts {
+		return dto.WorkspacePortDTO{}, internalErrors.NewValidationError(
+			fmt.Sprintf("A workspace can expose at most %d ports", maxWorkspacePorts),
+			map[string][]string{"port": {"too many ports"}})
+	}
+
+	port := models.WorkspacePort{
+		WorkspaceID: workspace.ID,
+		Port:        req.Port,
+		Label:       req.Label,
+		Visibility:  enums.WorkspacePortVisibility(req.Visibility),
+		Source:      enums.WorkspacePortSourceAPI,
+	}
+	if port.Visibility == "" {
+		port.Visibility = enums.WorkspacePortVisibilityPrivate
+	}
+	if err := s.workspacePortRepository.Create(ctx, &port); err != nil {
+		return dto.WorkspacePortDTO{}, err
+	}
+
+	s.requestForwarding(workspace)
+	return dto.ToWorkspacePortDTO(port, workspace.URL), nil
+}
+
+// UpdatePort changes the label and visibility of a port. The proxy applies
+// the visibility from the next request on.
+func (s *WorkspacePortService) UpdatePort(ctx context.Context, workspace dto.WorkspaceDTO, number int, req requests.UpdateWorkspacePortRequest) (dto.WorkspacePortDTO, error) {
+	port, err := s.getPort(ctx, workspace.ID, number)
+	if err != nil {
+		return dto.WorkspacePortDTO{}, err
+	}
+
+	port.Label = req.Label
+	port.Visibility = enums.WorkspacePortVisibility(req.Visibility)
+	if err := s.workspacePortRepository.Update(ctx, port); err != nil {
+		return dto.WorkspacePortDTO{}, err
+	}
+	return dto.ToWorkspacePortDTO(*port, workspace.URL), nil
+}
+
+// DeletePort stops exposing a port. Ports from the devcontainer.json are
+// declared again the next time the workspace starts.
+func (s *WorkspacePortService) DeletePort(ctx context.Context, workspace dto.WorkspaceDTO, number int) error {
+	port, err := s.getPort(ctx, workspace.ID, number)
+	if err != nil {
+		return err
+	}
+	devpodWorkspaceDTO := dto.DevpodWorkspace{DevpodWorkspaceId: workspace.ID, Fingerprint: workspace.Fingerprint}
+	if err := s.deletePort(ctx, devpodWorkspaceDTO, *port); err != nil {
+		return err
+	}
+
+	s.requestForwarding(workspace)
+	return nil
+}
+
+// WorkspaceStatusChanged has the worker forward the ports of a workspace
+// that is now running, after reading its devcontainer.json, and stop
+// forwarding those of a workspace that no longer runs.
+func (s *WorkspacePortService) WorkspaceStatusChanged(ctx context.Context, workspaceID uint64, status enums.WorkspaceStatus) {
+	if status != enums.WorkspaceStatusRunning {
+		count, err := s.workspacePortRepository.CountByWorkspaceID(ctx, workspaceID)
+		if err != nil {
+			log.Printf("[ports] failed to load the ports of workspace %d: %v", workspaceID, err)
+			return
+		}
+		if count == 0 {
+			return
+		}
+	}
+
+	if err := s.enqueueForwarding(workspaceID, status == enums.WorkspaceStatusRunning); err != nil {
+		log.Printf("[ports] %v", err)
+	}
+}
+
+// ResetForwards runs when the worker starts, which forwards no port yet.
+// The worker ports recorded by an earlier run are cleared, as the proxy
+// would otherwise reach whatever now listens on them, and the ports of
+// running workspaces are forwarded again.
+func (s *WorkspacePortService) ResetForwards(ctx context.Context) error {
+	if err := s.workspacePortRepository.ClearAllWorkerPorts(ctx); err != nil {
+		return fmt.Errorf("failed to clear worker ports: %w", err)
+	}
+
+	workspaceIDs, err := s.workspacePortRepository.GetRunningWorkspaceIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load running workspaces with ports: %w", err)
+	}
+	for _, workspaceID := range workspaceIDs {
+		if err := s.enqueueForwarding(workspaceID, false); err != nil {
+			log.Printf("[ports] %v", err)
+		}
+	}
+	return nil
+}
+
+// SyncForwards forwards every declared port of the workspace while it runs
+// and stops the forwards of ports no longer declared, or of a workspace
+// that no longer runs. It runs on the worker, whose ports the reverse proxy
+// reaches the forwards on. Syncs of the same workspace run one at a time.
+func (s *WorkspacePortService) SyncForwards(ctx context.Context, workspaceID uint64, readDevcontainer bool) error {
+	unlock := s.lockSync(workspaceID)
+	defer unlock()
+
+	workspace, err := s.workspaceRepository.GetByIDIncludingDeleted(ctx, workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to load workspace %d: %w", workspaceID, err)
+	}
+	running := workspace.Status == enums.WorkspaceStatusRunning && !workspace.DeletedAt.Valid
+	devpodWorkspaceDTO := dto.DevpodWorkspace{
+		DevpodWorkspaceId: workspace.ID,
+		Fingerprint:       workspace.Fingerprint,
+		DevcontainerPath:  workspace.DevcontainerPath,
+	}
+
+	if running && readDevcontainer {
+		if err := s.syncDevcontainerPorts(ctx, devpodWorkspaceDTO); err != nil {
+			log.Printf("[ports] failed to read the forwarded ports of workspace %d: %v", workspaceID, err)
+		}
+	}
+
+	ports, err := s.workspacePortRepository.GetByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to load the ports of workspace %d: %w", workspaceID, err)
+	}
+	declared := make(map[int]models.WorkspacePort, len(ports))
+	if running {
+		for _, port := range ports {
+			declared[port.Port] = port
+		}
+	}
+
+	s.mu.Lock()
+	for number, forward := range s.forwards[workspaceID] {
+		if _, ok := declared[number]; !ok {
+			forward.cancel()
+			delete(s.forwards[workspaceID], number)
+		}
+	}
+	if len(s.forwards[workspaceID]) == 0 {
+		delete(s.forwards, workspaceID)
+	}
+	s.mu.Unlock()
+
+	for _, port := range ports {
+		if _, ok := declared[port.Port]; !ok {
+			// Ports of a workspace that doesn't run are forwarded nowhere,
+			// whatever the worker that forwarded them last.
+			if port.WorkerPort != nil {
+				s.clearWorkerPort(port, *port.WorkerPort)
+			}
+			continue
+		}
+		if err := s.forward(devpodWorkspaceDTO, port); err != nil {
+			log.Printf("[ports] failed to forward port %d of workspace %d: %v", port.Port, workspaceID, err)
+		}
+	}
+	return nil
+}
+
+// lockSync takes the lock of the workspace's syncs and returns its unlock.
+func (s *WorkspacePortService) lockSync(workspaceID uint64) func() {
+	s.mu.Lock()
+	lock, ok := s.syncing[workspaceID]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.syncing[workspaceID] = lock
+	}
+	s.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// forward starts forwarding the port unless it already is, and maps it to
+// a port on the worker the reverse proxy can reach. The mapping is keyed by
+// the forward's generation, so tearing down a forward never touches the one
+// that replaced it. A tunnel that drops on its own is forwarded again.
+func (s *WorkspacePortService) forward(devpodWorkspaceDTO dto.DevpodWorkspace, port models.WorkspacePort) error {
+	workspaceID := devpodWorkspaceDTO.DevpodWorkspaceId
+
+	s.mu.Lock()
+	if _, ok := s.forwards[workspaceID][port.Port]; ok {
+		s.mu.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.generation++
+	forward := &portForward{generation: s.generation, cancel: cancel}
+	if s.forwards[workspaceID] == nil {
+		s.forwards[workspaceID] = make(map[int]*portForward)
+	}
+	s.forwards[workspaceID][port.Port] = forward
+	s.mu.Unlock()
+
+	stop := func() {
+		cancel()
+		s.mu.Lock()
+		if s.forwards[workspaceID][port.Port] == forward {
+			delete(s.forwards[workspaceID], port.Port)
+			if len(s.forwards[workspaceID]) == 0 {
+				delete(s.forwards, workspaceID)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	tunnel, err := s.backend.ForwardPort(ctx, devpodWorkspaceDTO, port.Port)
+	if err != nil {
+		stop()
+		return err
+	}
+
+	key := fmt.Sprintf("%d:%d:%d", workspaceID, port.Port, forward.generation)
+	mapping, err := MapWorkspace(key, tunnel.LocalPort)
+	if err != nil {
+		stop()
+		<-tunnel.Done
+		return err
+	}
+	if err := s.workspacePortRepository.SetWorkerPort(ctx, port.ID, mapping.ExternalPort); err != nil {
+		log.Printf("[ports] failed to record the worker port of port %d of workspace %d: %v", port.Port, workspaceID, err)
+	}
+	log.Printf("[ports] forwarding port %d of workspace %d to worker port %d", port.Port, workspaceID, mapping.ExternalPort)
+
+	go func() {
+		dropped := false
+		select {
+		case <-tunnel.Done:
+			dropped = ctx.Err() == nil
+		case <-ctx.Done():
+			<-tunnel.Done
+		}
+		// The worker port is cleared before it is freed, so no new
+		// forward can record it in between.
+		s.clearWorkerPort(port, mapping.ExternalPort)
+		UnmapWorkspace(key)
+		stop()
+		log.Printf("[ports] stopped forwarding port %d of workspace %d", port.Port, workspaceID)
+
+		if dropped {
+			if err := s.enqueueForwarding(workspaceID, false, asynq.ProcessIn(reforwardDelay)); err != nil {
+				log.Printf("[ports] %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// clearWorkerPort records that the port is no longer forwarded to the
+// worker port, unless a newer forward recorded another one since.
+func (s *WorkspacePortService) clearWorkerPort(port models.WorkspacePort, workerPort int) {
+	if err := s.workspacePortRepository.ClearWorkerPort(context.Background(), port.ID, workerPort); err != nil {
+		log.Printf("[ports] failed to clear the worker port of port %d of workspace %d: %v", port.Port, port.WorkspaceID, err)
+	}
+}
+
+// syncDevcontainerPorts declares the ports the workspace's devcontainer.json
+// forwards and removes the ones it no longer does. Ports declared through the
+// API are left as they are.
+func (s *WorkspacePortService) syncDevcontainerPorts(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace) error {
+	numbers, err := s.backend.DevcontainerPorts(ctx, devpodWorkspaceDTO)
+	if err != nil {
+		return err
+	}
+	workspaceID := devpodWorkspaceDTO.DevpodWorkspaceId
+
+	ports, err := s.workspacePortRepository.GetByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[int]models.WorkspacePort, len(ports))
+	for _, port := range ports {
+		existing[port.Port] = port
+	}
+
+	wanted := make(map[int]bool, len(numbers))
+	for _, number := range numbers {
+		wanted[number] = true
+		if _, ok := existing[number]; ok || len(existing) >= maxWorkspacePorts {
+			continue
+		}
+		port := models.WorkspacePort{
+			WorkspaceID: workspaceID,
+			Port:        number,
+			Visibility:  enums.WorkspacePortVisibilityPrivate,
+			Source:      enums.WorkspacePortSourceDevcontainer,
+		}
+		if err := s.workspacePortRepository.Create(ctx, &port); err != nil {
+			return err
+		}
+		existing[number] = port
+	}
+
+	for _, port := range ports {
+		if port.Source == enums.WorkspacePortSourceDevcontainer && !wanted[port.Port] {
+			if err := s.deletePort(ctx, devpodWorkspaceDTO, port); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deletePort removes a declared port along with its DNS record.
+func (s *WorkspacePortService) deletePort(ctx context.Context, devpodWorkspaceDTO dto.DevpodWorkspace, port models.WorkspacePort) error {
+	if err := s.workspacePortRepository.Delete(ctx, port.ID); err != nil {
+		return err
+	}
+	if err := s.backend.DeletePortDNSRecord(ctx, devpodWorkspaceDTO, port.Port); err != nil {
+		log.Printf("[ports] failed to delete the DNS record of port %d of workspace %d: %v", port.Port, port.WorkspaceID, err)
+	}
+	return nil
+}
+
+// requestForwarding has the worker update the forwards of a running
+// workspace after its ports changed.
+func (s *WorkspacePortService) requestForwarding(workspace dto.WorkspaceDTO) {
+	if workspace.Status != string(enums.WorkspaceStatusRunning) {
+		return
+	}
+	if err := s.enqueueForwarding(workspace.ID, false); err != nil {
+		log.Printf("[ports] %v", err)
+	}
+}
+
+func (s *WorkspacePortService) enqueueForwarding(workspaceID uint64, readDevcontainer bool, opts ...asynq.Option) error {
+	task, err := tasks.NewForwardWorkspacePortsTask(workspaceID, readDevcontainer)
+	if err != nil {
+		return err
+	}
+	if _, err := s.asynqClient.Enqueue(task, append([]asynq.Option{asynq.MaxRetry(3)}, opts...)...); err != nil {
+		return fmt.Errorf("failed to enqueue the port forwarding of workspace %d: %w", workspaceID, err)
+	}
+	return nil
+}
+
+func (s *WorkspacePortService) getPort(ctx context.Context, workspaceID uint64, number int) (*models.WorkspacePort, error) {
+	port, err := s.workspacePortRepository.GetByPort(ctx, workspaceID, number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, internalErrors.NewNotFoundError("port")
+	}
+	return port, err
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"time"
+)
+
+// StartWorkspaceRun records that a worker started running the action. Runs
+// of the workspace still marked running were left behind by a worker that
+// went away, as only one action of a workspace runs at a time.
+func (s *WorkspaceService) StartWorkspaceRun(
+	ctx context.Context,
+	workspaceID, actionID uint64,
+	action constants.WorkspaceAction,
+	taskID string,
+	attempt int,
+) (uint64, error) {
+	now := time.Now()
+	if err := s.workspaceRunRepository.InterruptRunning(ctx, workspaceID, now); err != nil {
+		return 0, err
+	}
+
+	run := models.WorkspaceRun{
+		WorkspaceID: workspaceID,
+		Action:      action,
+		Actor:       enums.WorkspaceActorSystem,
+		TaskID:      taskID,
+		Attempt:     attempt,
+		Status:      enums.WorkspaceRunStatusRunning,
+		StartedAt:   now,
+	}
+	if actionID != 0 {
+		workspaceAction, err := s.workspaceActionRepository.GetByID(ctx, actionID)
+		if err != nil {
+			return 0, err
+		}
+		run.ActionID = &workspaceAction.ID
+		run.Actor = workspaceAction.Actor
+		run.ActorUserID = actionActorUserID(workspaceAction)
+	}
+
+	if err := s.workspaceRunRepository.Create(ctx, &run); err != nil {
+		return 0, err
+	}
+	return run.ID, nil
+}
+
+// FinishWorkspaceRun records the outcome of a run, with the failure code and
+// reason of a failed one.
+func (s *WorkspaceService) FinishWorkspaceRun(ctx context.Context, runID uint64, status enums.WorkspaceRunStatus, errorCode, errorReason string) error {
+	return s.workspaceRunRepository.Finish(ctx, runID, status, errorCode, errorReason, time.Now())
+}
+
+// GetWorkspaceRuns lists the workspace's runs, latest first.
+func (s *WorkspaceService) GetWorkspaceRuns(ctx context.Context, workspaceID uint64, page, limit int) (pagination.Pagination, error) {
+	pagination, err := s.workspaceRunRepository.GetByWorkspaceID(ctx, workspaceID, page, limit)
+	if err != nil {
+		return pagination, err
+	}
+
+	runs := pagination.Data.([]models.WorkspaceRun)
+	pagination.Data = dto.ToWorkspaceRunDTOs(runs)
+
+	return pagination, nil
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/constants"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// scheduleWeekdays maps the day names schedules are written with to
+// weekdays.
+var scheduleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type WorkspaceScheduleServiceConfig struct {
+	Repositories *repositories.Repositories
+	Workspace    *WorkspaceService
+}
+
+// WorkspaceScheduleService manages the start and stop schedules of
+// workspaces and templates and runs them.
+type WorkspaceScheduleService struct {
+	workspaceScheduleRepository *repositories.WorkspaceScheduleRepository
+	workspaceRepository         *repositories.WorkspaceRepository
+	workspaceService            *WorkspaceService
+}
+
+func NewWorkspaceScheduleService(config *WorkspaceScheduleServiceConfig) *WorkspaceScheduleService {
+	return &WorkspaceScheduleService{
+		workspaceScheduleRepository: config.Repositories.WorkspaceSchedule,
+		workspaceRepository:         config.Repositories.Workspace,
+		workspaceService:            config.Workspace,
+	}
+}
+
+func (s *WorkspaceScheduleService) GetSchedules(ctx context.Context, scope enums.WorkspaceScheduleScope, scopeID uint64) ([]dto.WorkspaceScheduleDTO, error) {
+	schedules, err := s.workspaceScheduleRepository.GetByScope(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToWorkspaceScheduleDTOs(schedules), nil
+}
+
+func (s *WorkspaceScheduleService) CreateSchedule(
+	ctx context.Context,
+	scope enums.WorkspaceScheduleScope,
+	scopeID uint64,
+	organizationID uint32,
+	userID uint64,
+	req requests.CreateWorkspaceScheduleRequest,
+) (dto.WorkspaceScheduleDTO, error) {
+	if err := validateSchedule(req); err != nil {
+		return dto.WorkspaceScheduleDTO{}, err
+	}
+
+	schedule := models.WorkspaceSchedule{
+		OrganizationID: organizationID,
+		Scope:          scope,
+		ScopeID:        scopeID,
+		CreatedByID:    userID,
+	}
+	applyScheduleRequest(&schedule, req)
+
+	if err := s.workspaceScheduleRepository.Create(ctx, &schedule); err != nil {
+		return dto.WorkspaceScheduleDTO{}, err
+	}
+	return dto.ToWorkspaceScheduleDTO(schedule), nil
+}
+
+// UpdateSchedule replaces a schedule. Times that already passed are not
+// acted on, so the change takes effect from the next start or stop.
+func (s *WorkspaceScheduleService) UpdateSchedule(
+	ctx context.Context,
+	scope enums.WorkspaceScheduleScope,
+	scopeID uint64,
+	scheduleID uint64,
+	req requests.UpdateWorkspaceScheduleRequest,
+) (dto.WorkspaceScheduleDTO, error) {
+	schedule, err := s.getSchedule(ctx, scope, scopeID, scheduleID)
+	if err != nil {
+		return dto.WorkspaceScheduleDTO{}, err
+	}
+	if err := validateSchedule(req); err != nil {
+		return dto.WorkspaceScheduleDTO{}, err
+	}
+
+	applyScheduleRequest(schedule, req)
+	schedule.LastAppliedAt = nil
+
+	if err := s.workspaceScheduleRepository.Update(ctx, schedule); err != nil {
+		return dto.WorkspaceScheduleDTO{}, err
+	}
+	return dto.ToWorkspaceScheduleDTO(*schedule), nil
+}
+
+func (s *WorkspaceScheduleService) DeleteSchedule(ctx context.Context, scope enums.WorkspaceScheduleScope, scopeID uint64, scheduleID uint64) error {
+	if _, err := s.getSchedule(ctx, scope, scopeID, scheduleID); err != nil {
+		return err
+	}
+	return s.workspaceScheduleRepository.Delete(ctx, scheduleID)
+}
+
+// RunSchedules acts on the schedules whose latest start or stop has not been
+// acted on yet. Only the latest one counts, so a scheduler that was down
+// catches up with a single action. Workspaces already in the target state,
+// or busy with something else, are left alone.
+func (s *WorkspaceScheduleService) RunSchedules(ctx context.Context) error {
+	schedules, err := s.workspaceScheduleRepository.GetEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load schedules: %w", err)
+	}
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	// Workspaces with schedules of their own don't follow their template's.
+	scheduledIDs, err := s.workspaceScheduleRepository.GetScheduledWorkspaceIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled workspaces: %w", err)
+	}
+	ownSchedule := make(map[uint64]bool, len(scheduledIDs))
+	for _, id := range scheduledIDs {
+		ownSchedule[id] = true
+	}
+
+	now := time.Now()
+	for _, schedule := range schedules {
+		action, at, ok := lastScheduledAction(schedule, now)
+		if !ok || !at.After(scheduleAppliedUntil(schedule)) {
+			continue
+		}
+
+		if err := s.runSchedule(ctx, schedule, action, ownSchedule); err != nil {
+			log.Printf("[schedules] failed to run schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if err := s.workspaceScheduleRepository.MarkApplied(ctx, schedule.ID, now); err != nil {
+			log.Printf("[schedules] failed to mark schedule %d as applied: %v", schedule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *WorkspaceScheduleService) runSchedule(
+	ctx context.Context,
+	schedule models.WorkspaceSchedule,
+	action constants.WorkspaceAction,
+	ownSchedule map[uint64]bool,
+) error {
+	var workspaces []models.Workspace
+	var err error
+	if schedule.Scope == enums.WorkspaceScheduleScopeTemplate {
+		workspaces, err = s.workspaceRepository.GetByTemplateID(ctx, schedule.ScopeID)
+	} else {
+		workspaces, err = s.workspaceRepository.GetByIDs(ctx, []uint64{schedule.ScopeID})
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, workspace := range workspaces {
+		if schedule.Scope == enums.WorkspaceScheduleScopeTemplate && ownSchedule[workspace.ID] {
+			continue
+		}
+
+		// Failing to act on one workspace, e.g. because of a quota, does not
+		// hold back the others.
+		req := requests.WorkspaceActionRequest{
+			ID:     workspace.ID,
+			UserID: workspace.UserID,
+			Actor:  enums.WorkspaceActorScheduler,
+		}
+		switch {
+		case action == constants.ActionStart && workspace.Status == enums.WorkspaceStatusStopped:
+			log.Printf("⏰ Starting workspace %d on schedule %d", workspace.ID, schedule.ID)
+			_, err = s.workspaceService.StartWorkspace(ctx, req)
+		case action == constants.ActionStop && workspace.Status == enums.WorkspaceStatusRunning:
+			log.Printf("⏰ Stopping workspace %d on schedule %d", workspace.ID, schedule.ID)
+			_, err = s.workspaceService.StopWorkspace(ctx, req)
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("[schedules] failed to %s workspace %d: %v", action, workspace.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *WorkspaceScheduleService) getSchedule(ctx context.Context, scope enums.WorkspaceScheduleScope, scopeID uint64, scheduleID uint64) (*models.WorkspaceSchedule, error) {
+	schedule, err := s.workspaceScheduleRepository.GetByID(ctx, scope, scopeID, scheduleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, internalErrors.NewNotFoundError("schedule")
+	}
+	return schedule, err
+}
+
+func validateSchedule(req requests.CreateWorkspaceScheduleRequest) error {
+	if req.StartTime == nil && req.StopTime == nil {
+		return internalErrors.NewValidationError("A schedule needs a start or a stop time",
+			map[string][]string{"start_time": {"required without stop_time"}})
+	}
+	if req.StartTime != nil && req.StopTime != nil && *req.StartTime == *req.StopTime {
+		return internalErrors.NewValidationError("A schedule can't start and stop at the same time",
+			map[string][]string{"stop_time": {"must differ from start_time"}})
+	}
+	return nil
+}
+
+func applyScheduleRequest(schedule *models.WorkspaceSchedule, req requests.CreateWorkspaceScheduleRequest) {
+	schedule.Days = req.Days
+	schedule.StartTime = req.StartTime
+	schedule.StopTime = req.StopTime
+	schedule.Timezone = req.Timezone
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+}
+
+// scheduleAppliedUntil is the time up to which the schedule was acted on.
+// Times from before it was last saved are never acted on.
+func scheduleAppliedUntil(schedule models.WorkspaceSchedule) time.Time {
+	if schedule.LastAppliedAt != nil && schedule.LastAppliedAt.After(schedule.UpdatedAt) {
+		return *schedule.LastAppliedAt
+	}
+	return schedule.UpdatedAt
+}
+
+// lastScheduledAction returns the schedule's latest start or stop at or
+// before now, looking back a week.
+func lastScheduledAction(schedule models.WorkspaceSchedule, now time.Time) (constants.WorkspaceAction, time.Time, bool) {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	days := make(map[time.Weekday]bool, len(schedule.Days))
+	for _, day := range schedule.Days {
+		if weekday, ok := scheduleWeekdays[day]; ok {
+			days[weekday] = true
+		}
+	}
+
+	local := now.In(location)
+	for back := 0; back <= 7; back++ {
+		day := local.AddDate(0, 0, -back)
+		if !days[day.Weekday()] {
+			continue
+		}
+
+		var action constants.WorkspaceAction
+		var latest time.Time
+		for _, transition := range []struct {
+			clock  *string
+			action constants.WorkspaceAction
+		}{
+			{schedule.StartTime, constants.ActionStart},
+			{schedule.StopTime, constants.ActionStop},
+		} {
+			if transition.clock == nil {
+				continue
+			}
+			at, err := time.ParseInLocation("2006-01-02 15:04", day.Format("2006-01-02 ")+*transition.clock, location)
+			if err != nil || at.After(now) || at.Before(latest) {
+				continue
+			}
+			action, latest = transition.action, at
+		}
+		if action != "" {
+			return action, latest, true
+		}
+	}
+	return "", time.Time{}, false
+}
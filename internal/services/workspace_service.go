@@ -8,27 +8,42 @@ import (
 	"clusterix-code/internal/data/models"
 	"clusterix-code/internal/data/repositories"
 	"clusterix-code/internal/services/devpod"
-	"clusterix-code/internal/tasks"
-	"clusterix-code/internal/utils/aws"
+	internalErrors "clusterix-code/internal/utils/errors"
 	"clusterix-code/internal/utils/pagination"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hibiken/asynq"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	statusUpdateAttempts        = 3
+	invalidStatusTransitionCode = "INVALID_STATUS_TRANSITION"
+)
+
 type WorkspaceServiceConfig struct {
 	Repositories    *repositories.Repositories
 	Publisher       *PublisherService
 	Socket          *SocketService
 	WorkspaceConfig *WorkspaceConfigService
-	Devpod          *devpod.DevpodService
+	EnvVar          *EnvVarService
+	UserPreference  *UserPreferenceService
+	Quota           *QuotaService
+	Budget          *BudgetService
+	Webhook         *WebhookService
+	WorkspacePort   *WorkspacePortService
+	Backend         devpod.WorkspaceBackend
 	AsynqClient     *asynq.Client
+	AsynqInspector  *asynq.Inspector
+	// ActionTimeouts bounds each action's task, see WorkspaceActionTimeouts.
+	ActionTimeouts map[constants.WorkspaceAction]time.Duration
 }
 
 type WorkspaceService struct {
@@ -36,9 +51,22 @@ type WorkspaceService struct {
 	publisherService               *PublisherService
 	socketService                  *SocketService
 	workspaceConfigService         *WorkspaceConfigService
-	devpod                         *devpod.DevpodService
+	envVarService                  *EnvVarService
+	userPreferenceService          *UserPreferenceService
+	quotaService                   *QuotaService
+	budgetService                  *BudgetService
+	webhookService                 *WebhookService
+	workspacePortService           *WorkspacePortService
+	gitRepository                  *repositories.GitRepository
+	machineConfigRepository        *repositories.MachineConfigRepository
+	backend                        devpod.WorkspaceBackend
 	workspaceStatusEventRepository *repositories.WorkspaceStatusEventRepository
+	workspaceActionRepository      *repositories.WorkspaceActionRepository
+	workspaceRunRepository         *repositories.WorkspaceRunRepository
+	workspacePortRepository        *repositories.WorkspacePortRepository
 	asynqClient                    *asynq.Client
+	asynqInspector                 *asynq.Inspector
+	actionTimeouts                 map[constants.WorkspaceAction]time.Duration
 }
 
 func NewWorkspaceService(config *WorkspaceServiceConfig) *WorkspaceService {
@@ -47,9 +75,22 @@ func NewWorkspaceService(config *WorkspaceServiceConfig) *WorkspaceService {
 		publisherService:               config.Publisher,
 		socketService:                  config.Socket,
 		workspaceConfigService:         config.WorkspaceConfig,
-		devpod:                         config.Devpod,
+		envVarService:                  config.EnvVar,
+		userPreferenceService:          config.UserPreference,
+		quotaService:                   config.Quota,
+		budgetService:                  config.Budget,
+		webhookService:                 config.Webhook,
+		workspacePortService:           config.WorkspacePort,
+		gitRepository:                  config.Repositories.GitRepository,
+		machineConfigRepository:        config.Repositories.MachineConfig,
+		backend:                        config.Backend,
 		workspaceStatusEventRepository: config.Repositories.WorkspaceStatusEvent,
+		workspaceActionRepository:      config.Repositories.WorkspaceAction,
+		workspaceRunRepository:         config.Repositories.WorkspaceRun,
+		workspacePortRepository:        config.Repositories.WorkspacePort,
 		asynqClient:                    config.AsynqClient,
+		asynqInspector:                 config.AsynqInspector,
+		actionTimeouts:                 config.ActionTimeouts,
 	}
 }
 
@@ -72,12 +113,34 @@ func (s *WorkspaceService) GetWorkspaces(ctx context.Context, userId uint64, sea
 	return pagination, nil
 }
 
+// GetSharedWorkspaces lists the workspaces other users shared with the user.
+func (s *WorkspaceService) GetSharedWorkspaces(ctx context.Context, userId uint64, with []string, page, limit int) (pagination.Pagination, error) {
+	pagination, err := s.workspaceRepository.GetSharedWorkspaces(ctx, userId, with, page, limit)
+	if err != nil {
+		return pagination, err
+	}
+
+	workspaces := pagination.Data.([]models.Workspace)
+	pagination.Data = dto.ToWorkspaceDTOs(workspaces)
+
+	return pagination, nil
+}
+
 func (s *WorkspaceService) GetWorkspace(ctx context.Context, workspaceId uint64) (dto.WorkspaceDTO, error) {
 	workspace, err := s.workspaceRepository.GetByID(ctx, workspaceId)
 	if err != nil {
 		return dto.WorkspaceDTO{}, err
 	}
-	return dto.ToWorkspaceDTO(*workspace), nil
+
+	workspaceDTO := dto.ToWorkspaceDTO(*workspace)
+	if workspace.Status == enums.WorkspaceStatusFailed {
+		failure, err := s.workspaceStatusEventRepository.GetLatestFailure(ctx, workspaceId)
+		if err != nil {
+			return dto.WorkspaceDTO{}, err
+		}
+		workspaceDTO.Failure = dto.ToWorkspaceFailureDTO(failure)
+	}
+	return workspaceDTO, nil
 }
 
 func (s *WorkspaceService) GetWorkspaceIncludingDeleted(ctx context.Context, id uint64) (dto.WorkspaceDTO, error) {
@@ -88,6 +151,25 @@ func (s *WorkspaceService) GetWorkspaceIncludingDeleted(ctx context.Context, id
 	return dto.ToWorkspaceDTO(*workspace), nil
 }
 
+// GetWorkspaceTimeline returns the workspace's status history, latest first,
+// with how long the workspace stayed in each status.
+func (s *WorkspaceService) GetWorkspaceTimeline(ctx context.Context, workspaceID uint64, page, limit int) (pagination.Pagination, error) {
+	pagination, err := s.workspaceStatusEventRepository.GetTimeline(ctx, workspaceID, page, limit)
+	if err != nil {
+		return pagination, err
+	}
+
+	now := time.Now()
+	spans := pagination.Data.([]repositories.WorkspaceStatusEventSpan)
+	events := make([]dto.WorkspaceStatusEventDTO, len(spans))
+	for i, span := range spans {
+		events[i] = dto.ToWorkspaceStatusEventDTO(span.WorkspaceStatusEvent, span.EndedAt, now)
+	}
+	pagination.Data = events
+
+	return pagination, nil
+}
+
 func (s *WorkspaceService) GetWorkspaceByFingerprint(ctx context.Context, workspaceFingerprint string) (dto.WorkspaceDTO, error) {
 	workspace, err := s.workspaceRepository.GetByFingerprint(ctx, workspaceFingerprint)
 	if err != nil {
@@ -97,6 +179,27 @@ func (s *WorkspaceService) GetWorkspaceByFingerprint(ctx context.Context, worksp
 }
 
 func (s *WorkspaceService) CreateWorkspace(ctx context.Context, req requests.CreateWorkspaceRequest) (dto.WorkspaceDTO, error) {
+	if err := s.userPreferenceService.ApplyWorkspaceDefaults(ctx, &req); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+	if err := validateIDE(req.IDE); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+	if err := validateGitSource(enums.GitRefType(req.GitRefType), req.GitRef, req.Subfolder, req.DevcontainerPath); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
+	machineConfig, err := s.resolveMachineConfig(ctx, req.MachineConfigID, req.RepositoryID)
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+	if err := s.quotaService.CheckWorkspaceStart(ctx, req.OrganizationID, req.UserID, 0, machineConfig); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+	if err := s.budgetService.CheckWorkspaceStart(ctx, req.OrganizationID); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
 	fingerprint := s.GenerateFingerprint(req.Title, req.UserID, req.OrganizationID)
 
 	var workspaceConfigRequest requests.CreateWorkspaceConfigRequest
@@ -113,35 +216,50 @@ func (s *WorkspaceService) CreateWorkspace(ctx context.Context, req requests.Cre
 		UserID:                   req.UserID,
 		GitPersonalAccessTokenID: req.GitAccessTokenID,
 		OrganizationID:           req.OrganizationID,
-		Status:                   enums.WorkspaceStatus(req.Status),
+		Status:                   enums.WorkspaceStatusPending,
 		Tags:                     req.Tags,
 		URL:                      "",
 		ProviderID:               &req.ProviderID,
 		Fingerprint:              fingerprint,
 		WorkspaceConfigID:        &workspaceConfig.ID,
+		GitRefType:               enums.GitRefType(req.GitRefType),
+		GitRef:                   req.GitRef,
+		Subfolder:                req.Subfolder,
+		DevcontainerPath:         req.DevcontainerPath,
+	}
+	if req.MachineConfigID != 0 {
+		workspace.MachineConfigID = &req.MachineConfigID
+	}
+	if req.TemplateID != 0 {
+		workspace.TemplateID = &req.TemplateID
 	}
 
-	if err := s.workspaceRepository.Create(ctx, &workspace); err != nil {
+	err = s.workspaceRepository.Transaction(ctx, func(workspaceRepository *repositories.WorkspaceRepository) error {
+		if err := s.quotaService.ReserveWorkspaceStart(ctx, workspaceRepository, req.OrganizationID, req.UserID, 0, machineConfig); err != nil {
+			return err
+		}
+		return workspaceRepository.Create(ctx, &workspace)
+	})
+	if err != nil {
 		return dto.WorkspaceDTO{}, err
 	}
 
-	err = s.UpdateWorkspaceStatus(ctx, workspace.ID, enums.WorkspaceStatusPending, "Workspace creation is waiting for processing")
+	err = s.recordStatus(ctx, &models.WorkspaceStatusEvent{
+		WorkspaceID: workspace.ID,
+		Status:      enums.WorkspaceStatusPending,
+		Message:     "Workspace creation is waiting for processing",
+		Actor:       enums.WorkspaceActorUser,
+		ActorUserID: &req.UserID,
+	})
 	if err != nil {
 		log.Printf("Failed to update workspace status: %v", err)
 	}
 
-	createdWorkspace, _ := s.workspaceRepository.GetByID(ctx, workspace.ID)
-
-	task, err := tasks.NewStartWorkspaceTask(workspace.ID, req.UserID)
-	if err != nil {
-		return dto.WorkspaceDTO{}, fmt.Errorf("failed to create workspace job: %w", err)
+	if _, err := s.SubmitWorkspaceAction(ctx, workspace.ID, req.UserID, constants.ActionStart, enums.WorkspaceActorUser); err != nil {
+		return dto.WorkspaceDTO{}, err
 	}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
-	if err != nil {
-		return dto.WorkspaceDTO{}, fmt.Errorf("failed to enqueue workspace creation task: %w", err)
-	}
-	log.Printf("✅ Enqueued workspace creation task: ID=%s queue=%s", info.ID, info.Queue)
+	createdWorkspace, _ := s.workspaceRepository.GetByID(ctx, workspace.ID)
 
 	// Socket message
 	message := dto.Message{
@@ -155,11 +273,31 @@ func (s *WorkspaceService) CreateWorkspace(ctx context.Context, req requests.Cre
 }
 
 func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, req requests.UpdateWorkspaceRequest) (dto.WorkspaceDTO, error) {
+	if req.IDE != "" {
+		if err := validateIDE(req.IDE); err != nil {
+			return dto.WorkspaceDTO{}, err
+		}
+	}
+
 	workspace, err := s.workspaceRepository.GetByID(ctx, req.ID)
 	if err != nil {
 		return dto.WorkspaceDTO{}, err
 	}
 
+	if req.GitRefType != "" {
+		workspace.GitRefType = enums.GitRefType(req.GitRefType)
+		workspace.GitRef = req.GitRef
+	}
+	if req.Subfolder != "" {
+		workspace.Subfolder = req.Subfolder
+	}
+	if req.DevcontainerPath != "" {
+		workspace.DevcontainerPath = req.DevcontainerPath
+	}
+	if err := validateGitSource(workspace.GitRefType, workspace.GitRef, workspace.Subfolder, workspace.DevcontainerPath); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
 	if req.Title != "" {
 		workspace.Title = req.Title
 	}
@@ -179,25 +317,38 @@ func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, req requests.Upd
 		workspace.ProviderID = &req.ProviderID
 	}
 
+	// The update rebuilds the workspace, possibly on another repository's
+	// machine.
+	var machineConfigID uint64
+	if workspace.MachineConfigID != nil {
+		machineConfigID = *workspace.MachineConfigID
+	}
+	machineConfig, err := s.resolveMachineConfig(ctx, machineConfigID, workspace.RepositoryID)
+	if err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+	if err := s.checkWorkspaceBoot(ctx, workspace, machineConfig); err != nil {
+		return dto.WorkspaceDTO{}, err
+	}
+
 	if err := s.workspaceRepository.Update(ctx, workspace); err != nil {
 		return dto.WorkspaceDTO{}, err
 	}
 
-	task, err := tasks.NewRebuildWorkspaceTask(req.ID, workspace.UserID)
-	if err != nil {
-		return dto.WorkspaceDTO{}, fmt.Errorf("failed to rebuild workspace job: %w", err)
+	if _, err := s.SubmitWorkspaceAction(ctx, workspace.ID, workspace.UserID, constants.ActionRebuild, enums.WorkspaceActorUser); err != nil {
+		return dto.WorkspaceDTO{}, err
 	}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
+	updatedWorkspace, err := s.workspaceRepository.GetByID(ctx, workspace.ID)
 	if err != nil {
-		return dto.WorkspaceDTO{}, fmt.Errorf("failed to enqueue workspace rebuilding task: %w", err)
+		return dto.WorkspaceDTO{}, err
 	}
-	log.Printf("✅ Enqueued workspace rebuilding task: ID=%s queue=%s", info.ID, info.Queue)
-
-	return dto.ToWorkspaceDTO(*workspace), nil
+	return dto.ToWorkspaceDTO(*updatedWorkspace), nil
 }
 
-func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, workspaceId string) error {
+// DeleteWorkspace terminates the workspace on behalf of the actor, who may
+// give a reason for the terminating status, and deletes it.
+func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, workspaceId string, actor enums.WorkspaceActor, reason string) error {
 	id, err := strconv.ParseUint(workspaceId, 10, 64)
 	if err != nil {
 		return err
@@ -208,22 +359,16 @@ func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, workspaceId stri
 		return err
 	}
 
-	if err := s.workspaceRepository.DeleteWorkspace(ctx, workspaceId); err != nil {
-		return err
-	}
-
-	task, err := tasks.NewTerminateWorkspaceTask(id, workspace.UserID)
-	if err != nil {
-		return fmt.Errorf("failed to terminate workspace job: %w", err)
+	// A terminated workspace has nothing left to tear down.
+	if workspace.Status == enums.WorkspaceStatusTerminated {
+		return s.workspaceRepository.DeleteWorkspace(ctx, workspaceId)
 	}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
-	if err != nil {
-		return fmt.Errorf("failed to enqueue workspace terminating task: %w", err)
+	if _, err := s.SubmitWorkspaceActionWithReason(ctx, id, workspace.UserID, constants.ActionTerminate, actor, reason); err != nil {
+		return err
 	}
-	log.Printf("✅ Enqueued workspace terminating task: ID=%s queue=%s", info.ID, info.Queue)
 
-	return nil
+	return s.workspaceRepository.DeleteWorkspace(ctx, workspaceId)
 }
 
 func (s *WorkspaceService) UpdateWorkspaceURL(ctx context.Context, workspaceID uint64, url string) error {
@@ -231,128 +376,245 @@ func (s *WorkspaceService) UpdateWorkspaceURL(ctx context.Context, workspaceID u
 }
 
 func (s *WorkspaceService) StartWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
-	err := s.UpdateWorkspaceStatus(ctx, req.ID, enums.WorkspaceStatusStarting, "Workspace starting is waiting for processing")
-	if err != nil {
-		log.Printf("Failed to update workspace status: %v", err)
+	if _, err := s.SubmitWorkspaceAction(ctx, req.ID, req.UserID, constants.ActionStart, workspaceActionActor(req)); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	task, err := tasks.NewStartWorkspaceTask(req.ID, req.UserID)
-	if err != nil {
-		return false, fmt.Errorf("failed to start workspace job: %w", err)
+func (s *WorkspaceService) StopWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
+	if _, err := s.SubmitWorkspaceActionWithReason(ctx, req.ID, req.UserID, constants.ActionStop, workspaceActionActor(req), req.Reason); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
-	if err != nil {
-		return false, fmt.Errorf("failed to enqueue workspace starting task: %w", err)
+func (s *WorkspaceService) RestartWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
+	if _, err := s.SubmitWorkspaceAction(ctx, req.ID, req.UserID, constants.ActionRestart, workspaceActionActor(req)); err != nil {
+		return false, err
 	}
-	log.Printf("✅ Enqueued workspace starting task: ID=%s queue=%s", info.ID, info.Queue)
-
 	return true, nil
 }
 
-func (s *WorkspaceService) StopWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
-	err := s.UpdateWorkspaceStatus(ctx, req.ID, enums.WorkspaceStatusStopping, "Workspace stopping is waiting for processing")
-	if err != nil {
-		log.Printf("Failed to update workspace status: %v", err)
+func (s *WorkspaceService) RebuildWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
+	if _, err := s.SubmitWorkspaceAction(ctx, req.ID, req.UserID, constants.ActionRebuild, workspaceActionActor(req)); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	task, err := tasks.NewStopWorkspaceTask(req.ID, req.UserID)
-	if err != nil {
-		return false, fmt.Errorf("failed to stop workspace job: %w", err)
+func (s *WorkspaceService) TerminateWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
+	if _, err := s.SubmitWorkspaceAction(ctx, req.ID, req.UserID, constants.ActionTerminate, workspaceActionActor(req)); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
-	if err != nil {
-		return false, fmt.Errorf("failed to enqueue workspace stopping task: %w", err)
+// workspaceActionActor is the actor of an action request, the user unless
+// the request says otherwise.
+func workspaceActionActor(req requests.WorkspaceActionRequest) enums.WorkspaceActor {
+	if req.Actor == "" {
+		return enums.WorkspaceActorUser
 	}
-	log.Printf("✅ Enqueued workspace stopping task: ID=%s queue=%s", info.ID, info.Queue)
-
-	return true, nil
+	return req.Actor
 }
 
-func (s *WorkspaceService) RestartWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
-	err := s.UpdateWorkspaceStatus(ctx, req.ID, enums.WorkspaceStatusRestarting, "Workspace restarting is waiting for processing")
+// CancelWorkspaceAction drops the queued actions of a workspace and signals
+// the worker running its current action to stop. A cancelled running action
+// is recorded by the worker once it has cleaned up; one that no worker has
+// picked up yet is recorded here.
+func (s *WorkspaceService) CancelWorkspaceAction(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
+	var dropped int64
+	var running *models.WorkspaceAction
+	err := s.workspaceActionRepository.WithWorkspaceLock(ctx, req.ID, func(tx *repositories.WorkspaceActionTx) error {
+		pending, err := tx.Action.GetPending(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		for i := range pending {
+			if pending[i].Status == enums.WorkspaceActionStatusRunning {
+				running = &pending[i]
+				if err := tx.Action.RequestCancel(ctx, running.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		dropped, err = tx.Action.FinishQueued(ctx, req.ID, enums.WorkspaceActionStatusCancelled, "Cancelled by user")
+		return err
+	})
 	if err != nil {
-		log.Printf("Failed to update workspace status: %v", err)
+		return false, err
 	}
 
-	task, err := tasks.NewRestartWorkspaceTask(req.ID, req.UserID)
-	if err != nil {
-		return false, fmt.Errorf("failed to restart workspace job: %w", err)
+	if running == nil {
+		if dropped == 0 {
+			return false, internalErrors.NewConflictError("NO_ACTION_IN_PROGRESS", "Workspace has no action in progress")
+		}
+		return true, nil
 	}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
+	started, err := s.cancelActionTask(running.TaskID)
 	if err != nil {
-		return false, fmt.Errorf("failed to enqueue workspace restarting task: %w", err)
+		return false, err
+	}
+	if started {
+		return true, nil
 	}
-	log.Printf("✅ Enqueued workspace restarting task: ID=%s queue=%s", info.ID, info.Queue)
 
+	if err := s.recordStatus(ctx, &models.WorkspaceStatusEvent{
+		WorkspaceID: req.ID,
+		Status:      enums.WorkspaceStatusCancelled,
+		Message:     "Workspace action was cancelled before it started",
+		Actor:       enums.WorkspaceActorUser,
+		ActorUserID: &req.UserID,
+	}); err != nil {
+		return false, err
+	}
+	if err := s.FinishWorkspaceAction(ctx, running.ID, enums.WorkspaceActionStatusCancelled, "Cancelled by user"); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
-func (s *WorkspaceService) RebuildWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
-	err := s.UpdateWorkspaceStatus(ctx, req.ID, enums.WorkspaceStatusRebuilding, "Workspace rebuilding is waiting for processing")
-	if err != nil {
-		log.Printf("Failed to update workspace status: %v", err)
+// cancelActionTask stops the asynq task of a running action. An active task
+// gets a cancellation signal and started is true; a task still waiting in its
+// queue is deleted.
+func (s *WorkspaceService) cancelActionTask(taskID string) (started bool, err error) {
+	info, err := s.findActionTask(taskID)
+	if err != nil || info == nil {
+		return false, err
 	}
 
-	task, err := tasks.NewRebuildWorkspaceTask(req.ID, req.UserID)
-	if err != nil {
-		return false, fmt.Errorf("failed to rebuild workspace job: %w", err)
+	if info.State == asynq.TaskStateActive {
+		if err := s.asynqInspector.CancelProcessing(taskID); err != nil {
+			return false, fmt.Errorf("failed to cancel task %s: %w", taskID, err)
+		}
+		log.Printf("🛑 Cancelling active workspace task: ID=%s type=%s", info.ID, info.Type)
+		return true, nil
 	}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
-	if err != nil {
-		return false, fmt.Errorf("failed to enqueue workspace rebuilding task: %w", err)
+	if err := s.asynqInspector.DeleteTask(info.Queue, taskID); err != nil {
+		return false, fmt.Errorf("failed to delete task %s: %w", taskID, err)
 	}
-	log.Printf("✅ Enqueued workspace rebuilding task: ID=%s queue=%s", info.ID, info.Queue)
+	log.Printf("🛑 Dropped queued workspace task: ID=%s type=%s", info.ID, info.Type)
+	return false, nil
+}
 
-	return true, nil
+// UpdateWorkspaceStatus moves the workspace to newStatus if the state machine
+// allows it, records a status event and notifies subscribers. Rejected
+// transitions return an INVALID_STATUS_TRANSITION conflict error. The change
+// is attributed to whoever triggered the workspace's running action.
+func (s *WorkspaceService) UpdateWorkspaceStatus(ctx context.Context, workspaceID uint64, newStatus enums.WorkspaceStatus, message string) error {
+	return s.recordStatus(ctx, &models.WorkspaceStatusEvent{
+		WorkspaceID: workspaceID,
+		Status:      newStatus,
+		Message:     message,
+	})
 }
 
-func (s *WorkspaceService) TerminateWorkspace(ctx context.Context, req requests.WorkspaceActionRequest) (bool, error) {
-	err := s.UpdateWorkspaceStatus(ctx, req.ID, enums.WorkspaceStatusTerminating, "Workspace terminating is waiting for processing")
-	if err != nil {
-		log.Printf("Failed to update workspace status: %v", err)
-	}
+// UpdateWorkspaceStatusBy is UpdateWorkspaceStatus for a change the actor
+// made outside of any action.
+func (s *WorkspaceService) UpdateWorkspaceStatusBy(ctx context.Context, workspaceID uint64, newStatus enums.WorkspaceStatus, message string, actor enums.WorkspaceActor) error {
+	return s.recordStatus(ctx, &models.WorkspaceStatusEvent{
+		WorkspaceID: workspaceID,
+		Status:      newStatus,
+		Message:     message,
+		Actor:       actor,
+	})
+}
 
-	task, err := tasks.NewTerminateWorkspaceTask(req.ID, req.UserID)
-	if err != nil {
-		return false, fmt.Errorf("failed to terminate workspace job: %w", err)
+// CorrectWorkspaceStatus is UpdateWorkspaceStatusBy for a decision made on
+// the observed copy of the workspace. The status only changes if the
+// workspace is still at the version it was observed at, so a change made in
+// the meantime, such as a user terminating it, is never overridden. It
+// reports whether the status was changed.
+func (s *WorkspaceService) CorrectWorkspaceStatus(ctx context.Context, observed models.Workspace, newStatus enums.WorkspaceStatus, message string, actor enums.WorkspaceActor) (bool, error) {
+	if !observed.Status.CanTransitionTo(newStatus) {
+		return false, NewInvalidStatusTransitionError(observed.Status, newStatus)
 	}
 
-	info, err := s.asynqClient.Enqueue(task, asynq.Unique(5*time.Minute))
+	updated, err := s.workspaceRepository.UpdateStatusWithVersion(ctx, observed.ID, string(newStatus), observed.Version)
 	if err != nil {
-		return false, fmt.Errorf("failed to enqueue workspace terminating task: %w", err)
+		return false, fmt.Errorf("failed to update workspace status: %w", err)
 	}
-	log.Printf("✅ Enqueued workspace terminating task: ID=%s queue=%s", info.ID, info.Queue)
-
-	return true, nil
-}
-
-func (s *WorkspaceService) UpdateWorkspaceStatus(ctx context.Context, workspaceID uint64, newStatus enums.WorkspaceStatus, message string) error {
-	if err := s.workspaceRepository.UpdateStatus(ctx, workspaceID, string(newStatus)); err != nil {
-		return fmt.Errorf("failed to update workspace status: %w", err)
+	if !updated {
+		return false, nil
 	}
 
 	event := &models.WorkspaceStatusEvent{
-		WorkspaceID: workspaceID,
+		WorkspaceID: observed.ID,
 		Status:      newStatus,
 		Message:     message,
+		Actor:       actor,
 	}
 	if err := s.workspaceStatusEventRepository.Create(ctx, event); err != nil {
+		return true, fmt.Errorf("failed to create status event: %w", err)
+	}
+	s.announceStatus(ctx, event)
+	return true, nil
+}
+
+// FailWorkspace moves the workspace to failed and records the failure code
+// and reason on the status event.
+func (s *WorkspaceService) FailWorkspace(ctx context.Context, workspaceID uint64, code, reason string) error {
+	return s.recordStatus(ctx, &models.WorkspaceStatusEvent{
+		WorkspaceID: workspaceID,
+		Status:      enums.WorkspaceStatusFailed,
+		Message:     "Workspace is failed by worker",
+		ErrorCode:   code,
+		ErrorReason: reason,
+	})
+}
+
+func (s *WorkspaceService) recordStatus(ctx context.Context, event *models.WorkspaceStatusEvent) error {
+	if err := s.writeStatus(ctx, s.workspaceRepository, s.workspaceStatusEventRepository, event); err != nil {
+		return err
+	}
+	s.announceStatus(ctx, event)
+	return nil
+}
+
+// writeStatus moves the workspace to the event's status and records the
+// event through the given repositories, which may be bound to a transaction.
+func (s *WorkspaceService) writeStatus(
+	ctx context.Context,
+	workspaceRepository *repositories.WorkspaceRepository,
+	statusEventRepository *repositories.WorkspaceStatusEventRepository,
+	event *models.WorkspaceStatusEvent,
+) error {
+	if err := s.transitionStatus(ctx, workspaceRepository, event.WorkspaceID, event.Status); err != nil {
+		return err
+	}
+
+	if event.Actor == "" {
+		s.attributeStatus(ctx, event)
+	}
+	if err := statusEventRepository.Create(ctx, event); err != nil {
 		return fmt.Errorf("failed to create status event: %w", err)
 	}
+	return nil
+}
 
+// announceStatus tells subscribers, webhooks and port forwarding about a
+// written status change.
+func (s *WorkspaceService) announceStatus(ctx context.Context, event *models.WorkspaceStatusEvent) {
+	workspaceID := event.WorkspaceID
+	newStatus := event.Status
 	workspace, _ := s.GetWorkspaceIncludingDeleted(ctx, workspaceID)
 
+	data := map[string]interface{}{
+		"status": newStatus,
+		"url":    workspace.URL,
+	}
+	if event.ErrorCode != "" {
+		data["error_code"] = event.ErrorCode
+		data["error_reason"] = event.ErrorReason
+	}
 	body := dto.Message{
 		EventType: constants.WorkspaceStatus,
 		Channel:   fmt.Sprintf("workspace_%d_status", workspaceID),
-		Data: map[string]interface{}{
-			"status": newStatus,
-			"url":    workspace.URL,
-		},
+		Data:      data,
 	}
 	payload, err := json.Marshal(body)
 	if err != nil {
@@ -360,62 +622,212 @@ func (s *WorkspaceService) UpdateWorkspaceStatus(ctx context.Context, workspaceI
 	}
 	s.publisherService.Publish(constants.CLUSTERIX_CODE_V1_EXCHANGE, constants.WORKSPACE_LOG_HANDLER_QUEUE, payload)
 
-	return nil
+	s.webhookService.WorkspaceStatusChanged(ctx, workspace, *event)
+	s.workspacePortService.WorkspaceStatusChanged(ctx, workspaceID, newStatus)
+}
+
+// attributeStatus attributes a status change to the workspace's running run
+// and the actor of its running action, else to the system.
+func (s *WorkspaceService) attributeStatus(ctx context.Context, event *models.WorkspaceStatusEvent) {
+	event.Actor = enums.WorkspaceActorSystem
+
+	run, err := s.workspaceRunRepository.GetRunning(ctx, event.WorkspaceID)
+	if err != nil {
+		log.Printf("Failed to load the running run of workspace %d: %v", event.WorkspaceID, err)
+	} else if run != nil {
+		event.RunID = &run.ID
+	}
+
+	pending, err := s.workspaceActionRepository.GetPending(ctx, event.WorkspaceID)
+	if err != nil {
+		log.Printf("Failed to load the actions of workspace %d: %v", event.WorkspaceID, err)
+		return
+	}
+	if len(pending) > 0 && pending[0].Status == enums.WorkspaceActionStatusRunning {
+		event.Actor = pending[0].Actor
+		event.ActorUserID = actionActorUserID(&pending[0])
+	}
+}
+
+// transitionStatus writes the new status with an optimistic lock on the
+// workspace version, re-reading and re-checking the transition when another
+// writer got there first.
+func (s *WorkspaceService) transitionStatus(ctx context.Context, workspaceRepository *repositories.WorkspaceRepository, workspaceID uint64, newStatus enums.WorkspaceStatus) error {
+	for attempt := 0; attempt < statusUpdateAttempts; attempt++ {
+		workspace, err := workspaceRepository.GetStatusByID(ctx, workspaceID)
+		if err != nil {
+			return fmt.Errorf("failed to update workspace status: %w", err)
+		}
+
+		if !workspace.Status.CanTransitionTo(newStatus) {
+			return NewInvalidStatusTransitionError(workspace.Status, newStatus)
+		}
+
+		updated, err := workspaceRepository.UpdateStatusWithVersion(ctx, workspaceID, string(newStatus), workspace.Version)
+		if err != nil {
+			return fmt.Errorf("failed to update workspace status: %w", err)
+		}
+		if updated {
+			return nil
+		}
+	}
+
+	return internalErrors.NewConflictError(
+		"WORKSPACE_STATUS_CONFLICT",
+		"Workspace status was changed concurrently, please try again")
+}
+
+func NewInvalidStatusTransitionError(from, to enums.WorkspaceStatus) *internalErrors.AppError {
+	return internalErrors.NewConflictError(
+		invalidStatusTransitionCode,
+		fmt.Sprintf("Workspace cannot move from %s to %s", from, to))
+}
+
+// IsInvalidStatusTransition reports whether err is a rejected status change.
+func IsInvalidStatusTransition(err error) bool {
+	var appErr *internalErrors.AppError
+	return errors.As(err, &appErr) && appErr.Code == invalidStatusTransitionCode
 }
 
 func (s *WorkspaceService) RunWorkspaceAction(
 	ctx context.Context,
 	workspaceID uint64,
 	userID uint64,
-	onSuccess func(message string, logType string) error,
-	onFailure func(message string, logType string) error,
+	onEvent devpod.EventHandler,
 	action constants.WorkspaceAction,
 ) error {
 	workspace, err := s.workspaceRepository.GetByIDIncludingDeleted(ctx, workspaceID)
 	if err != nil {
-		wrappedErr := fmt.Errorf("workspace not found: %w", err)
-		if onFailure != nil {
-			_ = onFailure(err.Error(), "")
-		}
-		return wrappedErr
+		return fmt.Errorf("workspace not found: %w", err)
 	}
 
 	devpodWorkspaceDTO := dto.DevpodWorkspace{
 		AccessToken:        workspace.GitPersonalAccessToken.Token,
 		RepositoryUrl:      workspace.Repository.RepositoryURL,
 		DevpodWorkspaceId:  workspace.ID,
-		DevpodWorkspaceIde: "openvscode",
-		AWSInstanceType:    workspace.Repository.MachineConfig.InstanceType,
+		DevpodWorkspaceIde: string(enums.IDE(workspace.Ide).OrDefault()),
+		AWSInstanceType:    workspaceMachineConfig(workspace).InstanceType,
 		UserId:             userID,
+		Fingerprint:        workspace.Fingerprint,
+		GitRefType:         string(workspace.GitRefType),
+		GitRef:             workspace.GitRef,
+		Subfolder:          workspace.Subfolder,
+		DevcontainerPath:   workspace.DevcontainerPath,
+	}
+
+	// Only the actions that run devpod up create the workspace environment
+	// and install the owner's dotfiles.
+	switch action {
+	case constants.ActionStart, constants.ActionRestart, constants.ActionRebuild:
+		if devpodWorkspaceDTO.Env, err = s.envVarService.ResolveWorkspaceEnv(ctx, workspace); err != nil {
+			return fmt.Errorf("failed to resolve workspace environment: %w", err)
+		}
+		devpodWorkspaceDTO.DotfilesRepository, devpodWorkspaceDTO.DotfilesScript, err = s.userPreferenceService.GetDotfiles(ctx, workspace.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to load dotfiles: %w", err)
+		}
+	}
+
+	// Terminating deletes the DNS records of the workspace's ports too.
+	if action == constants.ActionTerminate {
+		if devpodWorkspaceDTO.Ports, err = s.workspacePortRepository.GetPortNumbers(ctx, workspace.ID); err != nil {
+			return fmt.Errorf("failed to load workspace ports: %w", err)
+		}
 	}
 
 	switch action {
 	case constants.ActionStart:
-		aws.CreateARecord(workspace.Fingerprint)
-		err = s.devpod.StartWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+		err = s.backend.StartWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 	case constants.ActionStop:
-		err = s.devpod.StopWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+		err = s.backend.StopWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 	case constants.ActionRestart:
-		err = s.devpod.RestartWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+		err = s.backend.RestartWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 	case constants.ActionRebuild:
-		err = s.devpod.RebuildWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+		err = s.backend.RebuildWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 	case constants.ActionTerminate:
-		err = s.devpod.TerminateWorkspace(ctx, devpodWorkspaceDTO, onSuccess, onFailure)
+		err = s.backend.TerminateWorkspace(ctx, devpodWorkspaceDTO, onEvent)
 	default:
 		err = fmt.Errorf("unknown action: %s", action)
 	}
 
 	if err != nil {
-		wrappedErr := fmt.Errorf("devpod %s failed: %w", action, err)
-		if onFailure != nil {
-			_ = onFailure(err.Error(), "")
+		if ctx.Err() != nil {
+			// The task was cancelled; the caller records the cancellation
+			// instead of a failure.
+			return fmt.Errorf("devpod %s cancelled: %w", action, ctx.Err())
 		}
-		return wrappedErr
+		return fmt.Errorf("devpod %s failed: %w", action, err)
 	}
 
 	return nil
 }
 
+// checkWorkspaceBoot makes sure the workspace may boot a machine of the
+// config within its owner's and organization's quotas and its
+// organization's budget, without reserving anything.
+func (s *WorkspaceService) checkWorkspaceBoot(ctx context.Context, workspace *models.Workspace, machineConfig models.MachineConfig) error {
+	if err := s.quotaService.CheckWorkspaceStart(ctx, workspace.OrganizationID, workspace.UserID, workspace.ID, machineConfig); err != nil {
+		return err
+	}
+	return s.budgetService.CheckWorkspaceStart(ctx, workspace.OrganizationID)
+}
+
+// reserveWorkspaceBoot is checkWorkspaceBoot for an action about to boot
+// the workspace's machine, run in the locked change to its action queue.
+// The quotas are reserved until that change commits. Every action that
+// boots a machine goes through it.
+func (s *WorkspaceService) reserveWorkspaceBoot(ctx context.Context, tx *repositories.WorkspaceActionTx, workspaceID uint64) error {
+	workspace, err := tx.Workspace.GetByID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := s.quotaService.ReserveWorkspaceStart(ctx, tx.Workspace, workspace.OrganizationID, workspace.UserID, workspace.ID, workspaceMachineConfig(workspace)); err != nil {
+		return err
+	}
+	return s.budgetService.CheckWorkspaceStart(ctx, workspace.OrganizationID)
+}
+
+// resolveMachineConfig is the machine a workspace runs on: the machine
+// config it overrides, or its repository's.
+func (s *WorkspaceService) resolveMachineConfig(ctx context.Context, machineConfigID, repositoryID uint64) (models.MachineConfig, error) {
+	if machineConfigID != 0 {
+		machineConfig, err := s.machineConfigRepository.GetByID(ctx, machineConfigID)
+		if err != nil {
+			return models.MachineConfig{}, err
+		}
+		return *machineConfig, nil
+	}
+
+	repository, err := s.gitRepository.GetByID(ctx, repositoryID, []string{"MachineConfig"})
+	if err != nil {
+		return models.MachineConfig{}, err
+	}
+	return repository.MachineConfig, nil
+}
+
+// workspaceMachineConfig is the machine of a workspace loaded with its
+// machine config and its repository's.
+func workspaceMachineConfig(workspace *models.Workspace) models.MachineConfig {
+	if workspace.MachineConfigID != nil {
+		return workspace.MachineConfig
+	}
+	return workspace.Repository.MachineConfig
+}
+
+// validateIDE rejects IDEs that aren't in the supported IDE registry.
+func validateIDE(ide string) error {
+	if enums.IDE(ide).IsSupported() {
+		return nil
+	}
+
+	var names []string
+	for _, supported := range enums.SupportedIDEs() {
+		names = append(names, string(supported))
+	}
+	return internalErrors.NewValidationError("Unsupported IDE",
+		map[string][]string{"ide": {"must be one of " + strings.Join(names, ", ")}})
+}
+
 func (s *WorkspaceService) GenerateFingerprint(title string, userId uint64, organizationId uint32) string {
 	hasher := sha256.New()
 
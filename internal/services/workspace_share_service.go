@@ -0,0 +1,154 @@
+package services
+
+import (
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// workspaceChannelPattern matches the websocket channels workspace status
+// and logs are published on.
+var workspaceChannelPattern = regexp.MustCompile(`^workspace_([0-9]+)_(logs|status)$`)
+
+// organizationChannelPattern matches the websocket channels organization
+// budget alerts are published on.
+var organizationChannelPattern = regexp.MustCompile(`^organization_([0-9]+)_budget$`)
+
+type WorkspaceShareServiceConfig struct {
+	Repositories *repositories.Repositories
+}
+
+type WorkspaceShareService struct {
+	workspaceShareRepository *repositories.WorkspaceShareRepository
+	workspaceRepository      *repositories.WorkspaceRepository
+	userRepository           *repositories.UserRepository
+}
+
+func NewWorkspaceShareService(config *WorkspaceShareServiceConfig) *WorkspaceShareService {
+	return &WorkspaceShareService{
+		workspaceShareRepository: config.Repositories.WorkspaceShare,
+		workspaceRepository:      config.Repositories.Workspace,
+		userRepository:           config.Repositories.User,
+	}
+}
+
+func (s *WorkspaceShareService) GetShares(ctx context.Context, workspaceID uint64) ([]dto.WorkspaceShareDTO, error) {
+	shares, err := s.workspaceShareRepository.GetByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToWorkspaceShareDTOs(shares), nil
+}
+
+// ShareWorkspace grants a user of the workspace's organization a role on
+// the workspace, replacing the role the user had.
+func (s *WorkspaceShareService) ShareWorkspace(ctx context.Context, workspace *dto.WorkspaceDTO, userID, sharedByID uint64, role enums.WorkspaceRole) (dto.WorkspaceShareDTO, error) {
+	if !role.IsShareable() {
+		return dto.WorkspaceShareDTO{}, internalErrors.NewValidationError("Invalid role",
+			map[string][]string{"role": {"must be one of viewer, user, operator"}})
+	}
+	if userID == workspace.UserID {
+		return dto.WorkspaceShareDTO{}, internalErrors.NewValidationError("Invalid user",
+			map[string][]string{"user_id": {"is the owner of the workspace"}})
+	}
+
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.WorkspaceShareDTO{}, internalErrors.NewNotFoundError("user")
+	}
+	if err != nil {
+		return dto.WorkspaceShareDTO{}, err
+	}
+	if !user.IsActive || user.OrganizationID != uint64(workspace.OrganizationID) {
+		// Users of other organizations are reported as missing so their IDs
+		// can't be probed.
+		return dto.WorkspaceShareDTO{}, internalErrors.NewNotFoundError("user")
+	}
+
+	share := models.WorkspaceShare{
+		WorkspaceID: workspace.ID,
+		UserID:      userID,
+		Role:        role,
+		SharedByID:  sharedByID,
+	}
+	if err := s.workspaceShareRepository.Save(ctx, &share); err != nil {
+		return dto.WorkspaceShareDTO{}, err
+	}
+	share.User = *user
+	return dto.ToWorkspaceShareDTO(share), nil
+}
+
+func (s *WorkspaceShareService) UnshareWorkspace(ctx context.Context, workspaceID, userID uint64) error {
+	deleted, err := s.workspaceShareRepository.Delete(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return internalErrors.NewNotFoundError("workspace share")
+	}
+	return nil
+}
+
+// WorkspaceRole is the role the user has on the workspace, or empty if the
+// user has no access to it. Owners and collaborators must belong to the
+// workspace's organization.
+func (s *WorkspaceShareService) WorkspaceRole(ctx context.Context, authUser *dto.User, workspace *dto.WorkspaceDTO) (enums.WorkspaceRole, error) {
+	if workspace.OrganizationID != authUser.OrganizationID {
+		return "", nil
+	}
+	if workspace.UserID == authUser.ID {
+		return enums.WorkspaceRoleOwner, nil
+	}
+
+	share, err := s.workspaceShareRepository.Get(ctx, workspace.ID, authUser.ID)
+	if err != nil || share == nil {
+		return "", err
+	}
+	return share.Role, nil
+}
+
+// CanAccessWorkspace reports whether the user's role on the workspace
+// includes the required role.
+func (s *WorkspaceShareService) CanAccessWorkspace(ctx context.Context, authUser *dto.User, workspace *dto.WorkspaceDTO, required enums.WorkspaceRole) bool {
+	role, err := s.WorkspaceRole(ctx, authUser, workspace)
+	if err != nil {
+		log.Printf("failed to resolve role of user %d on workspace %d: %v", authUser.ID, workspace.ID, err)
+		return false
+	}
+	return role.Includes(required)
+}
+
+// CanSubscribe lets viewers of a workspace follow its status and logs, and
+// members of an organization its budget alerts. Any other channel is
+// refused.
+func (s *WorkspaceShareService) CanSubscribe(ctx context.Context, authUser *dto.User, channel string) bool {
+	if matches := organizationChannelPattern.FindStringSubmatch(channel); matches != nil {
+		organizationID, err := strconv.ParseUint(matches[1], 10, 32)
+		return err == nil && uint32(organizationID) == authUser.OrganizationID
+	}
+
+	matches := workspaceChannelPattern.FindStringSubmatch(channel)
+	if matches == nil {
+		return false
+	}
+	workspaceID, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	workspace, err := s.workspaceRepository.GetByIDIncludingDeleted(ctx, workspaceID)
+	if err != nil {
+		return false
+	}
+	workspaceDTO := dto.ToWorkspaceDTO(*workspace)
+	return s.CanAccessWorkspace(ctx, authUser, &workspaceDTO, enums.WorkspaceRoleViewer)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"clusterix-code/internal/api/requests"
+	"clusterix-code/internal/data/dto"
+	"clusterix-code/internal/data/enums"
+	"clusterix-code/internal/data/models"
+	"clusterix-code/internal/data/repositories"
+	internalErrors "clusterix-code/internal/utils/errors"
+	"clusterix-code/internal/utils/pagination"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var workspaceTemplateRelations = []string{"Repository", "MachineConfig"}
+
+type WorkspaceTemplateServiceConfig struct {
+	Repositories *repositories.Repositories
+}
+
+type WorkspaceTemplateService struct {
+	workspaceTemplateRepository *repositories.WorkspaceTemplateRepository
+	gitRepository               *repositories.GitRepository
+	machineConfigRepository     *repositories.MachineConfigRepository
+	providerRepository          *repositories.ProviderRepository
+}
+
+func NewWorkspaceTemplateService(config *WorkspaceTemplateServiceConfig) *WorkspaceTemplateService {
+	return &WorkspaceTemplateService{
+		workspaceTemplateRepository: config.Repositories.WorkspaceTemplate,
+		gitRepository:               config.Repositories.GitRepository,
+		machineConfigRepository:     config.Repositories.MachineConfig,
+		providerRepository:          config.Repositories.Provider,
+	}
+}
+
+func (s *WorkspaceTemplateService) GetTemplates(ctx context.Context, organizationId uint32, search string, with []string, page, limit int) (pagination.Pagination, error) {
+	pagination, err := s.workspaceTemplateRepository.GetTemplates(ctx, organizationId, search, with, page, limit)
+	if err != nil {
+		return pagination, err
+	}
+
+	templates := pagination.Data.([]models.WorkspaceTemplate)
+	pagination.Data = dto.ToWorkspaceTemplateDTOs(templates)
+
+	return pagination, nil
+}
+
+// GetTemplate returns a template of the organization. Templates of other
+// organizations are reported as missing.
+func (s *WorkspaceTemplateService) GetTemplate(ctx context.Context, organizationId uint32, templateId uint64) (dto.WorkspaceTemplateDTO, error) {
+	template, err := s.getTemplate(ctx, organizationId, templateId)
+	if err != nil {
+		return dto.WorkspaceTemplateDTO{}, err
+	}
+	return dto.ToWorkspaceTemplateDTO(*template), nil
+}
+
+func (s *WorkspaceTemplateService) CreateTemplate(ctx context.Context, organizationId uint32, userId uint64, req requests.CreateWorkspaceTemplateRequest) (dto.WorkspaceTemplateDTO, error) {
+	if err := s.validateTemplate(ctx, organizationId, req); err != nil {
+		return dto.WorkspaceTemplateDTO{}, err
+	}
+
+	template := models.WorkspaceTemplate{
+		OrganizationID: organizationId,
+		CreatedByID:    userId,
+	}
+	applyTemplateRequest(&template, req)
+
+	if err := s.workspaceTemplateRepository.Create(ctx, &template); err != nil {
+		return dto.WorkspaceTemplateDTO{}, err
+	}
+	return s.GetTemplate(ctx, organizationId, template.ID)
+}
+
+func (s *WorkspaceTemplateService) UpdateTemplate(ctx context.Context, organizationId uint32, templateId uint64, req requests.UpdateWorkspaceTemplateRequest) (dto.WorkspaceTemplateDTO, error) {
+	template, err := s.getTemplate(ctx, organizationId, templateId)
+	if err != nil {
+		return dto.WorkspaceTemplateDTO{}, err
+	}
+	if err := s.validateTemplate(ctx, organizationId, req); err != nil {
+		return dto.WorkspaceTemplateDTO{}, err
+	}
+
+	applyTemplateRequest(template, req)
+	// Save would also write the stale preloaded relations back.
+	template.Repository = models.Repository{}
+	template.MachineConfig = models.MachineConfig{}
+
+	if err := s.workspaceTemplateRepository.Update(ctx, template); err != nil {
+		return dto.WorkspaceTemplateDTO{}, err
+	}
+	return s.GetTemplate(ctx, organizationId, template.ID)
+}
+
+// DeleteTemplate removes a template. Workspaces created from it keep its
+// settings and environment variables.
+func (s *WorkspaceTemplateService) DeleteTemplate(ctx context.Context, organizationId uint32, templateId uint64) error {
+	if _, err := s.getTemplate(ctx, organizationId, templateId); err != nil {
+		return err
+	}
+	return s.workspaceTemplateRepository.Delete(ctx, templateId)
+}
+
+// NewWorkspaceRequest turns a template into the request creating a
+// workspace from it for the user.
+func (s *WorkspaceTemplateService) NewWorkspaceRequest(
+	ctx context.Context,
+	authUser *dto.User,
+	templateId uint64,
+	req requests.CreateWorkspaceFromTemplateRequest,
+) (requests.CreateWorkspaceRequest, error) {
+	template, err := s.getTemplate(ctx, authUser.OrganizationID, templateId)
+	if err != nil {
+		return requests.CreateWorkspaceRequest{}, err
+	}
+
+	workspaceReq := requests.CreateWorkspaceRequest{
+		Title:            req.Title,
+		Color:            req.Color,
+		IDE:              template.Ide,
+		RepositoryID:     template.RepositoryID,
+		UserID:           authUser.ID,
+		GitAccessTokenID: req.GitAccessTokenID,
+		OrganizationID:   authUser.OrganizationID,
+		ProviderID:       template.ProviderID,
+		Status:           "processing",
+		Tags:             template.Tags,
+		GitRefType:       string(template.GitRefType),
+		GitRef:           template.GitRef,
+		Subfolder:        template.Subfolder,
+		DevcontainerPath: template.DevcontainerPath,
+		TemplateID:       template.ID,
+	}
+	if workspaceReq.Title == "" {
+		workspaceReq.Title = template.Name
+	}
+	if workspaceReq.Color == "" {
+		workspaceReq.Color = template.Color
+	}
+	if template.MachineConfigID != nil {
+		workspaceReq.MachineConfigID = *template.MachineConfigID
+	}
+	return workspaceReq, nil
+}
+
+func (s *WorkspaceTemplateService) getTemplate(ctx context.Context, organizationId uint32, templateId uint64) (*models.WorkspaceTemplate, error) {
+	template, err := s.workspaceTemplateRepository.GetByID(ctx, templateId, workspaceTemplateRelations)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && template.OrganizationID != organizationId) {
+		return nil, internalErrors.NewNotFoundError("workspace template")
+	}
+	return template, err
+}
+
+func (s *WorkspaceTemplateService) validateTemplate(ctx context.Context, organizationId uint32, req requests.CreateWorkspaceTemplateRequest) error {
+	if req.IDE != "" {
+		if err := validateIDE(req.IDE); err != nil {
+			return err
+		}
+	}
+	if err := validateGitSource(enums.GitRefType(req.GitRefType), req.GitRef, req.Subfolder, req.DevcontainerPath); err != nil {
+		return err
+	}
+
+	errs := map[string][]string{}
+	if repository, err := s.gitRepository.GetByID(ctx, req.RepositoryID, nil); err != nil || repository.OrganizationID != organizationId {
+		errs["repository_id"] = []string{"must be a repository of your organization"}
+	}
+	if req.MachineConfigID != nil {
+		if _, err := s.machineConfigRepository.GetByID(ctx, *req.MachineConfigID); err != nil {
+			errs["machine_config_id"] = []string{"must be an existing machine config"}
+		}
+	}
+	if _, err := s.providerRepository.GetByID(ctx, req.ProviderID); err != nil {
+		errs["provider_id"] = []string{"must be an existing provider"}
+	}
+	if len(errs) > 0 {
+		return internalErrors.NewValidationError("Invalid workspace template", errs)
+	}
+	return nil
+}
+
+func applyTemplateRequest(template *models.WorkspaceTemplate, req requests.CreateWorkspaceTemplateRequest) {
+	template.Name = req.Name
+	template.Description = req.Description
+	template.RepositoryID = req.RepositoryID
+	template.MachineConfigID = req.MachineConfigID
+	template.ProviderID = req.ProviderID
+	template.Ide = req.IDE
+	template.Color = req.Color
+	template.GitRefType = enums.GitRefType(req.GitRefType)
+	template.GitRef = req.GitRef
+	template.Subfolder = req.Subfolder
+	template.DevcontainerPath = req.DevcontainerPath
+	template.Tags = req.Tags
+}
@@ -0,0 +1,11 @@
+package tasks
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskApplyRetention = "workspace:apply-retention"
+
+func NewApplyRetentionTask() *asynq.Task {
+	return asynq.NewTask(TaskApplyRetention, nil)
+}
@@ -0,0 +1,22 @@
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/hibiken/asynq"
+)
+
+const TaskDeliverWebhook = "webhook:deliver"
+
+type DeliverWebhookPayload struct {
+	DeliveryID uint64
+}
+
+func NewDeliverWebhookTask(deliveryID uint64) (*asynq.Task, error) {
+	payload, err := json.Marshal(DeliverWebhookPayload{
+		DeliveryID: deliveryID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskDeliverWebhook, payload), nil
+}
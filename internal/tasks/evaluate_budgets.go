@@ -0,0 +1,11 @@
+package tasks
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskEvaluateBudgets = "budget:evaluate"
+
+func NewEvaluateBudgetsTask() *asynq.Task {
+	return asynq.NewTask(TaskEvaluateBudgets, nil)
+}
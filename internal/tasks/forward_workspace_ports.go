@@ -0,0 +1,28 @@
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/hibiken/asynq"
+)
+
+const TaskForwardWorkspacePorts = "workspace:forward-ports"
+
+// ForwardWorkspacePortsPayload asks the worker to forward the declared ports
+// of a running workspace, or stop forwarding those of a workspace that no
+// longer runs. ReadDevcontainer first declares the forwardPorts of the
+// workspace's devcontainer.json.
+type ForwardWorkspacePortsPayload struct {
+	WorkspaceID      uint64
+	ReadDevcontainer bool
+}
+
+func NewForwardWorkspacePortsTask(workspaceID uint64, readDevcontainer bool) (*asynq.Task, error) {
+	payload, err := json.Marshal(ForwardWorkspacePortsPayload{
+		WorkspaceID:      workspaceID,
+		ReadDevcontainer: readDevcontainer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskForwardWorkspacePorts, payload), nil
+}
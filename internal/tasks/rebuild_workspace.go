@@ -7,15 +7,13 @@ import (
 
 const TaskRebuildWorkspace = "workspace:rebuild"
 
-type RebuildWorkspacePayload struct {
-	WorkspaceID uint64
-	UserID      uint64
-}
+type RebuildWorkspacePayload = WorkspaceActionPayload
 
-func NewRebuildWorkspaceTask(workspaceID uint64, userId uint64) (*asynq.Task, error) {
+func NewRebuildWorkspaceTask(workspaceID uint64, userId uint64, actionID uint64) (*asynq.Task, error) {
 	payload, err := json.Marshal(RebuildWorkspacePayload{
 		WorkspaceID: workspaceID,
 		UserID:      userId,
+		ActionID:    actionID,
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,11 @@
+package tasks
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskReconcileWorkspaces = "workspace:reconcile"
+
+func NewReconcileWorkspacesTask() *asynq.Task {
+	return asynq.NewTask(TaskReconcileWorkspaces, nil)
+}
@@ -7,15 +7,13 @@ import (
 
 const TaskRestartWorkspace = "workspace:restart"
 
-type RestartWorkspacePayload struct {
-	WorkspaceID uint64
-	UserID      uint64
-}
+type RestartWorkspacePayload = WorkspaceActionPayload
 
-func NewRestartWorkspaceTask(workspaceID uint64, userId uint64) (*asynq.Task, error) {
+func NewRestartWorkspaceTask(workspaceID uint64, userId uint64, actionID uint64) (*asynq.Task, error) {
 	payload, err := json.Marshal(RestartWorkspacePayload{
 		WorkspaceID: workspaceID,
 		UserID:      userId,
+		ActionID:    actionID,
 	})
 	if err != nil {
 		return nil, err
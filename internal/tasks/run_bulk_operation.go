@@ -0,0 +1,22 @@
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/hibiken/asynq"
+)
+
+const TaskRunBulkOperation = "workspace:run-bulk-operation"
+
+type RunBulkOperationPayload struct {
+	OperationID uint64
+}
+
+func NewRunBulkOperationTask(operationID uint64) (*asynq.Task, error) {
+	payload, err := json.Marshal(RunBulkOperationPayload{
+		OperationID: operationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskRunBulkOperation, payload), nil
+}
@@ -0,0 +1,11 @@
+package tasks
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskRunWorkspaceSchedules = "workspace:run-schedules"
+
+func NewRunWorkspaceSchedulesTask() *asynq.Task {
+	return asynq.NewTask(TaskRunWorkspaceSchedules, nil)
+}
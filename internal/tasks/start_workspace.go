@@ -7,15 +7,13 @@ import (
 
 const TaskStartWorkspace = "workspace:start"
 
-type StartWorkspacePayload struct {
-	WorkspaceID uint64
-	UserID      uint64
-}
+type StartWorkspacePayload = WorkspaceActionPayload
 
-func NewStartWorkspaceTask(workspaceID uint64, userId uint64) (*asynq.Task, error) {
+func NewStartWorkspaceTask(workspaceID uint64, userId uint64, actionID uint64) (*asynq.Task, error) {
 	payload, err := json.Marshal(StartWorkspacePayload{
 		WorkspaceID: workspaceID,
 		UserID:      userId,
+		ActionID:    actionID,
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,11 @@
+package tasks
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskStopIdleWorkspaces = "workspace:stop-idle"
+
+func NewStopIdleWorkspacesTask() *asynq.Task {
+	return asynq.NewTask(TaskStopIdleWorkspaces, nil)
+}
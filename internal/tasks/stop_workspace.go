@@ -7,15 +7,13 @@ import (
 
 const TaskStopWorkspace = "workspace:stop"
 
-type StopWorkspacePayload struct {
-	WorkspaceID uint64
-	UserID      uint64
-}
+type StopWorkspacePayload = WorkspaceActionPayload
 
-func NewStopWorkspaceTask(workspaceID uint64, userId uint64) (*asynq.Task, error) {
+func NewStopWorkspaceTask(workspaceID uint64, userId uint64, actionID uint64) (*asynq.Task, error) {
 	payload, err := json.Marshal(StopWorkspacePayload{
 		WorkspaceID: workspaceID,
 		UserID:      userId,
+		ActionID:    actionID,
 	})
 	if err != nil {
 		return nil, err
@@ -7,15 +7,13 @@ import (
 
 const TaskTerminateWorkspace = "workspace:terminate"
 
-type TerminateWorkspacePayload struct {
-	WorkspaceID uint64
-	UserID      uint64
-}
+type TerminateWorkspacePayload = WorkspaceActionPayload
 
-func NewTerminateWorkspaceTask(workspaceID uint64, userId uint64) (*asynq.Task, error) {
+func NewTerminateWorkspaceTask(workspaceID uint64, userId uint64, actionID uint64) (*asynq.Task, error) {
 	payload, err := json.Marshal(TerminateWorkspacePayload{
 		WorkspaceID: workspaceID,
 		UserID:      userId,
+		ActionID:    actionID,
 	})
 	if err != nil {
 		return nil, err
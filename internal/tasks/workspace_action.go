@@ -0,0 +1,11 @@
+package tasks
+
+// WorkspaceActionPayload is the payload of the tasks that run a workspace
+// action: start, stop, restart, rebuild and terminate.
+type WorkspaceActionPayload struct {
+	WorkspaceID uint64
+	UserID      uint64
+	// ActionID is the workspace action the task runs; zero for tasks
+	// enqueued before actions were queued per workspace.
+	ActionID uint64
+}
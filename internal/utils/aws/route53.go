@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -51,3 +52,51 @@ func CreateARecord(subdomain string) error {
 	fmt.Println(response)
 	return err
 }
+
+// DeleteARecord removes the record CreateARecord made for the subdomain.
+// A record that doesn't exist is not an error.
+func DeleteARecord(subdomain string) error {
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	r53 := route53.NewFromConfig(cfg)
+
+	hostedZoneID := os.Getenv("HOSTED_ZONE_ID")
+	domain := os.Getenv("REVERSE_PROXY_BASE_URL")
+
+	// A deletion has to match the record exactly, so it is looked up first.
+	recordName := fmt.Sprintf("%s.%s.", subdomain, domain)
+	records, err := r53.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(recordName),
+		StartRecordType: types.RRTypeA,
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return err
+	}
+	if len(records.ResourceRecordSets) == 0 {
+		return nil
+	}
+	record := records.ResourceRecordSets[0]
+	if !strings.EqualFold(aws.ToString(record.Name), recordName) || record.Type != types.RRTypeA {
+		return nil
+	}
+
+	_, err = r53.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+		ChangeBatch: &types.ChangeBatch{
+			Changes: []types.Change{
+				{
+					Action:            types.ChangeActionDelete,
+					ResourceRecordSet: &record,
+				},
+			},
+			Comment: aws.String(fmt.Sprintf("Deleted by Go app at %v", time.Now())),
+		},
+	})
+	return err
+}
@@ -16,6 +16,7 @@ const (
 	ErrorTypeAuth       ErrorType = "AUTHENTICATION"
 	ErrorTypeForbidden  ErrorType = "FORBIDDEN"
 	ErrorTypeBadRequest ErrorType = "BAD_REQUEST"
+	ErrorTypeConflict   ErrorType = "CONFLICT"
 )
 
 // AppError represents a structured application error
@@ -82,6 +83,26 @@ func NewForbiddenError(msg string) *AppError {
 	return NewError(ErrorTypeForbidden, "FORBIDDEN", msg, nil)
 }
 
+func NewConflictError(code string, msg string) *AppError {
+	return NewError(ErrorTypeConflict, code, msg, nil)
+}
+
+// NewQuotaExceededError reports an action refused by a quota; metadata
+// tells clients which limit was hit.
+func NewQuotaExceededError(msg string, metadata any) *AppError {
+	err := NewError(ErrorTypeForbidden, "QUOTA_EXCEEDED", msg, nil)
+	err.Metadata = metadata
+	return err
+}
+
+// NewBudgetExceededError reports an action refused because the
+// organization spent its budget.
+func NewBudgetExceededError(msg string, metadata any) *AppError {
+	err := NewError(ErrorTypeForbidden, "BUDGET_EXCEEDED", msg, nil)
+	err.Metadata = metadata
+	return err
+}
+
 // getHTTPCode maps error types to HTTP status codes
 func getHTTPCode(errType ErrorType) int {
 	fmt.Println(fmt.Sprintf("Mapping error type %s to HTTP code", errType))
@@ -98,6 +119,8 @@ func getHTTPCode(errType ErrorType) int {
 		return http.StatusForbidden
 	case ErrorTypeBadRequest:
 		return http.StatusBadRequest
+	case ErrorTypeConflict:
+		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
 	}
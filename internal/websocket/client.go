@@ -16,6 +16,8 @@ type Client struct {
 	Conn     *websocket.Conn
 	Send     chan []byte
 	Channels map[string]bool
+	// User is the authenticated user the connection belongs to.
+	User *dto.User
 }
 
 var upgrader = websocket.Upgrader{
@@ -27,7 +29,7 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
+func ServeWs(hub *Hub, user *dto.User, w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		// Handle error upgrading connection
@@ -38,6 +40,7 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		Conn:     conn,
 		Send:     make(chan []byte, 256),
 		Channels: make(map[string]bool),
+		User:     user,
 	}
 
 	// Register client
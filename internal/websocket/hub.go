@@ -3,6 +3,7 @@ package websocket
 import (
 	"clusterix-code/internal/data/dto"
 	"clusterix-code/internal/utils/logger"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -18,6 +19,7 @@ type Hub struct {
 	Unregister    chan *Client
 	Shutdown      chan struct{}
 	MessageRouter dto.MessageHandler
+	Authorizer    dto.SubscriptionAuthorizer
 }
 
 func NewHub() *Hub {
@@ -79,6 +81,11 @@ func (h *Hub) Stop() {
 }
 
 func (h *Hub) SubscribeClientToChannel(c *Client, channel string) {
+	if h.Authorizer == nil || !h.Authorizer.CanSubscribe(context.Background(), c.User, channel) {
+		logger.Info("Client refused subscription to channel", zap.String("channel", channel), zap.Uint64("user_id", c.User.ID))
+		return
+	}
+
 	if h.Channels[channel] == nil {
 		h.Channels[channel] = make(map[*Client]bool)
 		logger.Info("Channel created", zap.String("channel", channel))